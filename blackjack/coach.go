@@ -0,0 +1,261 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
+	"github.com/jwambugu/gophercises/deck"
+	"math/rand"
+	"sort"
+)
+
+// decisionStats is how often a situation has come up and how often it was played wrong.
+type decisionStats struct {
+	Key      trainer.Key `json:"key"`
+	Attempts int         `json:"attempts"`
+	Mistakes int         `json:"mistakes"`
+}
+
+// coach checks every decision the player makes against basic strategy.
+type coach struct {
+	strategy trainer.Strategy
+	notify   func(msg string)
+	rand     *rand.Rand
+
+	// session is this run's decisions, history is every decision ever made by the profile being played, if any.
+	session map[string]*decisionStats
+	history map[string]*decisionStats
+}
+
+func newCoach(strategy trainer.Strategy, history map[string]*decisionStats, notify func(string), seed int64) *coach {
+	return &coach{
+		strategy: strategy,
+		notify:   notify,
+		rand:     rand.New(rand.NewSource(seed)),
+		session:  make(map[string]*decisionStats),
+		history:  history,
+	}
+}
+
+func record(stats map[string]*decisionStats, key trainer.Key, mistake bool) {
+	if stats == nil {
+		return
+	}
+
+	s, ok := stats[key.String()]
+	if !ok {
+		s = &decisionStats{Key: key}
+		stats[key.String()] = s
+	}
+
+	s.Attempts++
+	if mistake {
+		s.Mistakes++
+	}
+}
+
+// check records the move the player made with hand, and tells them straight away if basic strategy disagrees.
+func (c *coach) check(hand []deck.Card, dealer deck.Card, move blackjack.Move) {
+	got, ok := blackjack.ActionOf(move)
+	if !ok {
+		return
+	}
+
+	key := trainer.KeyFor(hand, dealer)
+	want := trainer.Recommend(c.strategy, hand, dealer)
+
+	record(c.session, key, got != want)
+	record(c.history, key, got != want)
+
+	if got != want {
+		c.notify(fmt.Sprintf("Basic strategy says %s on %s, not %s. %s", want, key, got, reason(key, want)))
+	}
+}
+
+// reason explains why basic strategy plays key the way it does.
+func reason(k trainer.Key, want blackjack.Action) string {
+	switch {
+	case want == blackjack.ActionSplit && k.Pair && k.Soft:
+		return "Two hands starting with an ace are far stronger than a soft 12."
+	case want == blackjack.ActionSplit && k.Total == 16:
+		return "16 is the worst hand there is, two hands starting with an 8 do much better."
+	case want == blackjack.ActionSplit:
+		return "Each card makes a better start on its own against this dealer card."
+	case want == blackjack.ActionDouble:
+		return "You are likely to improve and the dealer is weak, so get more money on the table."
+	case want == blackjack.ActionHit && !k.Soft && k.Total <= 11:
+		return "You can't bust by taking another card."
+	case want == blackjack.ActionHit && k.Soft:
+		return "An ace counted as 11 can drop back to 1, so hitting can't bust you."
+	case want == blackjack.ActionHit && k.Dealer >= 7:
+		return "The dealer will probably make 17 or better, so standing loses more often."
+	case want == blackjack.ActionStand && !k.Soft && k.Total <= 16 && k.Dealer <= 6:
+		return "The dealer shows a bust card, so let them take the risk."
+	case want == blackjack.ActionStand:
+		return "Your hand is already strong enough that another card does more harm than good."
+	default:
+		return ""
+	}
+}
+
+// accuracy returns how often hard hands, soft hands and pairs were played correctly.
+func accuracy(stats map[string]*decisionStats) trainer.Report {
+	var r trainer.Report
+
+	for _, s := range stats {
+		a := &r.Hard
+		switch {
+		case s.Key.Pair:
+			a = &r.Pairs
+		case s.Key.Soft:
+			a = &r.Soft
+		}
+
+		a.Total += s.Attempts
+		a.Matches += s.Attempts - s.Mistakes
+	}
+
+	return r
+}
+
+// weakest returns up to n situations that were played wrong, the most often wrong first.
+func weakest(stats map[string]*decisionStats, n int) []*decisionStats {
+	var wrong []*decisionStats
+
+	for _, s := range stats {
+		if s.Mistakes > 0 {
+			wrong = append(wrong, s)
+		}
+	}
+
+	sort.Slice(wrong, func(i, j int) bool {
+		a, b := wrong[i], wrong[j]
+		ra, rb := float64(a.Mistakes)/float64(a.Attempts), float64(b.Mistakes)/float64(b.Attempts)
+
+		if ra != rb {
+			return ra > rb
+		}
+
+		return a.Key.String() < b.Key.String()
+	})
+
+	if len(wrong) > n {
+		wrong = wrong[:n]
+	}
+
+	return wrong
+}
+
+var dealerRanks = map[int]deck.Rank{2: deck.Two, 3: deck.Three, 4: deck.Four, 5: deck.Five, 6: deck.Six,
+	7: deck.Seven, 8: deck.Eight, 9: deck.Nine, 10: deck.King, 11: deck.Ace}
+
+// opening returns a two card hand, and a dealer up card, that make up key. Situations that can only come up after
+// hitting, like a hard 4 or a soft 21, which would be a blackjack, can't be dealt.
+func opening(k trainer.Key) ([]deck.Card, deck.Card, bool) {
+	var ranks []deck.Rank
+
+	switch {
+	case k.Pair && k.Soft:
+		ranks = []deck.Rank{deck.Ace, deck.Ace}
+	case k.Pair && k.Total >= 4 && k.Total <= 20 && k.Total%2 == 0:
+		r := deck.Rank(k.Total / 2)
+		ranks = []deck.Rank{r, r}
+	case k.Pair:
+		return nil, deck.Card{}, false
+	case k.Soft && k.Total >= 13 && k.Total <= 20:
+		ranks = []deck.Rank{deck.Ace, deck.Rank(k.Total - 11)}
+	case k.Soft:
+		return nil, deck.Card{}, false
+	case k.Total == 20:
+		ranks = []deck.Rank{deck.Ten, deck.King}
+	case k.Total >= 12 && k.Total <= 19:
+		ranks = []deck.Rank{deck.Ten, deck.Rank(k.Total - 10)}
+	case k.Total >= 5 && k.Total <= 11:
+		ranks = []deck.Rank{deck.Two, deck.Rank(k.Total - 2)}
+	default:
+		return nil, deck.Card{}, false
+	}
+
+	up, ok := dealerRanks[k.Dealer]
+	if !ok {
+		return nil, deck.Card{}, false
+	}
+
+	return []deck.Card{{Suit: deck.Club, Rank: ranks[0]}, {Suit: deck.Heart, Rank: ranks[1]}},
+		deck.Card{Suit: deck.Spade, Rank: up}, true
+}
+
+// drillRules deals the player a chosen situation from the top of every shoe.
+type drillRules struct {
+	blackjack.Rules
+	player []deck.Card
+	dealer deck.Card
+}
+
+func (d drillRules) Shoe(n int, shuffle func([]deck.Card) []deck.Card) []deck.Card {
+	top := []deck.Card{d.player[0], d.dealer, d.player[1]}
+
+	return append(top, d.Rules.Shoe(n, shuffle)...)
+}
+
+// drill returns rules that deal one of the situations played wrong most often, or any situation at random until
+// there have been mistakes to pick from.
+func (c *coach) drill(rules blackjack.Rules) blackjack.Rules {
+	stats := c.history
+	if stats == nil {
+		stats = c.session
+	}
+
+	var candidates []trainer.Key
+	for _, s := range weakest(stats, 5) {
+		candidates = append(candidates, s.Key)
+	}
+
+	for {
+		var k trainer.Key
+
+		if len(candidates) > 0 {
+			k = candidates[c.rand.Intn(len(candidates))]
+		} else {
+			k = trainer.Key{Total: 5 + c.rand.Intn(16), Soft: c.rand.Intn(4) == 0, Dealer: 2 + c.rand.Intn(10)}
+		}
+
+		player, dealer, ok := opening(k)
+		if !ok {
+			candidates = nil
+			continue
+		}
+
+		return drillRules{Rules: rules, player: player, dealer: dealer}
+	}
+}
+
+// percent formats how often decisions were right, or a dash if none were made.
+func percent(a trainer.Agreement) string {
+	if a.Total == 0 {
+		return "-"
+	}
+
+	return fmt.Sprintf("%.1f%%", a.Percent())
+}
+
+// accuracyString describes accuracy by kind of hand, like "hard 90.0%, soft 75.0%, pairs -".
+func accuracyString(r trainer.Report) string {
+	return fmt.Sprintf("hard %s, soft %s, pairs %s", percent(r.Hard), percent(r.Soft), percent(r.Pairs))
+}
+
+// summary describes how accurately the player followed basic strategy.
+func summary(stats map[string]*decisionStats) string {
+	r := accuracy(stats)
+	if r.Overall().Total == 0 {
+		return "No decisions made yet."
+	}
+
+	s := fmt.Sprintf("Strategy accuracy: %s, overall %s", accuracyString(r), percent(r.Overall()))
+
+	for _, w := range weakest(stats, 3) {
+		s += fmt.Sprintf("\n  %s: %d of %d wrong", w.Key, w.Mistakes, w.Attempts)
+	}
+
+	return s
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
+	"testing"
+)
+
+func TestOpening(t *testing.T) {
+	tests := []struct {
+		name string
+		key  trainer.Key
+		ok   bool
+	}{
+		{name: "hard 16", key: trainer.Key{Total: 16, Dealer: 10}, ok: true},
+		{name: "hard 4", key: trainer.Key{Total: 4, Dealer: 10}},
+		{name: "soft 20", key: trainer.Key{Total: 20, Soft: true, Dealer: 6}, ok: true},
+		{name: "soft 21", key: trainer.Key{Total: 21, Soft: true, Dealer: 6}},
+		{name: "pair of aces", key: trainer.Key{Total: 12, Soft: true, Pair: true, Dealer: 11}, ok: true},
+		{name: "pair of 8s", key: trainer.Key{Total: 16, Pair: true, Dealer: 2}, ok: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hand, dealer, ok := opening(tt.key)
+			if ok != tt.ok {
+				t.Fatalf("opening(%+v): want %v, got %v", tt.key, tt.ok, ok)
+			}
+
+			if ok && trainer.KeyFor(hand, dealer) != tt.key {
+				t.Errorf("opening(%+v): dealt %v against %v", tt.key, hand, dealer)
+			}
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
+	"github.com/jwambugu/gophercises/deck"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// countDecks is the number of decks in the shoe counting drills deal from.
+const countDecks = 6
+
+// countDrill flashes cards one at a time and then asks for the Hi-Lo running count, for the given number of rounds.
+// Every round flashes up to a whole shoe of cards.
+func countDrill(in io.Reader, out io.Writer, rounds, cards int, delay time.Duration) error {
+	if rounds < 1 {
+		return fmt.Errorf("counting drills need at least 1 round, got %d", rounds)
+	}
+
+	if max := countDecks * 52; cards < 1 || cards > max {
+		return fmt.Errorf("counting drills flash between 1 and %d cards, got %d", max, cards)
+	}
+
+	scanner := bufio.NewScanner(in)
+	shoe := deck.New(deck.Deck(countDecks), deck.Shuffle)
+	correct := 0
+
+	fmt.Fprintln(out, "Keep the Hi-Lo running count: +1 for 2 to 6, 0 for 7 to 9, -1 for tens and aces.")
+
+	for i := 0; i < rounds; i++ {
+		if len(shoe) < cards {
+			shoe = deck.New(deck.Deck(countDecks), deck.Shuffle)
+		}
+
+		flashed := shoe[:cards]
+		shoe = shoe[cards:]
+
+		fmt.Fprintf(out, "Round %d, press enter to start.\n", i+1)
+		if !scanner.Scan() {
+			return nil
+		}
+
+		for _, c := range flashed {
+			fmt.Fprintf(out, "\r\033[K  %s", c)
+			time.Sleep(delay)
+		}
+
+		fmt.Fprint(out, "\r\033[K[?] What's the running count?\n")
+
+		if !scanner.Scan() {
+			return nil
+		}
+
+		want := trainer.RunningCount(flashed...)
+		got, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+
+		if err == nil && got == want {
+			correct++
+			fmt.Fprintln(out, "Correct!")
+			continue
+		}
+
+		fmt.Fprintf(out, "The count was %d:\n", want)
+		for _, c := range flashed {
+			fmt.Fprintf(out, "  %+d  %s\n", trainer.HiLo(c), c)
+		}
+	}
+
+	fmt.Fprintf(out, "You got %d of %d counts right.\n", correct, rounds)
+
+	return nil
+}
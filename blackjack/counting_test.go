@@ -0,0 +1,37 @@
+package main
+
+import (
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestCountDrill_invalid(t *testing.T) {
+	tests := []struct {
+		name   string
+		rounds int
+		cards  int
+	}{
+		{name: "no rounds", rounds: 0, cards: 10},
+		{name: "no cards", rounds: 1, cards: 0},
+		{name: "more cards than the shoe", rounds: 1, cards: countDecks*52 + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := countDrill(strings.NewReader("\n0\n"), ioutil.Discard, tt.rounds, tt.cards, 0); err == nil {
+				t.Errorf("countDrill(%d rounds, %d cards): want an error", tt.rounds, tt.cards)
+			}
+		})
+	}
+
+	var out strings.Builder
+	if err := countDrill(strings.NewReader("\n0\n"), &out, 1, countDecks*52, 0); err != nil {
+		t.Fatalf("countDrill() received an error for a whole shoe: %s", err)
+	}
+
+	// A whole shoe always counts to 0.
+	if !strings.Contains(out.String(), "You got 1 of 1 counts right.") {
+		t.Errorf("expected the count of a whole shoe to be 0, got %q", out.String())
+	}
+}
@@ -1,212 +1,394 @@
 package main
 
 import (
+	"bufio"
+	"flag"
 	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
 	"github.com/jwambugu/gophercises/deck"
+	"io"
+	"log"
+	"os"
+	"sort"
 	"strings"
+	"time"
 )
 
-type Hand []deck.Card
+var rules = map[string]blackjack.Rules{
+	"standard":        blackjack.Standard{},
+	"spanish21":       blackjack.Spanish21{},
+	"switch":          blackjack.Switch{},
+	"double-exposure": blackjack.DoubleExposure{},
+}
 
-type GameState struct {
-	Deck   []deck.Card
-	State  State
-	Player Hand
-	Dealer Hand
+var dealers = map[string]func() blackjack.Dealer{
+	"h17": blackjack.H17,
+	"s17": blackjack.S17,
 }
 
-type State int8
+// session is what has happened since the CLI was started.
+type session struct {
+	bankroll   int
+	hands      int
+	wins       int
+	losses     int
+	pushes     int
+	net        int
+	biggestWin int
+}
 
-const (
-	StatePlayerTurn State = iota
-	StateDealerTurn
-	StateHandOver
-)
+func (s *session) record(won int) {
+	s.bankroll += won
+	s.net += won
+	s.hands++
 
-func (h Hand) String() string {
-	strs := make([]string, len(h))
+	switch {
+	case won > 0:
+		s.wins++
+	case won < 0:
+		s.losses++
+	default:
+		s.pushes++
+	}
 
-	for i := range h {
-		strs[i] = h[i].String()
+	if won > s.biggestWin {
+		s.biggestWin = won
 	}
-	return strings.Join(strs, ", ")
 }
 
-func min(a, b int) int {
-	if a < b {
-		return a
-	}
+// frontend is how the person at the terminal sees the game and plays it.
+type frontend interface {
+	blackjack.AI
+
+	// Ready is called before every round, and returns false if the player wants to stop.
+	Ready(s *session, penetration float64) bool
 
-	return b
+	// RoundOver is called once a round has been settled.
+	RoundOver(won int, s *session)
+
+	// Notify tells the player something outside of the normal flow of the game.
+	Notify(msg string)
+
+	// Close restores the terminal.
+	Close()
 }
 
-func (h Hand) MinScore() int {
-	score := 0
+// text is the plain, line by line frontend.
+type text struct {
+	blackjack.AI
+	in *bufio.Scanner
+}
 
-	for _, c := range h {
-		score += min(int(c.Rank), 10)
-	}
+// newText returns the text frontend, reading from in.
+func newText(in io.Reader) text {
+	scanner := bufio.NewScanner(in)
 
-	return score
+	return text{
+		AI: blackjack.NewHumanAI(&lineReader{in: scanner}, os.Stdout),
+		in: scanner,
+	}
 }
 
-func (h Hand) Score() int {
-	minScore := h.MinScore()
+// Ready waits for enter to deal the next round. Typing q, or running out of input, stops the game.
+func (t text) Ready(s *session, penetration float64) bool {
+	fmt.Printf("Bankroll: $%d | Shoe: %.0f%% dealt\n", s.bankroll, penetration*100)
+	fmt.Println("[?] Press enter to deal, or (q)uit")
 
-	if minScore > 11 {
-		return minScore
+	if !t.in.Scan() {
+		return false
 	}
 
-	for _, c := range h {
-		if c.Rank == deck.Ace {
-			// Ace is currently worth 1 point, we are making it 11 points
-			return minScore + 10
-		}
+	return !strings.EqualFold(strings.TrimSpace(t.in.Text()), "q")
+}
+
+func (t text) RoundOver(won int, s *session) {
+	switch {
+	case won > 0:
+		fmt.Printf("You won $%d!\n\n", won)
+	case won < 0:
+		fmt.Printf("You lost $%d!\n\n", -won)
+	default:
+		fmt.Print("Push!\n\n")
 	}
+}
 
-	return minScore
+func (t text) Notify(msg string) {
+	fmt.Println("[!]", msg)
 }
 
-func draw(cards []deck.Card) (deck.Card, []deck.Card) {
-	return cards[0], cards[1:]
+func (t text) Close() {
+	// Nothing to restore
 }
 
-func (h Hand) DealerString() string {
-	return h[0].String() + ", **HIDDEN**"
+// lineReader hands out the lines a scanner reads one at a time, so the text frontend and the AI it wraps can share
+// stdin without either of them buffering input meant for the other.
+type lineReader struct {
+	in   *bufio.Scanner
+	line []byte
 }
 
-func clone(gs GameState) GameState {
-	s := GameState{
-		Deck:   make([]deck.Card, len(gs.Deck)),
-		State:  gs.State,
-		Player: make(Hand, len(gs.Player)),
-		Dealer: make(Hand, len(gs.Dealer)),
+func (r *lineReader) Read(p []byte) (int, error) {
+	if len(r.line) == 0 {
+		if !r.in.Scan() {
+			if err := r.in.Err(); err != nil {
+				return 0, err
+			}
+
+			return 0, io.EOF
+		}
+
+		r.line = append([]byte(r.in.Text()), '\n')
 	}
 
-	copy(s.Deck, gs.Deck)
-	copy(s.Player, gs.Player)
-	copy(s.Dealer, gs.Dealer)
+	n := copy(p, r.line)
+	r.line = r.line[n:]
 
-	return s
+	return n, nil
 }
 
-func (gs *GameState) CurrentPlayer() *Hand {
-	switch gs.State {
-	case StatePlayerTurn:
-		return &gs.Player
-	case StateDealerTurn:
-		return &gs.Dealer
-	default:
-		panic("it isn't currently any player's turn")
+// player is the person at the terminal. It stops them from betting more than their bankroll and, when training,
+// has every decision they make checked by a coach.
+type player struct {
+	frontend
+	session *session
+	hands   int
+	coach   *coach
+}
+
+func (p *player) Bet(shuffled bool) int {
+	for {
+		// The bet is placed on every hand dealt, which is more than one in some variants.
+		bet := p.frontend.Bet(shuffled)
+		if bet*p.hands <= p.session.bankroll {
+			return bet
+		}
+
+		fmt.Printf("You can't bet more than your bankroll of $%d across %d hand(s)\n", p.session.bankroll, p.hands)
+		shuffled = false
 	}
 }
 
-func Shuffle(gs GameState) GameState {
-	s := clone(gs)
-	s.Deck = deck.New(deck.Deck(3), deck.Shuffle)
+func (p *player) Switch(hands [][]deck.Card, dealer deck.Card) bool {
+	if switcher, ok := p.frontend.(blackjack.Switcher); ok {
+		return switcher.Switch(hands, dealer)
+	}
 
-	return s
+	return false
 }
 
-func Deal(gs GameState) GameState {
-	s := clone(gs)
-	s.Player = make(Hand, 0, 5)
-	s.Dealer = make(Hand, 0, 5)
+func (p *player) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	move := p.frontend.Play(hand, dealer)
+	p.check(hand, dealer, move)
 
-	var card deck.Card
+	return move
+}
 
-	for i := 0; i < 2; i++ {
-		card, s.Deck = draw(s.Deck)
-		s.Player = append(s.Player, card)
+func (p *player) PlayExposed(hand []deck.Card, dealer []deck.Card) blackjack.Move {
+	var move blackjack.Move
 
-		card, s.Deck = draw(s.Deck)
-		s.Dealer = append(s.Dealer, card)
+	if exposed, ok := p.frontend.(blackjack.ExposedAI); ok {
+		move = exposed.PlayExposed(hand, dealer)
+	} else {
+		move = p.frontend.Play(hand, dealer[0])
 	}
 
-	s.State = StatePlayerTurn
-	return s
+	p.check(hand, dealer[0], move)
+
+	return move
+}
+
+func (p *player) check(hand []deck.Card, dealer deck.Card, move blackjack.Move) {
+	if p.coach != nil {
+		p.coach.check(hand, dealer, move)
+	}
 }
 
-func Hit(gs GameState) GameState {
-	s := clone(gs)
-	hand := s.CurrentPlayer()
+func names(m map[string]blackjack.Rules) string {
+	strs := make([]string, 0, len(m))
+
+	for name := range m {
+		strs = append(strs, name)
+	}
 
-	var card deck.Card
-	card, s.Deck = draw(s.Deck)
+	sort.Strings(strs)
 
-	*hand = append(*hand, card)
-	return s
+	return strings.Join(strs, ", ")
 }
 
-func Stand(gs GameState) GameState {
-	s := clone(gs)
-	s.State++
-	return s
+// stats is the stats subcommand, which prints how every profile has done over time.
+func stats(args []string) {
+	var storePath, name string
+	var sessions int
+
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	fs.StringVar(&storePath, "store", defaultStorePath(), "the file profiles are kept in")
+	fs.StringVar(&name, "profile", "", "only show the profile with this name")
+	fs.IntVar(&sessions, "sessions", 10, "the number of recent sessions to list for each profile")
+	_ = fs.Parse(args)
+
+	s, err := openStore(storePath)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if err := printStats(os.Stdout, s, name, sessions); err != nil {
+		log.Fatal(err)
+	}
 }
 
-func EndHand(gs GameState) GameState {
-	s := clone(gs)
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "stats" {
+		stats(os.Args[2:])
+		return
+	}
 
-	playerScore, dealerScore := gs.Player.Score(), gs.Dealer.Score()
+	var decks, hands, bankroll, countRounds, countCards int
+	var ruleSet, dealer, name, storePath, train string
+	var fullScreen bool
+	var countDelay time.Duration
+
+	flag.IntVar(&decks, "decks", 3, "the number of decks in the shoe")
+	flag.IntVar(&hands, "hands", 10, "the number of hands to play, 0 to play until you run out of money")
+	flag.IntVar(&bankroll, "bankroll", 1000, "the amount of money you start with")
+	flag.StringVar(&ruleSet, "rules", "standard", "the rule set to play: "+names(rules))
+	flag.StringVar(&dealer, "dealer", "h17", "whether the dealer hits (h17) or stands (s17) on a soft 17")
+	flag.BoolVar(&fullScreen, "tui", false, "play in a full screen terminal UI")
+	flag.StringVar(&name, "profile", "", "the profile to play as, keeping its bankroll and history between runs")
+	flag.StringVar(&storePath, "store", defaultStorePath(), "the file profiles are kept in")
+	flag.StringVar(&train, "train", "", "a training mode: play (check every decision against basic strategy), "+
+		"drill (only deal the situations you get wrong most) or count (card counting drills). Basic strategy is "+
+		"charted for standard rules, so it is only a guide for the other rule sets")
+	flag.IntVar(&countRounds, "count-rounds", 10, "the number of counting drills to do")
+	flag.IntVar(&countCards, "count-cards", 10, "the number of cards flashed in each counting drill")
+	flag.DurationVar(&countDelay, "count-delay", time.Second, "how long each card is flashed for in counting drills")
+	flag.Parse()
+
+	switch train {
+	case "", "play", "drill":
+	case "count":
+		if err := countDrill(os.Stdin, os.Stdout, countRounds, countCards, countDelay); err != nil {
+			log.Fatal(err)
+		}
+		return
+	default:
+		log.Fatalf("unknown training mode %q, expected play, drill or count", train)
+	}
 
-	fmt.Println("==FINAL HANDS==")
-	fmt.Println("Player:", gs.Player, "\nScore: ", playerScore)
-	fmt.Println("Dealer:", gs.Player, "\nScore: ", dealerScore)
+	r, ok := rules[ruleSet]
+	if !ok {
+		log.Fatalf("unknown rule set %q, expected one of %s", ruleSet, names(rules))
+	}
 
-	switch {
-	case playerScore > 21:
-		fmt.Println("You busted!")
-	case dealerScore > 21:
-		fmt.Println("Dealer busted!")
-	case playerScore > dealerScore:
-		fmt.Println("You win!")
-	case dealerScore > playerScore:
-		fmt.Println("You lose!")
-	case dealerScore == playerScore:
-		fmt.Println("You draw!")
+	d, ok := dealers[dealer]
+	if !ok {
+		log.Fatalf("unknown dealer %q, expected h17 or s17", dealer)
 	}
 
-	fmt.Println()
+	if train == "drill" && r.Hands() != 1 {
+		log.Fatalf("drills need a rule set that deals a single hand")
+	}
 
-	s.Player = nil
-	s.Dealer = nil
-	return s
-}
+	game := blackjack.New(blackjack.Options{
+		Decks:  decks,
+		Rules:  r,
+		Dealer: d(),
+	})
 
-func main() {
-	var gs GameState
-	gs = Shuffle(gs)
-
-	for i := 0; i < 10; i++ {
-		gs = Deal(gs)
-		var input string
-		for gs.State == StatePlayerTurn {
-			fmt.Println("Player:", gs.Player)
-			fmt.Println("Dealer:", gs.Dealer.DealerString())
-
-			fmt.Println("What will you do? (h)it, (s)tand")
-			_, _ = fmt.Scanf("%s\n", &input)
-
-			switch input {
-			case "h":
-				gs = Hit(gs)
-			case "s":
-				gs = Stand(gs)
-			default:
-				fmt.Println("Invalid option: ", input)
-			}
+	s := &session{bankroll: bankroll}
+	start := time.Now()
+
+	var profiles *store
+	var prof *profile
+
+	if name != "" {
+		var err error
+
+		profiles, err = openStore(storePath)
+		if err != nil {
+			log.Fatal(err)
 		}
 
-		for gs.State == StateDealerTurn {
-			// If dealer score <= 16, hit || If dealer has a sort 17, hit.
-			// Soft 17 is when an ace as 11 and the score is 17
-			if gs.Dealer.Score() <= 16 || (gs.Dealer.Score() == 17 && gs.Dealer.MinScore() != 17) {
-				gs = Hit(gs)
-			} else {
-				gs = Stand(gs)
-			}
+		prof = profiles.profile(name, bankroll)
+
+		if prof.Bankroll < blackjack.MinBet*r.Hands() {
+			fmt.Printf("%s is out of money, starting again with $%d.\n", name, bankroll)
+			prof.Bankroll = bankroll
+		}
+
+		s.bankroll = prof.Bankroll
+		fmt.Printf("Playing as %s with $%d.\n", name, s.bankroll)
+	}
+
+	var ui frontend = newText(os.Stdin)
+
+	if fullScreen {
+		t, err := newTUI(r.Hands())
+		if err != nil {
+			log.Fatalf("failed to start the terminal UI: %v", err)
+		}
+		// Restores the terminal if the game panics. It's closed as usual below otherwise.
+		defer t.Close()
+
+		ui = t
+	}
+
+	p := &player{
+		frontend: ui,
+		session:  s,
+		hands:    r.Hands(),
+	}
+
+	if train != "" {
+		var history map[string]*decisionStats
+		if prof != nil {
+			history = prof.training()
+		}
+
+		p.coach = newCoach(trainer.Basic{S17: dealer == "s17"}, history, ui.Notify, start.UnixNano())
+	}
+
+	for i := 0; hands == 0 || i < hands; i++ {
+		if s.bankroll < blackjack.MinBet*p.hands {
+			break
+		}
+
+		if !ui.Ready(s, game.Penetration()) {
+			break
+		}
+
+		if train == "drill" {
+			game = blackjack.New(blackjack.Options{
+				Decks:  decks,
+				Rules:  p.coach.drill(r),
+				Dealer: d(),
+			})
 		}
 
-		gs = EndHand(gs)
+		won := game.Round(p)[0]
+		s.record(won)
+
+		ui.RoundOver(won, s)
+	}
+
+	ui.Close()
+
+	if s.bankroll < blackjack.MinBet*p.hands {
+		fmt.Println("You don't have enough money left to place a bet.")
+	}
+
+	fmt.Printf("You finished with $%d after %d hand(s): %d won, %d lost, %d pushed.\n",
+		s.bankroll, s.hands, s.wins, s.losses, s.pushes)
+
+	if p.coach != nil {
+		fmt.Println(summary(p.coach.session))
+	}
+
+	if prof != nil {
+		prof.record(s, ruleSet, start)
+
+		if err := profiles.save(); err != nil {
+			log.Fatalf("failed to save %s's profile: %v", name, err)
+		}
 	}
 }
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestText_Ready(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "enter", input: "\n", want: true},
+		{name: "quit", input: "q\n", want: false},
+		{name: "quit in capitals", input: " Q \n", want: false},
+		{name: "no input", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := newText(strings.NewReader(tt.input)).Ready(&session{}, 0); got != tt.want {
+				t.Errorf("Ready() after %q: want %v, got %v", tt.input, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestText_sharedInput(t *testing.T) {
+	ui := newText(strings.NewReader("\n200\n"))
+
+	if !ui.Ready(&session{}, 0) {
+		t.Fatalf("expected enter to deal")
+	}
+
+	// The bet is read by the AI from the same input, after the line Ready read.
+	if got := ui.Bet(false); got != 200 {
+		t.Errorf("Bet(): want %d, got %d", 200, got)
+	}
+}
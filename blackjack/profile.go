@@ -0,0 +1,251 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"text/tabwriter"
+	"time"
+)
+
+type (
+	// profile is a named player whose bankroll and history carry over between runs.
+	profile struct {
+		Name       string       `json:"name"`
+		Bankroll   int          `json:"bankroll"`
+		Wins       int          `json:"wins"`
+		Losses     int          `json:"losses"`
+		Pushes     int          `json:"pushes"`
+		BiggestWin int          `json:"biggest_win"`
+		Sessions   []sessionLog `json:"sessions"`
+		// Training is every decision checked against basic strategy, by situation.
+		Training map[string]*decisionStats `json:"training,omitempty"`
+	}
+
+	// sessionLog is a record of a single run of the CLI.
+	sessionLog struct {
+		Start      time.Time `json:"start"`
+		End        time.Time `json:"end"`
+		Rules      string    `json:"rules"`
+		Hands      int       `json:"hands"`
+		Wins       int       `json:"wins"`
+		Losses     int       `json:"losses"`
+		Pushes     int       `json:"pushes"`
+		Net        int       `json:"net"`
+		BiggestWin int       `json:"biggest_win"`
+		Bankroll   int       `json:"bankroll"`
+	}
+
+	// store keeps every profile in a single JSON file.
+	store struct {
+		path     string
+		Profiles map[string]*profile `json:"profiles"`
+	}
+)
+
+// defaultStorePath returns where profiles are kept when no path is given.
+func defaultStorePath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = "."
+	}
+
+	return filepath.Join(dir, "gophercises-blackjack", "profiles.json")
+}
+
+// openStore loads the profiles saved at path. A missing file is an empty store.
+func openStore(path string) (*store, error) {
+	s := &store{
+		path:     path,
+		Profiles: make(map[string]*profile),
+	}
+
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	if err := json.NewDecoder(f).Decode(s); err != nil {
+		return nil, fmt.Errorf("failed to read profiles from %s: %w", path, err)
+	}
+
+	if s.Profiles == nil {
+		s.Profiles = make(map[string]*profile)
+	}
+
+	return s, nil
+}
+
+// profile returns the profile called name, creating it with bankroll if it doesn't exist yet.
+func (s *store) profile(name string, bankroll int) *profile {
+	p, ok := s.Profiles[name]
+	if !ok {
+		p = &profile{
+			Name:     name,
+			Bankroll: bankroll,
+		}
+
+		s.Profiles[name] = p
+	}
+
+	return p
+}
+
+// save writes every profile back to disk. The file is replaced in one go so a crash can't leave it half written.
+func (s *store) save() error {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), ".profiles-*.json")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	enc := json.NewEncoder(tmp)
+	enc.SetIndent("", "  ")
+
+	if err := enc.Encode(s); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// record adds a finished session to the profile's lifetime totals.
+func (p *profile) record(s *session, rules string, start time.Time) {
+	p.Bankroll = s.bankroll
+	p.Wins += s.wins
+	p.Losses += s.losses
+	p.Pushes += s.pushes
+
+	if s.biggestWin > p.BiggestWin {
+		p.BiggestWin = s.biggestWin
+	}
+
+	p.Sessions = append(p.Sessions, sessionLog{
+		Start:      start,
+		End:        time.Now(),
+		Rules:      rules,
+		Hands:      s.hands,
+		Wins:       s.wins,
+		Losses:     s.losses,
+		Pushes:     s.pushes,
+		Net:        s.net,
+		BiggestWin: s.biggestWin,
+		Bankroll:   s.bankroll,
+	})
+}
+
+// training returns the profile's training history, ready to be added to.
+func (p *profile) training() map[string]*decisionStats {
+	if p.Training == nil {
+		p.Training = make(map[string]*decisionStats)
+	}
+
+	return p.Training
+}
+
+func (p *profile) hands() int {
+	return p.Wins + p.Losses + p.Pushes
+}
+
+func (p *profile) net() int {
+	net := 0
+
+	for _, s := range p.Sessions {
+		net += s.Net
+	}
+
+	return net
+}
+
+// printStats writes the lifetime totals of every profile in s, or only of name if it is set, followed by their most
+// recent sessions.
+func printStats(w io.Writer, s *store, name string, sessions int) error {
+	if sessions < 0 {
+		return fmt.Errorf("the number of sessions to list can't be negative, got %d", sessions)
+	}
+
+	var profiles []*profile
+
+	for _, p := range s.Profiles {
+		if name == "" || p.Name == name {
+			profiles = append(profiles, p)
+		}
+	}
+
+	if len(profiles) == 0 {
+		if name != "" {
+			return fmt.Errorf("no profile called %q", name)
+		}
+
+		_, err := fmt.Fprintln(w, "No profiles yet. Play with -profile <name> to create one.")
+		return err
+	}
+
+	sort.Slice(profiles, func(i, j int) bool {
+		return profiles[i].Name < profiles[j].Name
+	})
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+
+	for _, p := range profiles {
+		winRate := 0.0
+		if p.hands() > 0 {
+			winRate = float64(p.Wins) * 100 / float64(p.hands())
+		}
+
+		fmt.Fprintf(tw, "%s\n", p.Name)
+		fmt.Fprintf(tw, "  Bankroll\t$%d\n", p.Bankroll)
+		fmt.Fprintf(tw, "  Hands\t%d\n", p.hands())
+		fmt.Fprintf(tw, "  W / L / P\t%d / %d / %d (%.1f%% won)\n", p.Wins, p.Losses, p.Pushes, winRate)
+		fmt.Fprintf(tw, "  Net\t%+d\n", p.net())
+		fmt.Fprintf(tw, "  Biggest win\t$%d\n", p.BiggestWin)
+		fmt.Fprintf(tw, "  Sessions\t%d\n", len(p.Sessions))
+
+		if len(p.Training) > 0 {
+			fmt.Fprintf(tw, "  Strategy\t%s\n", accuracyString(accuracy(p.Training)))
+
+			for _, w := range weakest(p.Training, 3) {
+				fmt.Fprintf(tw, "  \t%s: %d of %d wrong\n", w.Key, w.Mistakes, w.Attempts)
+			}
+		}
+
+		fmt.Fprintln(tw)
+
+		recent := p.Sessions
+		if len(recent) > sessions {
+			recent = recent[len(recent)-sessions:]
+		}
+
+		if len(recent) > 0 {
+			fmt.Fprintln(tw, "  Date\tRules\tHands\tW/L/P\tNet\tBankroll")
+		}
+
+		for i := len(recent) - 1; i >= 0; i-- {
+			s := recent[i]
+			fmt.Fprintf(tw, "  %s\t%s\t%d\t%d/%d/%d\t%+d\t$%d\n", s.Start.Format("2006-01-02 15:04"), s.Rules,
+				s.Hands, s.Wins, s.Losses, s.Pushes, s.Net, s.Bankroll)
+		}
+
+		fmt.Fprintln(tw)
+	}
+
+	return tw.Flush()
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testStore returns an empty store kept in a temporary directory.
+func testStore(t *testing.T) *store {
+	t.Helper()
+
+	s, err := openStore(filepath.Join(t.TempDir(), "blackjack", "profiles.json"))
+	if err != nil {
+		t.Fatalf("openStore() received an error: %s", err)
+	}
+
+	return s
+}
+
+// play records a session of the given results for the profile called name, creating it if needed.
+func play(s *store, name string, start time.Time, results ...int) *profile {
+	ss := &session{bankroll: 1000}
+	for _, won := range results {
+		ss.record(won)
+	}
+
+	p := s.profile(name, 1000)
+	p.record(ss, "standard", start)
+
+	return p
+}
+
+func TestStore(t *testing.T) {
+	s := testStore(t)
+	if len(s.Profiles) != 0 {
+		t.Fatalf("expected a missing file to be an empty store, got %d profiles", len(s.Profiles))
+	}
+
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	alice := play(s, "alice", start, 100, -50, 0, 150)
+
+	if got := s.profile("alice", 500); got != alice {
+		t.Errorf("expected the existing profile to be returned")
+	}
+
+	if err := s.save(); err != nil {
+		t.Fatalf("save() received an error: %s", err)
+	}
+
+	reopened, err := openStore(s.path)
+	if err != nil {
+		t.Fatalf("openStore() received an error: %s", err)
+	}
+
+	got := reopened.profile("alice", 500)
+
+	if got.Bankroll != 1200 || got.Wins != 2 || got.Losses != 1 || got.Pushes != 1 || got.BiggestWin != 150 {
+		t.Errorf("expected the totals to be kept, got %+v", got)
+	}
+	if len(got.Sessions) != 1 || got.Sessions[0].Net != 200 || !got.Sessions[0].Start.Equal(start) {
+		t.Errorf("expected the session to be kept, got %+v", got.Sessions)
+	}
+	if got.hands() != 4 || got.net() != 200 {
+		t.Errorf("hands and net: want %d and %d, got %d and %d", 4, 200, got.hands(), got.net())
+	}
+}
+
+func TestOpenStore_invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "profiles.json")
+
+	if err := ioutil.WriteFile(path, []byte(`{"profiles": `), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := openStore(path); err == nil {
+		t.Errorf("expected an error for a broken profiles file")
+	}
+}
+
+func TestPrintStats(t *testing.T) {
+	s := testStore(t)
+
+	var out strings.Builder
+	if err := printStats(&out, s, "", 10); err != nil || !strings.Contains(out.String(), "No profiles yet") {
+		t.Errorf("expected an empty store to say so, got %q and %v", out.String(), err)
+	}
+
+	start := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		play(s, "alice", start.AddDate(0, 0, i), 100)
+	}
+	play(s, "bob", start, -100)
+
+	tests := []struct {
+		name     string
+		profile  string
+		sessions int
+		want     []string
+		wantNot  []string
+	}{
+		{
+			name:     "every profile",
+			sessions: 10,
+			want:     []string{"alice", "bob", "2020-06-01 12:00", "2020-06-03 12:00"},
+		},
+		{
+			name:     "one profile",
+			profile:  "bob",
+			sessions: 10,
+			want:     []string{"bob", "0 / 1 / 0 (0.0% won)"},
+			wantNot:  []string{"alice"},
+		},
+		{
+			name:     "most recent sessions",
+			profile:  "alice",
+			sessions: 1,
+			want:     []string{"2020-06-03 12:00"},
+			wantNot:  []string{"2020-06-02 12:00", "2020-06-01 12:00"},
+		},
+		{
+			name:     "no sessions",
+			profile:  "alice",
+			sessions: 0,
+			want:     []string{"+300"},
+			wantNot:  []string{"Date", "2020-06"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out strings.Builder
+			if err := printStats(&out, s, tt.profile, tt.sessions); err != nil {
+				t.Fatalf("printStats() received an error: %s", err)
+			}
+
+			for _, want := range tt.want {
+				if !strings.Contains(out.String(), want) {
+					t.Errorf("expected %q in %q", want, out.String())
+				}
+			}
+			for _, unwanted := range tt.wantNot {
+				if strings.Contains(out.String(), unwanted) {
+					t.Errorf("expected no %q in %q", unwanted, out.String())
+				}
+			}
+		})
+	}
+
+	if err := printStats(ioutil.Discard, s, "carol", 10); err == nil {
+		t.Errorf("expected an error for a missing profile")
+	}
+	if err := printStats(ioutil.Discard, s, "", -1); err == nil {
+		t.Errorf("expected an error for a negative number of sessions")
+	}
+}
@@ -0,0 +1,364 @@
+package main
+
+import (
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+	"io"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+const (
+	clearScreen = "\033[H\033[2J"
+	hideCursor  = "\033[?25l"
+	showCursor  = "\033[?25h"
+	red         = "\033[31m"
+	bold        = "\033[1m"
+	reset       = "\033[0m"
+)
+
+var (
+	rankLabels  = [...]string{"", "A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+	suitSymbols = [...]string{"♠", "♦", "♣", "♥"}
+)
+
+// tui is the full screen frontend. It draws the table with card art and reads single key presses, so the terminal is
+// switched out of line mode while it runs.
+type tui struct {
+	in      io.Reader
+	out     io.Writer
+	restore string
+	delay   time.Duration
+	signals chan os.Signal
+	closed  sync.Once
+
+	handsPerBet int
+	bet         int
+	message     string
+	session     *session
+
+	// The last round's final hands, shown until the next round is dealt.
+	hands  [][]deck.Card
+	dealer []deck.Card
+}
+
+// stty runs stty against the terminal attached to stdin.
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+// newTUI switches the terminal to read single key presses, without echoing them. hands is the number of hands every
+// bet is placed on. The terminal is restored by Close, or when the program is interrupted or terminated.
+func newTUI(hands int) (*tui, error) {
+	saved, err := stty("-g")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := stty("-icanon", "-echo", "min", "1"); err != nil {
+		// Some of the settings may have been applied.
+		_, _ = stty(saved)
+		return nil, err
+	}
+
+	t := &tui{
+		in:          os.Stdin,
+		out:         os.Stdout,
+		restore:     saved,
+		delay:       400 * time.Millisecond,
+		handsPerBet: hands,
+		bet:         blackjack.MinBet,
+		signals:     make(chan os.Signal, 1),
+	}
+
+	signal.Notify(t.signals, os.Interrupt, syscall.SIGTERM)
+	go t.exitOnSignal()
+
+	fmt.Fprint(t.out, hideCursor)
+
+	return t, nil
+}
+
+// exitOnSignal restores the terminal and exits when the program is interrupted or terminated, which would otherwise
+// leave the shell without echo.
+func (t *tui) exitOnSignal() {
+	if _, ok := <-t.signals; !ok {
+		return
+	}
+
+	t.Close()
+	os.Exit(1)
+}
+
+// Close restores the terminal. It is safe to call more than once.
+func (t *tui) Close() {
+	t.closed.Do(func() {
+		signal.Stop(t.signals)
+		close(t.signals)
+
+		fmt.Fprint(t.out, showCursor+clearScreen)
+		_, _ = stty(t.restore)
+	})
+}
+
+// key waits for a single key press and returns it in lower case. Running out of input reads as q.
+func (t *tui) key() byte {
+	var b [1]byte
+
+	if n, err := t.in.Read(b[:]); err != nil || n == 0 {
+		return 'q'
+	}
+
+	if b[0] >= 'A' && b[0] <= 'Z' {
+		return b[0] + 'a' - 'A'
+	}
+
+	return b[0]
+}
+
+// art draws cards side by side, five lines high. Cards from hidden on are drawn face down.
+func art(cards []deck.Card, hidden int) []string {
+	lines := make([]string, 5)
+
+	for i, c := range cards {
+		if i >= hidden {
+			lines[0] += "┌─────┐"
+			lines[1] += "│░░░░░│"
+			lines[2] += "│░░░░░│"
+			lines[3] += "│░░░░░│"
+			lines[4] += "└─────┘"
+			continue
+		}
+
+		rank, suit := rankLabels[c.Rank], suitSymbols[c.Suit]
+		color := ""
+		if c.Suit == deck.Heart || c.Suit == deck.Diamond {
+			color = red
+		}
+
+		lines[0] += "┌─────┐"
+		lines[1] += fmt.Sprintf("│%s%-5s%s│", color, rank, reset)
+		lines[2] += fmt.Sprintf("│  %s%s%s  │", color, suit, reset)
+		lines[3] += fmt.Sprintf("│%s%5s%s│", color, rank, reset)
+		lines[4] += "└─────┘"
+	}
+
+	return lines
+}
+
+// screen is everything drawn on a single frame.
+type screen struct {
+	dealer []deck.Card
+	hidden bool
+	hands  [][]deck.Card
+	active int
+	prompt string
+}
+
+func (t *tui) draw(s screen) {
+	var b strings.Builder
+
+	b.WriteString(clearScreen)
+	b.WriteString(bold + " BLACKJACK" + reset + "\n\n")
+
+	if len(s.dealer) > 0 {
+		visible := len(s.dealer)
+		label := fmt.Sprintf(" Dealer (%d)", blackjack.Score(s.dealer...))
+
+		if s.hidden {
+			visible = 1
+			label = fmt.Sprintf(" Dealer (%d + ?)", blackjack.Score(s.dealer[0]))
+		}
+
+		b.WriteString(label + "\n")
+		for _, line := range art(s.dealer, visible) {
+			b.WriteString(" " + line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	for i, hand := range s.hands {
+		marker := "  "
+		if i == s.active && len(s.hands) > 1 {
+			marker = "▶ "
+		}
+
+		fmt.Fprintf(&b, "%sYou (%d)\n", marker, blackjack.Score(hand...))
+		for _, line := range art(hand, len(hand)) {
+			b.WriteString(" " + line + "\n")
+		}
+		b.WriteString("\n")
+	}
+
+	if ss := t.session; ss != nil {
+		b.WriteString(" ┌ Session ─────────────────────────┐\n")
+		fmt.Fprintf(&b, " │ Bankroll  %-23s│\n", fmt.Sprintf("$%d", ss.bankroll))
+		fmt.Fprintf(&b, " │ Hands     %-23d│\n", ss.hands)
+		fmt.Fprintf(&b, " │ W / L / P %-23s│\n", fmt.Sprintf("%d / %d / %d", ss.wins, ss.losses, ss.pushes))
+		fmt.Fprintf(&b, " │ Net       %-23s│\n", fmt.Sprintf("%+d", ss.net))
+		fmt.Fprintf(&b, " │ Best win  %-23s│\n", fmt.Sprintf("$%d", ss.biggestWin))
+		b.WriteString(" └──────────────────────────────────┘\n\n")
+	}
+
+	if t.message != "" {
+		b.WriteString(" " + t.message + "\n")
+	}
+
+	b.WriteString(" " + s.prompt + "\n")
+
+	fmt.Fprint(t.out, b.String())
+}
+
+func (t *tui) Ready(s *session, penetration float64) bool {
+	t.session = s
+
+	for {
+		if t.bet*t.handsPerBet > s.bankroll {
+			t.bet = s.bankroll / t.handsPerBet / blackjack.MinBet * blackjack.MinBet
+		}
+
+		t.draw(screen{
+			prompt: fmt.Sprintf("Bet: $%d | Shoe: %.0f%% dealt    [+/-] change bet  [enter] deal  [q] quit",
+				t.bet, penetration*100),
+		})
+
+		switch t.key() {
+		case '+', '=':
+			if (t.bet+blackjack.MinBet)*t.handsPerBet <= s.bankroll {
+				t.bet += blackjack.MinBet
+			}
+		case '-', '_':
+			if t.bet > blackjack.MinBet {
+				t.bet -= blackjack.MinBet
+			}
+		case '\n', '\r', ' ':
+			t.message = ""
+			return true
+		case 'q':
+			return false
+		}
+	}
+}
+
+func (t *tui) Bet(shuffled bool) int {
+	if shuffled {
+		t.message = "The shoe was just shuffled."
+	}
+
+	return t.bet
+}
+
+func (t *tui) Switch(hands [][]deck.Card, dealer deck.Card) bool {
+	for {
+		t.draw(screen{
+			dealer: []deck.Card{dealer, dealer},
+			hidden: true,
+			hands:  hands,
+			active: -1,
+			prompt: "Switch the second cards of your hands?    [y]es  [n]o",
+		})
+
+		switch t.key() {
+		case 'y':
+			return true
+		case 'n', 'q':
+			return false
+		}
+	}
+}
+
+func (t *tui) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	// The hole card is unknown, so the dealer's up card stands in for it and is drawn face down.
+	return t.play(hand, []deck.Card{dealer, dealer}, true)
+}
+
+func (t *tui) PlayExposed(hand []deck.Card, dealer []deck.Card) blackjack.Move {
+	return t.play(hand, dealer, false)
+}
+
+func (t *tui) play(hand []deck.Card, dealer []deck.Card, hidden bool) blackjack.Move {
+	canDouble := len(hand) == 2
+	canSplit := canDouble && hand[0].Rank == hand[1].Rank
+
+	prompt := "[h]it  [s]tand"
+	if canDouble {
+		prompt += "  [d]ouble"
+	}
+	if canSplit {
+		prompt += "  s[p]lit"
+	}
+
+	for {
+		t.draw(screen{
+			dealer: dealer,
+			hidden: hidden,
+			hands:  [][]deck.Card{hand},
+			prompt: prompt,
+		})
+
+		t.message = ""
+
+		switch k := t.key(); {
+		case k == 'h':
+			return blackjack.MoveHit
+		case k == 's', k == 'q':
+			return blackjack.MoveStand
+		case k == 'd' && canDouble:
+			return blackjack.MoveDouble
+		case k == 'p' && canSplit:
+			return blackjack.MoveSplit
+		default:
+			t.message = "That move isn't allowed right now."
+		}
+	}
+}
+
+// Results reveals the dealer's hole card and then deals the rest of the dealer's cards one at a time.
+func (t *tui) Results(hands [][]deck.Card, dealer []deck.Card) {
+	t.hands, t.dealer = hands, dealer
+
+	frame := func(cards []deck.Card, hidden bool) {
+		t.draw(screen{dealer: cards, hidden: hidden, hands: hands, active: -1, prompt: "Dealer's turn..."})
+		time.Sleep(t.delay)
+	}
+
+	frame(dealer[:2], true)
+
+	for i := 2; i <= len(dealer); i++ {
+		frame(dealer[:i], false)
+	}
+}
+
+func (t *tui) Notify(msg string) {
+	if t.message != "" {
+		t.message += "\n "
+	}
+
+	t.message += msg
+}
+
+func (t *tui) RoundOver(won int, s *session) {
+	switch {
+	case won > 0:
+		t.Notify(fmt.Sprintf("You won $%d!", won))
+	case won < 0:
+		t.Notify(fmt.Sprintf("You lost $%d.", -won))
+	default:
+		t.Notify("Push.")
+	}
+
+	t.draw(screen{dealer: t.dealer, hands: t.hands, active: -1, prompt: "Press any key to continue"})
+	t.message = ""
+
+	t.key()
+}
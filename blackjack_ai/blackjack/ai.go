@@ -1,8 +1,13 @@
 package blackjack
 
 import (
+	"bufio"
 	"fmt"
 	"github.com/jwambugu/gophercises/deck"
+	"io"
+	"os"
+	"strconv"
+	"strings"
 )
 
 type AI interface {
@@ -11,79 +16,150 @@ type AI interface {
 	Bet(shuffled bool) int
 }
 
+// Switcher is implemented by AIs that play Blackjack Switch. Switch is shown both of the player's opening hands and
+// returns true to swap their second cards.
+type Switcher interface {
+	Switch(hands [][]deck.Card, dealer deck.Card) bool
+}
+
+// ExposedAI is implemented by AIs that want to see both of the dealer's cards when the rules expose the hole card,
+// as in Double Exposure. PlayExposed is called instead of Play in that case.
+type ExposedAI interface {
+	PlayExposed(hand []deck.Card, dealer []deck.Card) Move
+}
+
 type humanAI struct {
+	in  *bufio.Scanner
+	out io.Writer
 }
 
+// HumanAI returns an AI that asks the person at the terminal what to do.
 func HumanAI() AI {
-	return humanAI{}
+	return NewHumanAI(os.Stdin, os.Stdout)
 }
 
-type dealerAI struct {
+// NewHumanAI returns an AI that writes prompts to out and reads the answers, one per line, from in.
+func NewHumanAI(in io.Reader, out io.Writer) AI {
+	return humanAI{
+		in:  bufio.NewScanner(in),
+		out: out,
+	}
 }
 
-func (ai *dealerAI) Bet(shuffled bool) int {
-	return 1
+// FormatCards returns the cards in a hand separated by commas.
+func FormatCards(cards []deck.Card) string {
+	strs := make([]string, len(cards))
+
+	for i := range cards {
+		strs[i] = cards[i].String()
+	}
+
+	return strings.Join(strs, ", ")
+}
+
+// readLine returns the next line typed in, or "q" once there is nothing left to read.
+func (ai humanAI) readLine() string {
+	if !ai.in.Scan() {
+		return "q"
+	}
+
+	return strings.TrimSpace(ai.in.Text())
 }
 
 func (ai humanAI) Bet(shuffled bool) int {
 	if shuffled {
-		fmt.Println("[*] The deck was just shuffled.")
+		fmt.Fprintln(ai.out, "[*] The deck was just shuffled.")
 	}
-	fmt.Println("[?] What would you like to bet?")
-	var bet int
 
-	_, _ = fmt.Scanf("%d\n", &bet)
-	return bet
-}
+	for {
+		fmt.Fprintf(ai.out, "[?] What would you like to bet? (minimum %d)\n", MinBet)
+
+		input := ai.readLine()
+		if input == "q" {
+			return MinBet
+		}
+
+		bet, err := strconv.Atoi(input)
+		if err == nil && bet >= MinBet {
+			return bet
+		}
 
-func (ai *dealerAI) Results(hands [][]deck.Card, dealer []deck.Card) {
-	// DO NOTHING
+		fmt.Fprintln(ai.out, "Invalid bet:", input)
+	}
 }
 
 func (ai humanAI) Results(hands [][]deck.Card, dealer []deck.Card) {
-	fmt.Println("==FINAL HANDS==")
-	fmt.Print("Player:")
+	fmt.Fprintln(ai.out, "==FINAL HANDS==")
 
 	for _, h := range hands {
-		fmt.Println(" ", h)
+		fmt.Fprintf(ai.out, "Player: %s (%d)\n", FormatCards(h), Score(h...))
 	}
 
-	fmt.Println("Dealer:", dealer)
+	fmt.Fprintf(ai.out, "Dealer: %s (%d)\n\n", FormatCards(dealer), Score(dealer...))
 }
 
-func (ai *dealerAI) Play(hand []deck.Card, dealer deck.Card) Move {
-	// If dealer score <= 16, hit || If dealer has a sort 17, hit.
-	// Soft 17 is when an ace as 11 and the score is 17
-	dealerScore := Score(hand...)
+func (ai humanAI) Switch(hands [][]deck.Card, dealer deck.Card) bool {
+	for {
+		for i, h := range hands {
+			fmt.Fprintf(ai.out, "Hand %d: %s (%d)\n", i+1, FormatCards(h), Score(h...))
+		}
 
-	if dealerScore <= 16 || (dealerScore == 17 && Soft(hand...)) {
-		return MoveHit
-	}
+		fmt.Fprintln(ai.out, "Dealer:", dealer.String()+", **HIDDEN**")
+		fmt.Fprintln(ai.out, "[?] Switch the second cards of your hands? (y)es, (n)o")
 
-	return MoveStand
+		switch input := ai.readLine(); input {
+		case "y":
+			return true
+		case "n", "q":
+			return false
+		default:
+			fmt.Fprintln(ai.out, "Invalid option: ", input)
+		}
+	}
 }
 
 func (ai humanAI) Play(hand []deck.Card, dealer deck.Card) Move {
+	return ai.play(hand, []deck.Card{dealer})
+}
+
+func (ai humanAI) PlayExposed(hand []deck.Card, dealer []deck.Card) Move {
+	return ai.play(hand, dealer)
+}
+
+func (ai humanAI) play(hand []deck.Card, dealer []deck.Card) Move {
+	canDouble := len(hand) == 2
+	canSplit := canDouble && hand[0].Rank == hand[1].Rank
+
+	options := "(h)it, (s)tand"
+	if canDouble {
+		options += ", (d)ouble"
+	}
+	if canSplit {
+		options += ", s(p)lit"
+	}
+
 	for {
-		fmt.Println("Player:", hand)
-		fmt.Println("Dealer:", dealer)
+		fmt.Fprintf(ai.out, "Player: %s (%d)\n", FormatCards(hand), Score(hand...))
 
-		fmt.Println("[?] What will you do? (h)it, (s)tand, (d)ouble, s(p)lit")
+		if len(dealer) == 1 {
+			fmt.Fprintln(ai.out, "Dealer:", FormatCards(dealer)+", **HIDDEN**")
+		} else {
+			fmt.Fprintf(ai.out, "Dealer: %s (%d)\n", FormatCards(dealer), Score(dealer...))
+		}
 
-		var input string
-		_, _ = fmt.Scanf("%s\n", &input)
+		fmt.Fprintf(ai.out, "[?] What will you do? %s\n", options)
 
-		switch input {
-		case "h":
+		switch input := ai.readLine(); {
+		case input == "h":
 			return MoveHit
-		case "s":
+		case input == "s", input == "q":
 			return MoveStand
-		case "d":
+		case input == "d" && canDouble:
 			return MoveDouble
-		case "p":
+		case input == "p" && canSplit:
 			return MoveSplit
 		default:
-			fmt.Println("Invalid option: ", input)
+			fmt.Fprintln(ai.out, "Invalid option: ", input)
 		}
 	}
 }
@@ -0,0 +1,50 @@
+package blackjack
+
+import (
+	"bytes"
+	"github.com/jwambugu/gophercises/deck"
+	"strings"
+	"testing"
+)
+
+func TestHumanAI_Bet(t *testing.T) {
+	var out bytes.Buffer
+	ai := NewHumanAI(strings.NewReader("abc\n50\n250\n"), &out)
+
+	if bet := ai.Bet(true); bet != 250 {
+		t.Errorf("expected a bet of %d, got %d", 250, bet)
+	}
+
+	if n := strings.Count(out.String(), "Invalid bet"); n != 2 {
+		t.Errorf("expected %d invalid bets, got %d", 2, n)
+	}
+}
+
+func TestHumanAI_Play(t *testing.T) {
+	var out bytes.Buffer
+
+	// Splitting isn't allowed without a pair, and doubling isn't allowed on three cards.
+	ai := NewHumanAI(strings.NewReader("p\nd\n"), &out)
+	hand := cards(deck.Ten, deck.Six)
+
+	g := Game{state: statePlayerTurn, seats: []seat{{hands: []Hand{{Cards: hand}}}}, deck: cards(deck.Two)}
+	_ = ai.Play(hand, deck.Card{Rank: deck.King})(&g)
+
+	if !g.seats[0].hands[0].Doubled {
+		t.Error("expected the hand to be doubled")
+	}
+
+	if !strings.Contains(out.String(), "Invalid option:  p") {
+		t.Errorf("expected the split to be rejected, got %q", out.String())
+	}
+
+	ai = NewHumanAI(strings.NewReader("d\ns\n"), &out)
+	hand = cards(deck.Two, deck.Three, deck.Four)
+
+	g = Game{state: statePlayerTurn, seats: []seat{{hands: []Hand{{Cards: hand}}}}}
+	_ = ai.Play(hand, deck.Card{Rank: deck.King})(&g)
+
+	if g.state != stateDealerTurn {
+		t.Error("expected the player to stand after the double was rejected")
+	}
+}
@@ -0,0 +1,36 @@
+package blackjack
+
+import "github.com/jwambugu/gophercises/deck"
+
+type cheatingDealer struct {
+	Dealer
+	cards []deck.Card
+}
+
+// CheatingDealer returns a Dealer that plays like dealer, but ignores the shoe and deals itself cards in order,
+// starting with its two opening cards. It makes the dealer's hand predictable when testing player AIs.
+//
+// CheatingDealer panics if it runs out of cards.
+func CheatingDealer(dealer Dealer, cards ...deck.Card) Dealer {
+	return &cheatingDealer{
+		Dealer: dealer,
+		cards:  cards,
+	}
+}
+
+func (d *cheatingDealer) Draw(shoe []deck.Card) (deck.Card, []deck.Card) {
+	if len(d.cards) == 0 {
+		panic("cheating dealer ran out of cards")
+	}
+
+	var card deck.Card
+	card, d.cards = draw(d.cards)
+
+	return card, shoe
+}
+
+// clone returns a copy of the dealer that deals its own cards, so States don't share the cards left.
+func (d *cheatingDealer) clone() Dealer {
+	c := *d
+	return &c
+}
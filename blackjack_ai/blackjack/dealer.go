@@ -0,0 +1,59 @@
+package blackjack
+
+import "github.com/jwambugu/gophercises/deck"
+
+// Dealer plays the dealer's hand once every player hand has been played.
+type Dealer interface {
+	// Draw takes the dealer's next card from the shoe, returning the card and what is left of the shoe.
+	Draw(shoe []deck.Card) (deck.Card, []deck.Card)
+
+	// Play returns the dealer's next move. It is called until the dealer stands.
+	Play(hand []deck.Card) Move
+}
+
+// cloner is implemented by Dealers that keep state, so copies of a game can each have their own.
+type cloner interface {
+	clone() Dealer
+}
+
+func cloneDealer(d Dealer) Dealer {
+	if c, ok := d.(cloner); ok {
+		return c.clone()
+	}
+
+	return d
+}
+
+// HouseRule is a Dealer that hits until a hard hand reaches Hard or a soft hand reaches Soft.
+type HouseRule struct {
+	Soft int
+	Hard int
+}
+
+// H17 returns a Dealer that hits on a soft 17 and stands on everything else from 17 up.
+func H17() Dealer {
+	return HouseRule{Soft: 18, Hard: 17}
+}
+
+// S17 returns a Dealer that stands on every 17, soft or hard.
+func S17() Dealer {
+	return HouseRule{Soft: 17, Hard: 17}
+}
+
+func (d HouseRule) Draw(shoe []deck.Card) (deck.Card, []deck.Card) {
+	return draw(shoe)
+}
+
+func (d HouseRule) Play(hand []deck.Card) Move {
+	target := d.Hard
+
+	if Soft(hand...) {
+		target = d.Soft
+	}
+
+	if Score(hand...) < target {
+		return MoveHit
+	}
+
+	return MoveStand
+}
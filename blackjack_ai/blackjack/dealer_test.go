@@ -0,0 +1,65 @@
+package blackjack
+
+import (
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+func TestDealer_Play(t *testing.T) {
+	tests := []struct {
+		name   string
+		dealer Dealer
+		hand   []deck.Card
+		hit    bool
+	}{
+		{"H17 hits 16", H17(), cards(deck.Ten, deck.Six), true},
+		{"H17 hits soft 17", H17(), cards(deck.Ace, deck.Six), true},
+		{"H17 stands on hard 17", H17(), cards(deck.Ten, deck.Seven), false},
+		{"H17 stands on soft 18", H17(), cards(deck.Ace, deck.Seven), false},
+		{"S17 hits 16", S17(), cards(deck.Ten, deck.Six), true},
+		{"S17 stands on soft 17", S17(), cards(deck.Ace, deck.Six), false},
+		{"S17 stands on hard 17", S17(), cards(deck.Ten, deck.Seven), false},
+		{"house rule hits soft 18", HouseRule{Soft: 19, Hard: 17}, cards(deck.Ace, deck.Seven), true},
+		{"house rule stands on soft 19", HouseRule{Soft: 19, Hard: 17}, cards(deck.Ace, deck.Eight), false},
+		{"house rule hits hard 17", HouseRule{Soft: 17, Hard: 18}, cards(deck.Ten, deck.Seven), true},
+	}
+
+	for _, tt := range tests {
+		move := tt.dealer.Play(tt.hand)
+
+		// Moves are functions, so run them against a game to tell them apart.
+		g := Game{state: stateDealerTurn, dealer: tt.hand, dealerAI: tt.dealer, deck: cards(deck.Two)}
+		_ = move(&g)
+
+		if hit := len(g.dealer) > len(tt.hand); hit != tt.hit {
+			t.Errorf("%s: want hit %v, got %v", tt.name, tt.hit, hit)
+		}
+	}
+}
+
+func TestCheatingDealer(t *testing.T) {
+	// The dealer ignores the shoe, gets 10, 6 and then draws a 5.
+	shoe := cards(deck.Ten, deck.Nine, deck.Eight, deck.Seven)
+
+	game := New(Options{
+		Decks:  1,
+		Hands:  1,
+		Rules:  stacked{Rules: Standard{}, cards: shoe},
+		Dealer: CheatingDealer(H17(), cards(deck.Ten, deck.Six, deck.Five)...),
+	})
+
+	ai := &scriptedAI{}
+
+	// 19 loses to 21.
+	if balance := game.Play(ai); balance != -100 {
+		t.Errorf("expected a balance of %d, got %d", -100, balance)
+	}
+
+	if Score(ai.dealer...) != 21 {
+		t.Errorf("expected the dealer to finish on %d, got %v", 21, ai.dealer)
+	}
+
+	if Score(ai.results[0]...) != 19 {
+		t.Errorf("expected the player to finish on %d, got %v", 19, ai.results[0])
+	}
+}
@@ -0,0 +1,27 @@
+package blackjack
+
+import "github.com/jwambugu/gophercises/deck"
+
+// DoubleExposure deals both of the dealer's cards face up. In exchange, the dealer wins every tie, including a
+// blackjack against a blackjack, and a blackjack pays even money.
+type DoubleExposure struct {
+	Standard
+}
+
+func (DoubleExposure) HoleCardExposed() bool {
+	return true
+}
+
+func (DoubleExposure) BlackjackPayout() float64 {
+	return 1
+}
+
+func (d DoubleExposure) Settle(hand Hand, dealer []deck.Card, blackjackPayout float64) float64 {
+	playerScore, dealerScore := Score(hand.Cards...), Score(dealer...)
+
+	if playerScore <= 21 && playerScore == dealerScore && BlackJack(hand.Cards...) == BlackJack(dealer...) {
+		return -1
+	}
+
+	return d.Standard.Settle(hand, dealer, blackjackPayout)
+}
@@ -0,0 +1,69 @@
+package blackjack
+
+import (
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+// exposedAI records the dealer cards it is shown and then stands.
+type exposedAI struct {
+	scriptedAI
+	shown []deck.Card
+}
+
+func (ai *exposedAI) PlayExposed(hand []deck.Card, dealer []deck.Card) Move {
+	ai.shown = dealer
+	return MoveStand
+}
+
+func TestDoubleExposure_Settle(t *testing.T) {
+	tests := []struct {
+		name   string
+		player []deck.Card
+		dealer []deck.Card
+		want   float64
+	}{
+		{"blackjack pays even money", cards(deck.Ace, deck.King), cards(deck.Ten, deck.Nine), 1},
+		{"blackjack beats dealer 21", cards(deck.Ace, deck.King), cards(deck.Ten, deck.Five, deck.Six), 1},
+		{"blackjack tie loses", cards(deck.Ace, deck.King), cards(deck.Ace, deck.Queen), -1},
+		{"tie loses", cards(deck.Ten, deck.Eight), cards(deck.Ten, deck.Eight), -1},
+		{"21 tie loses", cards(deck.Ten, deck.Five, deck.Six), cards(deck.Ten, deck.Four, deck.Seven), -1},
+		{"win", cards(deck.Ten, deck.Nine), cards(deck.Ten, deck.Eight), 1},
+		{"dealer bust", cards(deck.Ten, deck.Two), cards(deck.Ten, deck.Six, deck.Nine), 1},
+	}
+
+	rules := DoubleExposure{}
+
+	for _, tt := range tests {
+		got := rules.Settle(Hand{Cards: tt.player}, tt.dealer, rules.BlackjackPayout())
+		if got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestDoubleExposure_Play(t *testing.T) {
+	// Player stands on 10, 8 and the dealer stands on 8, 10.
+	shoe := cards(deck.Ten, deck.Eight, deck.Eight, deck.Ten)
+
+	ai := &exposedAI{}
+
+	if balance := playOne(DoubleExposure{}, shoe, ai); balance != -100 {
+		t.Errorf("expected a balance of %d, got %d", -100, balance)
+	}
+
+	if len(ai.shown) != 2 {
+		t.Errorf("expected to be shown %d dealer cards, got %d", 2, len(ai.shown))
+	}
+}
+
+func TestStandard_HidesHoleCard(t *testing.T) {
+	shoe := cards(deck.Ten, deck.Eight, deck.Eight, deck.Ten)
+
+	ai := &exposedAI{}
+	playOne(Standard{}, shoe, ai)
+
+	if ai.shown != nil {
+		t.Errorf("expected the hole card to stay hidden, was shown %v", ai.shown)
+	}
+}
@@ -2,9 +2,18 @@ package blackjack
 
 import (
 	"errors"
+	"fmt"
 	"github.com/jwambugu/gophercises/deck"
+	"math/rand"
 )
 
+// MinBet is the smallest bet allowed at the table.
+const MinBet = 100
+
+// cardsPerHand is the most cards a hand can take from a single deck without busting: four aces, four twos and three
+// threes. The shoe is reshuffled before any round that could need more than this for every hand, dealer included.
+const cardsPerHand = 11
+
 type state int8
 
 const (
@@ -18,38 +27,62 @@ type (
 		Decks           int
 		Hands           int
 		BlackjackPayout float64
+		// Rules is the variant of blackjack being played. Defaults to Standard.
+		Rules Rules
+		// Dealer plays the dealer's hand. Defaults to H17.
+		Dealer Dealer
+		// Rand shuffles every shoe, so a seeded Rand deals the same cards every time. Defaults to deck.Shuffle.
+		Rand *rand.Rand
 	}
 
-	hand struct {
-		cards []deck.Card
+	// Hand is a single hand played by the player along with what was wagered on it.
+	Hand struct {
+		Cards   []deck.Card
+		Bet     int
+		Doubled bool
+	}
+
+	// seat is a place at the table, played by a single AI.
+	seat struct {
+		ai    AI
 		bet   int
+		hands []Hand
 	}
 
 	Game struct {
 		deck            []deck.Card
+		shoeSize        int
+		reshuffleAt     int
 		state           state
-		player          []hand
+		seats           []seat
+		seatIndex       int
 		handIndex       int
 		dealer          []deck.Card
-		dealerAI        AI
-		balance         int
+		dealerAI        Dealer
+		rules           Rules
+		shuffle         func([]deck.Card) []deck.Card
 		noOfDecks       int
 		noOfHands       int
 		blackjackPayout float64
-		playerBet       int
 	}
 )
 
 var (
-	errorBusted = errors.New("hand score exceeded 21")
+	errorBusted    = errors.New("hand score exceeded 21")
+	errorShoeEmpty = errors.New("the shoe is empty")
 )
 
 type Move func(*Game) error
 
+// seat returns the seat whose turn it is.
+func (g *Game) seat() *seat {
+	return &g.seats[g.seatIndex]
+}
+
 func (g *Game) currentHand() *[]deck.Card {
 	switch g.state {
 	case statePlayerTurn:
-		return &g.player[g.handIndex].cards
+		return &g.seat().hands[g.handIndex].Cards
 	case stateDealerTurn:
 		return &g.dealer
 	default:
@@ -65,11 +98,12 @@ func MoveSplit(g *Game) error {
 	if (*cards)[0].Rank != (*cards)[1].Rank {
 		return errors.New("both cards must have the same rank to split")
 	}
-	g.player = append(g.player, hand{
-		cards: []deck.Card{(*cards)[1]},
-		bet:   g.player[g.handIndex].bet,
+	s := g.seat()
+	s.hands = append(s.hands, Hand{
+		Cards: []deck.Card{(*cards)[1]},
+		Bet:   s.hands[g.handIndex].Bet,
 	})
-	g.player[g.handIndex].cards = (*cards)[:1]
+	s.hands[g.handIndex].Cards = s.hands[g.handIndex].Cards[:1]
 	return nil
 }
 
@@ -77,7 +111,16 @@ func MoveHit(g *Game) error {
 	hand := g.currentHand()
 
 	var card deck.Card
-	card, g.deck = draw(g.deck)
+
+	if len(g.deck) == 0 {
+		return errorShoeEmpty
+	}
+
+	if g.state == stateDealerTurn {
+		card, g.deck = g.dealerAI.Draw(g.deck)
+	} else {
+		card, g.deck = draw(g.deck)
+	}
 
 	*hand = append(*hand, card)
 
@@ -95,7 +138,11 @@ func MoveStand(g *Game) error {
 	}
 	if g.state == statePlayerTurn {
 		g.handIndex++
-		if g.handIndex >= len(g.player) {
+		if g.handIndex >= len(g.seat().hands) {
+			g.handIndex = 0
+			g.seatIndex++
+		}
+		if g.seatIndex >= len(g.seats) {
 			g.state++
 		}
 		return nil
@@ -107,9 +154,13 @@ func MoveDouble(g *Game) error {
 	if len(*g.currentHand()) != 2 {
 		return errors.New("can only double on a hand with two cards")
 	}
-	g.playerBet *= 2
+	if err := MoveHit(g); err != nil && err != errorBusted {
+		return err
+	}
 
-	_ = MoveHit(g)
+	hand := &g.seat().hands[g.handIndex]
+	hand.Bet *= 2
+	hand.Doubled = true
 
 	return MoveStand(g)
 }
@@ -119,32 +170,71 @@ func draw(cards []deck.Card) (deck.Card, []deck.Card) {
 }
 
 func deal(g *Game) {
-	playerHand := make([]deck.Card, 0, 5)
 	g.dealer = make([]deck.Card, 0, 5)
+	g.seatIndex = 0
 	g.handIndex = 0
 
+	for i := range g.seats {
+		s := &g.seats[i]
+		s.hands = make([]Hand, g.rules.Hands())
+
+		for j := range s.hands {
+			s.hands[j] = Hand{
+				Cards: make([]deck.Card, 0, 5),
+				Bet:   s.bet,
+			}
+		}
+	}
+
 	var card deck.Card
 
 	for i := 0; i < 2; i++ {
-		card, g.deck = draw(g.deck)
-		playerHand = append(playerHand, card)
+		for j := range g.seats {
+			s := &g.seats[j]
 
-		card, g.deck = draw(g.deck)
+			for k := range s.hands {
+				card, g.deck = draw(g.deck)
+				s.hands[k].Cards = append(s.hands[k].Cards, card)
+			}
+		}
+
+		card, g.deck = g.dealerAI.Draw(g.deck)
 		g.dealer = append(g.dealer, card)
 	}
 
-	//playerHand = []deck.Card{
-	//	{Rank: deck.Seven},
-	//	{Rank: deck.Seven},
-	//}
+	g.state = statePlayerTurn
+}
 
-	g.player = []hand{
-		{
-			cards: playerHand,
-			bet:   g.playerBet,
-		},
+// switchCards swaps the second card of a seat's opening hands if the rules allow it and its AI wants to.
+func switchCards(g *Game, s *seat) {
+	switcher, ok := s.ai.(Switcher)
+	if !ok || !g.rules.Switch() || len(s.hands) != 2 {
+		return
 	}
-	g.state = statePlayerTurn
+
+	hands := make([][]deck.Card, len(s.hands))
+
+	for i, hand := range s.hands {
+		hands[i] = make([]deck.Card, len(hand.Cards))
+		copy(hands[i], hand.Cards)
+	}
+
+	if switcher.Switch(hands, g.dealer[0]) {
+		first, second := s.hands[0].Cards, s.hands[1].Cards
+		first[1], second[1] = second[1], first[1]
+	}
+}
+
+// play asks the AI for its next move, showing it the dealer's hole card if the rules expose it.
+func play(g *Game, ai AI, hand []deck.Card) Move {
+	if exposed, ok := ai.(ExposedAI); ok && g.rules.HoleCardExposed() {
+		dealer := make([]deck.Card, len(g.dealer))
+		copy(dealer, g.dealer)
+
+		return exposed.PlayExposed(hand, dealer)
+	}
+
+	return ai.Play(hand, g.dealer[0])
 }
 
 func min(a, b int) int {
@@ -191,55 +281,37 @@ func Score(hand ...deck.Card) int {
 	return minScore
 }
 
-func endRound(g *Game, ai AI) {
-	dealerScore := Score(g.dealer...)
-	dealerBlackjack := BlackJack(g.dealer...)
-
-	allHands := make([][]deck.Card, len(g.player))
-
-	for i, hand := range g.player {
-		cards := hand.cards
-		allHands[i] = hand.cards
-		winnings := hand.bet
-
-		playerScore, playerBlackjack := Score(cards...), BlackJack(cards...)
-
-		switch {
-		case playerBlackjack && dealerBlackjack:
-			winnings = 0
-		case dealerBlackjack:
-			winnings = -winnings
-		case playerBlackjack:
-			winnings = int(float64(winnings) * g.blackjackPayout)
-		case playerScore > 21:
-			winnings = -winnings
-		case dealerScore > 21:
-			// wins
-		case playerScore > dealerScore:
-			// wins
-		case dealerScore > playerScore:
-			winnings = -winnings
-		case dealerScore == playerScore:
-			winnings = 0
+// endRound settles every seat against the dealer and returns what each seat won or lost.
+func endRound(g *Game) []int {
+	winnings := make([]int, len(g.seats))
+
+	for i, s := range g.seats {
+		allHands := make([][]deck.Card, len(s.hands))
+
+		for j, hand := range s.hands {
+			allHands[j] = hand.Cards
+
+			payout := g.rules.Settle(hand, g.dealer, g.blackjackPayout)
+			winnings[i] += int(float64(hand.Bet) * payout)
 		}
 
-		g.balance += winnings
+		s.ai.Results(allHands, g.dealer)
 	}
 
-	ai.Results(allHands, g.dealer)
-
-	g.player = nil
+	g.seats = nil
 	g.dealer = nil
+
+	return winnings
 }
 
-func bet(g *Game, ai AI, shuffled bool) {
+func bet(ai AI, shuffled bool) int {
 	bet := ai.Bet(shuffled)
 
-	if bet < 100 {
-		panic("bet must be at least 100")
+	if bet < MinBet {
+		panic(fmt.Sprintf("bet must be at least %d", MinBet))
 	}
 
-	g.playerBet = bet
+	return bet
 }
 
 // BlackJack returns true if a hand is a blackjack
@@ -247,65 +319,103 @@ func BlackJack(hand ...deck.Card) bool {
 	return len(hand) == 2 && Score(hand...) == 21
 }
 
-func (g *Game) Play(ai AI) int {
-	g.deck = nil
-
-	minCardsCount := (52 * g.noOfDecks) / 3
+// Round plays a single round with a seat for each AI. The seats share the shoe and the dealer, and are played in
+// order. Round returns what each seat won or lost.
+//
+// The shoe is only reshuffled between rounds, once less than a third of it is left or too little for every hand at
+// the table to be played out, so a card is never dealt twice in a shoe. If a round still empties it, because of a lot
+// of splits or a shoe that is small for the table, hands that can't draw stand.
+func (g *Game) Round(ais ...AI) []int {
+	shuffled := false
+	hands := len(ais)*g.rules.Hands() + 1
+
+	if g.deck == nil || len(g.deck) < g.reshuffleAt || len(g.deck) < hands*cardsPerHand {
+		g.deck = g.rules.Shoe(g.noOfDecks, g.shuffle)
+		g.shoeSize = len(g.deck)
+		g.reshuffleAt = len(g.deck) / 3
+
+		shuffled = true
+	}
 
-	for i := 0; i < g.noOfHands; i++ {
-		shuffled := false
+	if len(g.deck) < hands*2 {
+		panic(fmt.Sprintf("a shoe of %d cards can't deal %d seats", len(g.deck), len(ais)))
+	}
 
-		if len(g.deck) < minCardsCount {
-			g.deck = deck.New(deck.Deck(g.noOfDecks), deck.Shuffle)
+	g.seats = make([]seat, len(ais))
 
-			shuffled = true
+	for i, ai := range ais {
+		g.seats[i] = seat{
+			ai:  ai,
+			bet: bet(ai, shuffled),
 		}
+	}
 
-		bet(g, ai, shuffled)
-		deal(g)
+	deal(g)
 
-		if BlackJack(g.dealer...) {
-			endRound(g, ai)
-			continue
-		}
+	for i := range g.seats {
+		switchCards(g, &g.seats[i])
+	}
+
+	if BlackJack(g.dealer...) {
+		return endRound(g)
+	}
 
-		for g.state == statePlayerTurn {
-			hand := make([]deck.Card, len(*g.currentHand()))
-			copy(hand, *g.currentHand())
+	for g.state == statePlayerTurn {
+		hand := make([]deck.Card, len(*g.currentHand()))
+		copy(hand, *g.currentHand())
 
-			move := ai.Play(hand, g.dealer[0])
+		move := play(g, g.seat().ai, hand)
 
-			err := move(g)
+		err := move(g)
 
-			switch err {
-			case errorBusted:
-				_ = MoveStand(g)
-			case nil:
-				// Nothing to do here
-			default:
-				panic(err)
-			}
+		switch err {
+		case errorBusted, errorShoeEmpty:
+			_ = MoveStand(g)
+		case nil:
+			// Nothing to do here
+		default:
+			panic(err)
 		}
+	}
 
-		for g.state == stateDealerTurn {
-			hand := make([]deck.Card, len(g.dealer))
-			copy(hand, g.dealer)
+	for g.state == stateDealerTurn {
+		hand := make([]deck.Card, len(g.dealer))
+		copy(hand, g.dealer)
 
-			move := g.dealerAI.Play(hand, g.dealer[0])
-			_ = move(g)
+		move := g.dealerAI.Play(hand)
+		if err := move(g); err == errorShoeEmpty {
+			_ = MoveStand(g)
 		}
+	}
+
+	return endRound(g)
+}
+
+// Penetration returns how much of the shoe has been dealt, from 0 to 1. The shoe is shuffled before the next round
+// once it passes 2/3, or sooner when a lot of seats are playing.
+func (g *Game) Penetration() float64 {
+	if g.shoeSize == 0 {
+		return 0
+	}
+
+	return 1 - float64(len(g.deck))/float64(g.shoeSize)
+}
+
+// Play plays Options.Hands rounds with a single seat, starting from a fresh shoe, and returns the AI's balance.
+func (g *Game) Play(ai AI) int {
+	g.deck = nil
+	balance := 0
 
-		endRound(g, ai)
+	for i := 0; i < g.noOfHands; i++ {
+		balance += g.Round(ai)[0]
 	}
 
-	return g.balance
+	return balance
 }
 
 func New(opts Options) Game {
 	g := Game{
-		state:    statePlayerTurn,
-		dealerAI: &dealerAI{},
-		balance:  0,
+		state: statePlayerTurn,
 	}
 
 	if opts.Decks == 0 {
@@ -316,13 +426,28 @@ func New(opts Options) Game {
 		opts.Hands = 100
 	}
 
+	if opts.Rules == nil {
+		opts.Rules = Standard{}
+	}
+
 	if opts.BlackjackPayout == 0 {
-		opts.BlackjackPayout = 1.5
+		opts.BlackjackPayout = opts.Rules.BlackjackPayout()
+	}
+
+	if opts.Dealer == nil {
+		opts.Dealer = H17()
 	}
 
 	g.noOfHands = opts.Hands
 	g.noOfDecks = opts.Decks
 	g.blackjackPayout = opts.BlackjackPayout
+	g.rules = opts.Rules
+	g.dealerAI = opts.Dealer
+	g.shuffle = deck.Shuffle
+
+	if opts.Rand != nil {
+		g.shuffle = deck.ShuffleWith(opts.Rand)
+	}
 
 	return g
 }
@@ -0,0 +1,97 @@
+package blackjack
+
+import (
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+func TestGame_Round(t *testing.T) {
+	// Seats are dealt in order before the dealer: first seat gets 10, 9, second seat gets 5, 6 and the dealer gets
+	// 10, 7. The second seat hits and draws a 10.
+	shoe := cards(deck.Ten, deck.Five, deck.Ten, deck.Nine, deck.Six, deck.Seven, deck.Ten)
+
+	game := New(Options{
+		Decks: 1,
+		Rules: stacked{Rules: Standard{}, cards: shoe},
+	})
+
+	first := &scriptedAI{}
+	second := &scriptedAI{moves: []Move{MoveHit}}
+
+	winnings := game.Round(first, second)
+
+	if len(winnings) != 2 {
+		t.Fatalf("expected winnings for %d seats, got %d", 2, len(winnings))
+	}
+
+	if winnings[0] != 100 || winnings[1] != 100 {
+		t.Errorf("expected both seats to win %d, got %v", 100, winnings)
+	}
+
+	if Score(second.results[0]...) != 21 {
+		t.Errorf("expected the second seat to finish on %d, got %v", 21, second.results[0])
+	}
+}
+
+// shoeAI stands on every hand and counts the cards it has seen since the shoe was last shuffled.
+type shoeAI struct {
+	scriptedAI
+	shuffles int
+	seen     map[deck.Card]int
+}
+
+func (ai *shoeAI) Bet(shuffled bool) int {
+	if shuffled {
+		ai.shuffles++
+		ai.seen = make(map[deck.Card]int)
+	}
+
+	return 100
+}
+
+func (ai *shoeAI) Results(hands [][]deck.Card, dealer []deck.Card) {
+	for _, hand := range append(hands, dealer) {
+		for _, c := range hand {
+			ai.seen[c]++
+		}
+	}
+}
+
+func TestGame_Round_reshuffle(t *testing.T) {
+	game := New(Options{Decks: 1})
+	ai := &shoeAI{}
+
+	const rounds = 100
+
+	for i := 0; i < rounds; i++ {
+		game.Round(ai)
+
+		for c, n := range ai.seen {
+			if n > 1 {
+				t.Fatalf("round %d: expected every card to be dealt once per shoe, got %s %d times", i+1, c, n)
+			}
+		}
+	}
+
+	if ai.shuffles < 2 || ai.shuffles == rounds {
+		t.Errorf("expected the shoe to be shuffled between some of the %d rounds, got %d shuffles", rounds, ai.shuffles)
+	}
+}
+
+func TestGame_Round_emptyShoe(t *testing.T) {
+	// The deal uses up the whole shoe, so the player's hit stands on 16 instead of drawing from a new shoe.
+	game := New(Options{
+		Decks: 1,
+		Rules: stacked{Rules: Standard{}, cards: cards(deck.Ten, deck.Ten, deck.Six, deck.Seven)},
+	})
+
+	ai := &scriptedAI{moves: []Move{MoveHit}}
+
+	if winnings := game.Round(ai); winnings[0] != -100 {
+		t.Errorf("expected the player to lose %d, got %v", 100, winnings)
+	}
+
+	if len(ai.results[0]) != 2 {
+		t.Errorf("expected the player to keep two cards, got %v", ai.results[0])
+	}
+}
@@ -0,0 +1,72 @@
+package blackjack
+
+import "github.com/jwambugu/gophercises/deck"
+
+// Rules describes the parts of blackjack that change between variants. Variants embed Standard and override only the
+// methods they need.
+type Rules interface {
+	// Shoe returns a shoe made up of n decks, shuffled by shuffle.
+	Shoe(n int, shuffle func([]deck.Card) []deck.Card) []deck.Card
+
+	// Hands returns the number of hands dealt to the player at the start of a round.
+	Hands() int
+
+	// Switch reports whether the player may swap the second cards of their opening hands.
+	Switch() bool
+
+	// HoleCardExposed reports whether the player gets to see both of the dealer's cards.
+	HoleCardExposed() bool
+
+	// BlackjackPayout returns what a blackjack pays when Options.BlackjackPayout is not set.
+	BlackjackPayout() float64
+
+	// Settle returns what a hand won (positive) or lost (negative) against the dealer, as a multiple of its bet.
+	Settle(hand Hand, dealer []deck.Card, blackjackPayout float64) float64
+}
+
+// Standard is blackjack as it is played in most casinos.
+type Standard struct{}
+
+func (Standard) Shoe(n int, shuffle func([]deck.Card) []deck.Card) []deck.Card {
+	return deck.New(deck.Deck(n), shuffle)
+}
+
+func (Standard) Hands() int {
+	return 1
+}
+
+func (Standard) Switch() bool {
+	return false
+}
+
+func (Standard) HoleCardExposed() bool {
+	return false
+}
+
+func (Standard) BlackjackPayout() float64 {
+	return 1.5
+}
+
+func (Standard) Settle(hand Hand, dealer []deck.Card, blackjackPayout float64) float64 {
+	playerScore, playerBlackjack := Score(hand.Cards...), BlackJack(hand.Cards...)
+	dealerScore, dealerBlackjack := Score(dealer...), BlackJack(dealer...)
+
+	switch {
+	case playerBlackjack && dealerBlackjack:
+		return 0
+	case dealerBlackjack:
+		return -1
+	case playerBlackjack:
+		return blackjackPayout
+	case playerScore > 21:
+		return -1
+	case dealerScore > 21:
+		return 1
+	case playerScore > dealerScore:
+		return 1
+	case dealerScore > playerScore:
+		return -1
+	default:
+		return 0
+	}
+}
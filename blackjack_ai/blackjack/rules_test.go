@@ -0,0 +1,92 @@
+package blackjack
+
+import (
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+// stacked plays by the embedded Rules, but always deals from the same shoe.
+type stacked struct {
+	Rules
+	cards []deck.Card
+}
+
+func (s stacked) Shoe(n int, shuffle func([]deck.Card) []deck.Card) []deck.Card {
+	cards := make([]deck.Card, len(s.cards))
+	copy(cards, s.cards)
+
+	return cards
+}
+
+// scriptedAI bets 100 and returns its moves in order, standing once it runs out.
+type scriptedAI struct {
+	moves   []Move
+	results [][]deck.Card
+	dealer  []deck.Card
+}
+
+func (ai *scriptedAI) Bet(shuffled bool) int {
+	return 100
+}
+
+func (ai *scriptedAI) Play(hand []deck.Card, dealer deck.Card) Move {
+	if len(ai.moves) == 0 {
+		return MoveStand
+	}
+
+	move := ai.moves[0]
+	ai.moves = ai.moves[1:]
+
+	return move
+}
+
+func (ai *scriptedAI) Results(hands [][]deck.Card, dealer []deck.Card) {
+	ai.results = hands
+	ai.dealer = dealer
+}
+
+func cards(ranks ...deck.Rank) []deck.Card {
+	cards := make([]deck.Card, len(ranks))
+
+	for i, r := range ranks {
+		cards[i] = deck.Card{Suit: deck.Heart, Rank: r}
+	}
+
+	return cards
+}
+
+// playOne plays a single round of rules dealt from shoe and returns the player's balance.
+func playOne(rules Rules, shoe []deck.Card, ai AI) int {
+	game := New(Options{
+		Decks: 1,
+		Hands: 1,
+		Rules: stacked{Rules: rules, cards: shoe},
+	})
+
+	return game.Play(ai)
+}
+
+func TestStandard_Settle(t *testing.T) {
+	tests := []struct {
+		name   string
+		player []deck.Card
+		dealer []deck.Card
+		want   float64
+	}{
+		{"blackjack", cards(deck.Ace, deck.King), cards(deck.Ten, deck.Nine), 1.5},
+		{"blackjack push", cards(deck.Ace, deck.King), cards(deck.Ace, deck.Queen), 0},
+		{"dealer blackjack", cards(deck.Ten, deck.Ten), cards(deck.Ace, deck.Queen), -1},
+		{"bust", cards(deck.Ten, deck.Six, deck.Nine), cards(deck.Ten, deck.Six, deck.Nine), -1},
+		{"dealer bust", cards(deck.Ten, deck.Six), cards(deck.Ten, deck.Six, deck.Nine), 1},
+		{"win", cards(deck.Ten, deck.Nine), cards(deck.Ten, deck.Eight), 1},
+		{"lose", cards(deck.Ten, deck.Seven), cards(deck.Ten, deck.Eight), -1},
+		{"push", cards(deck.Ten, deck.Eight), cards(deck.Ten, deck.Eight), 0},
+	}
+
+	for _, tt := range tests {
+		got := Standard{}.Settle(Hand{Cards: tt.player}, tt.dealer, 1.5)
+		if got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package blackjack
+
+import "github.com/jwambugu/gophercises/deck"
+
+// Spanish21 is played with the tens removed from every deck. In exchange, a player's 21 always wins and some 21s earn
+// bonus payouts, as long as the hand wasn't doubled:
+//
+//	5 card 21          3:2
+//	6 card 21          2:1
+//	7+ card 21         3:1
+//	6-7-8 or 7-7-7     3:2 mixed suits, 2:1 suited, 3:1 in spades
+type Spanish21 struct {
+	Standard
+}
+
+// Shoe returns a shoe made up of n 48 card decks, shuffled by shuffle.
+func (Spanish21) Shoe(n int, shuffle func([]deck.Card) []deck.Card) []deck.Card {
+	tens := func(card deck.Card) bool {
+		return card.Rank == deck.Ten
+	}
+
+	return deck.New(deck.Filter(tens), deck.Deck(n), shuffle)
+}
+
+func (s Spanish21) Settle(hand Hand, dealer []deck.Card, blackjackPayout float64) float64 {
+	switch {
+	case BlackJack(hand.Cards...):
+		return blackjackPayout
+	case Score(hand.Cards...) == 21:
+		if hand.Doubled {
+			return 1
+		}
+
+		return spanish21Bonus(hand.Cards)
+	default:
+		return s.Standard.Settle(hand, dealer, blackjackPayout)
+	}
+}
+
+// spanish21Bonus returns the payout for a 21 made up of cards.
+func spanish21Bonus(cards []deck.Card) float64 {
+	if len(cards) == 3 && (sameRanks(cards, deck.Six, deck.Seven, deck.Eight) ||
+		sameRanks(cards, deck.Seven, deck.Seven, deck.Seven)) {
+		switch {
+		case !sameSuit(cards):
+			return 1.5
+		case cards[0].Suit == deck.Spade:
+			return 3
+		default:
+			return 2
+		}
+	}
+
+	switch {
+	case len(cards) >= 7:
+		return 3
+	case len(cards) == 6:
+		return 2
+	case len(cards) == 5:
+		return 1.5
+	default:
+		return 1
+	}
+}
+
+// sameRanks returns true if cards are made up of exactly ranks, in any order.
+func sameRanks(cards []deck.Card, ranks ...deck.Rank) bool {
+	if len(cards) != len(ranks) {
+		return false
+	}
+
+	counts := make(map[deck.Rank]int)
+
+	for _, r := range ranks {
+		counts[r]++
+	}
+
+	for _, c := range cards {
+		counts[c.Rank]--
+		if counts[c.Rank] < 0 {
+			return false
+		}
+	}
+
+	return true
+}
+
+func sameSuit(cards []deck.Card) bool {
+	for _, c := range cards[1:] {
+		if c.Suit != cards[0].Suit {
+			return false
+		}
+	}
+
+	return true
+}
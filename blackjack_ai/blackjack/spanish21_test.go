@@ -0,0 +1,81 @@
+package blackjack
+
+import (
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+func TestSpanish21_Shoe(t *testing.T) {
+	shoe := Spanish21{}.Shoe(2, deck.Shuffle)
+
+	if len(shoe) != 48*2 {
+		t.Errorf("expected %d cards in the shoe, got %d", 48*2, len(shoe))
+	}
+
+	for _, c := range shoe {
+		if c.Rank == deck.Ten {
+			t.Fatalf("expected all tens to be removed, found %v", c)
+		}
+	}
+}
+
+func TestSpanish21_Settle(t *testing.T) {
+	tests := []struct {
+		name   string
+		player []deck.Card
+		dealer []deck.Card
+		want   float64
+	}{
+		{"blackjack beats dealer blackjack", cards(deck.Ace, deck.King), cards(deck.Ace, deck.Queen), 1.5},
+		{"21 beats dealer 21", cards(deck.Nine, deck.Two, deck.King), cards(deck.Nine, deck.Two, deck.Jack), 1},
+		{"five card 21", cards(deck.Two, deck.Three, deck.Four, deck.Five, deck.Seven), cards(deck.King, deck.Nine), 1.5},
+		{"six card 21", cards(deck.Ace, deck.Two, deck.Three, deck.Four, deck.Five, deck.Six), cards(deck.King, deck.Nine), 2},
+		{"seven card 21", cards(deck.Ace, deck.Ace, deck.Two, deck.Two, deck.Three, deck.Four, deck.Eight), cards(deck.King, deck.Nine), 3},
+		{"push", cards(deck.King, deck.Eight), cards(deck.Queen, deck.Eight), 0},
+		{"bust", cards(deck.King, deck.Eight, deck.Five), cards(deck.Queen, deck.Eight), -1},
+	}
+
+	for _, tt := range tests {
+		got := Spanish21{}.Settle(Hand{Cards: tt.player}, tt.dealer, 1.5)
+		if got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSpanish21_SettleSuitedBonus(t *testing.T) {
+	dealer := cards(deck.King, deck.Nine)
+
+	tests := []struct {
+		name   string
+		player []deck.Card
+		want   float64
+	}{
+		{"mixed 6-7-8", []deck.Card{{Suit: deck.Heart, Rank: deck.Six}, {Suit: deck.Club, Rank: deck.Seven}, {Suit: deck.Heart, Rank: deck.Eight}}, 1.5},
+		{"suited 7-7-7", []deck.Card{{Suit: deck.Heart, Rank: deck.Seven}, {Suit: deck.Heart, Rank: deck.Seven}, {Suit: deck.Heart, Rank: deck.Seven}}, 2},
+		{"spades 8-6-7", []deck.Card{{Suit: deck.Spade, Rank: deck.Eight}, {Suit: deck.Spade, Rank: deck.Six}, {Suit: deck.Spade, Rank: deck.Seven}}, 3},
+	}
+
+	for _, tt := range tests {
+		got := Spanish21{}.Settle(Hand{Cards: tt.player}, dealer, 1.5)
+		if got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+
+		got = Spanish21{}.Settle(Hand{Cards: tt.player, Doubled: true}, dealer, 1.5)
+		if got != 1 {
+			t.Errorf("%s doubled: want %v, got %v", tt.name, 1, got)
+		}
+	}
+}
+
+func TestSpanish21_Play(t *testing.T) {
+	// Player gets 5, 6 and hits to 21 with a King. Dealer gets 9, 2 and then draws to 21 with a Jack.
+	shoe := cards(deck.Five, deck.Nine, deck.Six, deck.Two, deck.King, deck.Jack)
+
+	ai := &scriptedAI{moves: []Move{MoveHit}}
+
+	if balance := playOne(Spanish21{}, shoe, ai); balance != 100 {
+		t.Errorf("expected a balance of %d, got %d", 100, balance)
+	}
+}
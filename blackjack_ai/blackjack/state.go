@@ -0,0 +1,389 @@
+package blackjack
+
+import (
+	"errors"
+	"fmt"
+	"github.com/jwambugu/gophercises/deck"
+	"reflect"
+	"strings"
+)
+
+// Action is a choice a player can make, as a value that can be compared and enumerated.
+type Action int8
+
+const (
+	ActionHit Action = iota
+	ActionStand
+	ActionDouble
+	ActionSplit
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionHit:
+		return "Hit"
+	case ActionStand:
+		return "Stand"
+	case ActionDouble:
+		return "Double"
+	case ActionSplit:
+		return "Split"
+	default:
+		return fmt.Sprintf("Action(%d)", a)
+	}
+}
+
+// Move returns the Move that plays the action on a Game.
+func (a Action) Move() Move {
+	switch a {
+	case ActionStand:
+		return MoveStand
+	case ActionDouble:
+		return MoveDouble
+	case ActionSplit:
+		return MoveSplit
+	default:
+		return MoveHit
+	}
+}
+
+// ActionOf returns the Action that plays move, if move is one of the engine's Moves. Funcs can't be compared in Go,
+// so the moves are told apart by their code pointers.
+func ActionOf(move Move) (Action, bool) {
+	ptr := reflect.ValueOf(move).Pointer()
+
+	for _, a := range []Action{ActionHit, ActionStand, ActionDouble, ActionSplit} {
+		if reflect.ValueOf(a.Move()).Pointer() == ptr {
+			return a, true
+		}
+	}
+
+	return 0, false
+}
+
+var errorNotPlayerTurn = errors.New("it isn't the player's turn")
+
+// State is an immutable snapshot of a single seat's round: the shoe, the player's hands and the dealer's hand.
+// Nothing that returns a State changes the State it was called on, so states can be kept, compared and explored
+// freely, which is what tree searching AIs like expectimax or MCTS need.
+//
+// Transitions are made by running the same Moves the Game uses on a copy of the state, so both APIs always play by
+// the same rules. The shoe is never refilled or reshuffled: once it runs out, drawing returns an error.
+//
+// Every State gets its own copy of the dealers made by CheatingDealer. Other Dealers are shared between States and
+// must not keep any state of their own.
+type State struct {
+	shoe    []deck.Card
+	hands   []Hand
+	current int
+	dealer  []deck.Card
+	state   state
+
+	rules    Rules
+	dealerAI Dealer
+	payout   float64
+}
+
+// Outcome is a State that an action can lead to, and the probability of it happening.
+type Outcome struct {
+	Probability float64
+	State       State
+}
+
+func cloneCards(cards []deck.Card) []deck.Card {
+	if cards == nil {
+		return nil
+	}
+
+	c := make([]deck.Card, len(cards))
+	copy(c, cards)
+
+	return c
+}
+
+func cloneHands(hands []Hand) []Hand {
+	c := make([]Hand, len(hands))
+
+	for i, h := range hands {
+		c[i] = h
+		c[i].Cards = cloneCards(h.Cards)
+	}
+
+	return c
+}
+
+// Deal starts a round by dealing from shoe, with bet placed on every hand the rules deal. Rules, Dealer and
+// BlackjackPayout are taken from opts, with the same defaults as New. The shoe is copied, not consumed.
+func Deal(shoe []deck.Card, bet int, opts Options) State {
+	g := New(opts)
+	g.deck = cloneCards(shoe)
+	g.seats = []seat{{bet: bet}}
+
+	deal(&g)
+
+	if BlackJack(g.dealer...) {
+		g.state = stateHandOver
+	}
+
+	return stateOf(&g)
+}
+
+// Snapshot returns the State of the seat currently being played. Outside of a player's turn, it is the state of
+// the last seat at the table.
+func (g *Game) Snapshot() State {
+	return stateOf(g)
+}
+
+// stateOf copies the seat being played in g into a State.
+func stateOf(g *Game) State {
+	s := State{
+		shoe:     cloneCards(g.deck),
+		current:  g.handIndex,
+		dealer:   cloneCards(g.dealer),
+		state:    g.state,
+		rules:    g.rules,
+		dealerAI: cloneDealer(g.dealerAI),
+		payout:   g.blackjackPayout,
+	}
+
+	if len(g.seats) > 0 {
+		i := g.seatIndex
+		if i >= len(g.seats) {
+			i = len(g.seats) - 1
+		}
+
+		s.hands = cloneHands(g.seats[i].hands)
+	}
+
+	if s.state != statePlayerTurn {
+		s.current = len(s.hands)
+	}
+
+	return s
+}
+
+// game returns a Game holding a copy of the state, ready to have Moves run on it. Moves fail once the shoe is empty.
+func (s State) game() *Game {
+	return &Game{
+		deck:            cloneCards(s.shoe),
+		state:           s.state,
+		seats:           []seat{{hands: cloneHands(s.hands)}},
+		handIndex:       s.current,
+		dealer:          cloneCards(s.dealer),
+		dealerAI:        cloneDealer(s.dealerAI),
+		rules:           s.rules,
+		blackjackPayout: s.payout,
+	}
+}
+
+// PlayerTurn reports whether the player has a hand left to play.
+func (s State) PlayerTurn() bool {
+	return s.state == statePlayerTurn
+}
+
+// Over reports whether the round has finished and can be settled.
+func (s State) Over() bool {
+	return s.state == stateHandOver
+}
+
+// Hand returns the hand being played, or nil once the player is done.
+func (s State) Hand() []deck.Card {
+	if !s.PlayerTurn() {
+		return nil
+	}
+
+	return cloneCards(s.hands[s.current].Cards)
+}
+
+// Hands returns every hand the player holds.
+func (s State) Hands() []Hand {
+	return cloneHands(s.hands)
+}
+
+// DealerUpCard returns the dealer's face up card.
+func (s State) DealerUpCard() deck.Card {
+	return s.dealer[0]
+}
+
+// Dealer returns the dealer's cards, including the hole card.
+func (s State) Dealer() []deck.Card {
+	return cloneCards(s.dealer)
+}
+
+// Remaining returns the number of cards left in the shoe.
+func (s State) Remaining() int {
+	return len(s.shoe)
+}
+
+// Legal returns every action the player may take, or nothing when it isn't their turn. Hitting and doubling aren't
+// legal once the shoe is empty.
+func (s State) Legal() []Action {
+	if !s.PlayerTurn() {
+		return nil
+	}
+
+	cards := s.hands[s.current].Cards
+	canDraw := len(s.shoe) > 0
+
+	var actions []Action
+	if canDraw {
+		actions = append(actions, ActionHit)
+	}
+	actions = append(actions, ActionStand)
+
+	if len(cards) == 2 {
+		if canDraw {
+			actions = append(actions, ActionDouble)
+		}
+
+		if cards[0].Rank == cards[1].Rank {
+			actions = append(actions, ActionSplit)
+		}
+	}
+
+	return actions
+}
+
+// Apply returns the State after the player takes action, drawing from the top of the shoe. A busted hand is
+// stood on automatically, just like in Game. Drawing from an empty shoe is an error.
+func (s State) Apply(a Action) (State, error) {
+	if !s.PlayerTurn() {
+		return s, errorNotPlayerTurn
+	}
+
+	g := s.game()
+
+	switch err := a.Move()(g); err {
+	case errorBusted:
+		_ = MoveStand(g)
+	case nil:
+		// Nothing to do here
+	default:
+		return s, err
+	}
+
+	return stateOf(g), nil
+}
+
+// Outcomes returns every State that action can lead to, one per rank that could be drawn next, weighted by how
+// many cards of that rank are left in the shoe. Actions that don't draw have a single, certain outcome.
+func (s State) Outcomes(a Action) ([]Outcome, error) {
+	if a != ActionHit && a != ActionDouble {
+		next, err := s.Apply(a)
+		if err != nil {
+			return nil, err
+		}
+
+		return []Outcome{{Probability: 1, State: next}}, nil
+	}
+
+	if len(s.shoe) == 0 {
+		return nil, errorShoeEmpty
+	}
+
+	var outcomes []Outcome
+	seen := make(map[deck.Rank]int)
+
+	for i, c := range s.shoe {
+		if j, ok := seen[c.Rank]; ok {
+			outcomes[j].Probability += 1 / float64(len(s.shoe))
+			continue
+		}
+
+		// Move the card to the top of the shoe so that it is the one drawn.
+		drawn := s
+		drawn.shoe = make([]deck.Card, 0, len(s.shoe))
+		drawn.shoe = append(drawn.shoe, c)
+		drawn.shoe = append(drawn.shoe, s.shoe[:i]...)
+		drawn.shoe = append(drawn.shoe, s.shoe[i+1:]...)
+
+		next, err := drawn.Apply(a)
+		if err != nil {
+			return nil, err
+		}
+
+		seen[c.Rank] = len(outcomes)
+		outcomes = append(outcomes, Outcome{Probability: 1 / float64(len(s.shoe)), State: next})
+	}
+
+	return outcomes, nil
+}
+
+// Resolve returns the State after the dealer plays out their hand, drawing from the top of the shoe. It does
+// nothing while the player still has hands to play, and returns an error if the shoe runs out.
+func (s State) Resolve() (State, error) {
+	if s.state != stateDealerTurn {
+		return s, nil
+	}
+
+	g := s.game()
+
+	for g.state == stateDealerTurn {
+		move := g.dealerAI.Play(cloneCards(g.dealer))
+
+		if err := move(g); err == errorShoeEmpty {
+			return s, err
+		}
+	}
+
+	return stateOf(g), nil
+}
+
+// Winnings returns what the player won (positive) or lost (negative) across every hand. It is only meaningful once
+// the round is over.
+func (s State) Winnings() int {
+	winnings := 0
+
+	for _, h := range s.hands {
+		winnings += int(float64(h.Bet) * s.rules.Settle(h, s.dealer, s.payout))
+	}
+
+	return winnings
+}
+
+// String describes the state, which makes it useful in test failures.
+func (s State) String() string {
+	hands := make([]string, len(s.hands))
+
+	for i, h := range s.hands {
+		marker := ""
+		if i == s.current && s.PlayerTurn() {
+			marker = "*"
+		}
+
+		hands[i] = fmt.Sprintf("%s[%s]", marker, FormatCards(h.Cards))
+	}
+
+	return fmt.Sprintf("player %s dealer [%s] shoe %d", strings.Join(hands, " "), FormatCards(s.dealer), len(s.shoe))
+}
+
+// Diff returns a line for every way o differs from s, or nothing if they are the same.
+func (s State) Diff(o State) []string {
+	var diffs []string
+
+	if s.state != o.state {
+		diffs = append(diffs, fmt.Sprintf("turn: %d != %d", s.state, o.state))
+	}
+
+	if len(s.hands) != len(o.hands) {
+		diffs = append(diffs, fmt.Sprintf("hands: %d != %d", len(s.hands), len(o.hands)))
+	}
+
+	for i := 0; i < len(s.hands) && i < len(o.hands); i++ {
+		a, b := s.hands[i], o.hands[i]
+
+		if FormatCards(a.Cards) != FormatCards(b.Cards) || a.Bet != b.Bet || a.Doubled != b.Doubled {
+			diffs = append(diffs, fmt.Sprintf("hand %d: %+v != %+v", i, a, b))
+		}
+	}
+
+	if FormatCards(s.dealer) != FormatCards(o.dealer) {
+		diffs = append(diffs, fmt.Sprintf("dealer: [%s] != [%s]", FormatCards(s.dealer), FormatCards(o.dealer)))
+	}
+
+	if FormatCards(s.shoe) != FormatCards(o.shoe) {
+		diffs = append(diffs, fmt.Sprintf("shoe: %d cards != %d cards", len(s.shoe), len(o.shoe)))
+	}
+
+	return diffs
+}
@@ -0,0 +1,225 @@
+package blackjack
+
+import (
+	"fmt"
+	"github.com/jwambugu/gophercises/deck"
+	"math"
+	"testing"
+)
+
+func TestState_ApplyIsPure(t *testing.T) {
+	// Player gets 10, 6 and the dealer 9, 7. The next cards are 5 and then King.
+	s := Deal(cards(deck.Ten, deck.Nine, deck.Six, deck.Seven, deck.Five, deck.King), 100, Options{})
+	before := s.String()
+
+	first, err := s.Apply(ActionHit)
+	if err != nil {
+		t.Fatalf("Apply() received an error: %s", err)
+	}
+
+	second, _ := s.Apply(ActionHit)
+
+	if diff := first.Diff(second); diff != nil {
+		t.Errorf("expected the same action on the same state to give the same state, got %v", diff)
+	}
+
+	if s.String() != before {
+		t.Errorf("expected the state to be unchanged, was %s, now %s", before, s)
+	}
+
+	if Score(first.Hand()...) != 21 {
+		t.Errorf("expected the player to hit to %d, got %s", 21, first)
+	}
+}
+
+func TestState_Legal(t *testing.T) {
+	tests := []struct {
+		name string
+		shoe []deck.Card
+		want []Action
+	}{
+		{"two cards", cards(deck.Ten, deck.Nine, deck.Six, deck.Seven, deck.Two), []Action{ActionHit, ActionStand, ActionDouble}},
+		{"pair", cards(deck.Eight, deck.Nine, deck.Eight, deck.Seven, deck.Two), []Action{ActionHit, ActionStand, ActionDouble, ActionSplit}},
+	}
+
+	for _, tt := range tests {
+		got := Deal(tt.shoe, 100, Options{}).Legal()
+		if fmt.Sprint(got) != fmt.Sprint(tt.want) {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	s, _ := Deal(cards(deck.Two, deck.Nine, deck.Three, deck.Seven, deck.Four, deck.Five), 100, Options{}).Apply(ActionHit)
+	if got := s.Legal(); fmt.Sprint(got) != fmt.Sprint([]Action{ActionHit, ActionStand}) {
+		t.Errorf("three cards: want %v, got %v", []Action{ActionHit, ActionStand}, got)
+	}
+
+	s, _ = s.Apply(ActionStand)
+	if got := s.Legal(); got != nil {
+		t.Errorf("dealer's turn: want no actions, got %v", got)
+	}
+
+	if _, err := s.Apply(ActionHit); err == nil {
+		t.Error("expected an error playing on the dealer's turn")
+	}
+}
+
+func TestState_EmptyShoe(t *testing.T) {
+	// The deal uses up the whole shoe, so there is nothing left to draw.
+	s := Deal(cards(deck.Ten, deck.Nine, deck.Six, deck.Seven), 100, Options{})
+
+	if got := s.Legal(); fmt.Sprint(got) != fmt.Sprint([]Action{ActionStand}) {
+		t.Errorf("legal: want %v, got %v", []Action{ActionStand}, got)
+	}
+
+	for _, a := range []Action{ActionHit, ActionDouble} {
+		next, err := s.Apply(a)
+		if err == nil {
+			t.Errorf("%v: expected an error drawing from an empty shoe, got %s", a, next)
+		}
+		if diff := s.Diff(next); diff != nil {
+			t.Errorf("%v: expected the state to be unchanged, got %v", a, diff)
+		}
+	}
+
+	// The dealer has 16 and must hit, but can't.
+	stood, err := s.Apply(ActionStand)
+	if err != nil {
+		t.Fatalf("Apply() received an error: %s", err)
+	}
+
+	if _, err := stood.Resolve(); err == nil {
+		t.Error("expected an error resolving the dealer's hand from an empty shoe")
+	}
+}
+
+func TestState_CheatingDealerIsCopied(t *testing.T) {
+	dealer := CheatingDealer(H17(), cards(deck.Ten, deck.Six, deck.Two, deck.King)...)
+	s := Deal(cards(deck.Ten, deck.Nine, deck.Five, deck.Five), 100, Options{Dealer: dealer})
+
+	stood, err := s.Apply(ActionStand)
+	if err != nil {
+		t.Fatalf("Apply() received an error: %s", err)
+	}
+
+	first, err := stood.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() received an error: %s", err)
+	}
+
+	second, err := stood.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() received an error: %s", err)
+	}
+
+	if diff := first.Diff(second); diff != nil {
+		t.Errorf("expected resolving the same state twice to give the same state, got %v", diff)
+	}
+
+	if got := Score(first.Dealer()...); got != 18 {
+		t.Errorf("expected the dealer to draw the 2 and stand on %d, got %s", 18, first)
+	}
+}
+
+func TestActionOf(t *testing.T) {
+	for _, a := range []Action{ActionHit, ActionStand, ActionDouble, ActionSplit} {
+		got, ok := ActionOf(a.Move())
+		if !ok || got != a {
+			t.Errorf("ActionOf(%v.Move()): want %v, got %v", a, a, got)
+		}
+	}
+
+	if _, ok := ActionOf(func(g *Game) error { return nil }); ok {
+		t.Error("expected a move that isn't the engine's to have no action")
+	}
+}
+
+func TestState_Outcomes(t *testing.T) {
+	s := Deal(cards(deck.Ten, deck.Nine, deck.Six, deck.Seven, deck.Five, deck.King, deck.Queen, deck.Two), 100, Options{})
+
+	outcomes, err := s.Outcomes(ActionHit)
+	if err != nil {
+		t.Fatalf("Outcomes() received an error: %s", err)
+	}
+
+	// 5, King, Queen and 2 are left, and Kings and Queens are different ranks.
+	if len(outcomes) != 4 {
+		t.Fatalf("expected %d outcomes, got %d", 4, len(outcomes))
+	}
+
+	total := 0.0
+	busts := 0
+
+	for _, o := range outcomes {
+		total += o.Probability
+
+		if o.State.PlayerTurn() {
+			continue
+		}
+
+		if Score(o.State.Hands()[0].Cards...) > 21 {
+			busts++
+		}
+	}
+
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("expected the probabilities to add up to 1, got %v", total)
+	}
+
+	if busts != 2 {
+		t.Errorf("expected %d outcomes to bust, got %d", 2, busts)
+	}
+}
+
+func TestState_MatchesGame(t *testing.T) {
+	shoe := cards(deck.Ten, deck.Five, deck.Six, deck.Six, deck.Three, deck.King, deck.Two)
+
+	s := Deal(shoe, 100, Options{})
+	s, _ = s.Apply(ActionHit)
+	s, _ = s.Apply(ActionStand)
+	s, err := s.Resolve()
+	if err != nil {
+		t.Fatalf("Resolve() received an error: %s", err)
+	}
+
+	if !s.Over() {
+		t.Fatalf("expected the round to be over, got %s", s)
+	}
+
+	game := New(Options{Decks: 1, Rules: stacked{Rules: Standard{}, cards: shoe}})
+	winnings := game.Round(&scriptedAI{moves: []Move{MoveHit}})
+
+	if s.Winnings() != winnings[0] {
+		t.Errorf("expected the state to win the same as the game, %d, got %d", winnings[0], s.Winnings())
+	}
+}
+
+func TestGame_Snapshot(t *testing.T) {
+	shoe := cards(deck.Ten, deck.Five, deck.Six, deck.Six, deck.Three)
+
+	game := New(Options{Decks: 1, Rules: stacked{Rules: Standard{}, cards: shoe}})
+
+	var snapshot State
+	ai := &snapshotAI{game: &game, snapshot: &snapshot}
+	game.Round(ai)
+
+	want := Deal(shoe, 100, Options{})
+	if diff := want.Diff(snapshot); diff != nil {
+		t.Errorf("expected the snapshot to match a fresh deal, got %v", diff)
+	}
+}
+
+// snapshotAI takes a snapshot of the game the first time it plays, and then stands.
+type snapshotAI struct {
+	scriptedAI
+	game     *Game
+	snapshot *State
+}
+
+func (ai *snapshotAI) Play(hand []deck.Card, dealer deck.Card) Move {
+	if ai.snapshot.hands == nil {
+		*ai.snapshot = ai.game.Snapshot()
+	}
+
+	return MoveStand
+}
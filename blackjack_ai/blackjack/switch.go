@@ -0,0 +1,30 @@
+package blackjack
+
+import "github.com/jwambugu/gophercises/deck"
+
+// Switch is Blackjack Switch. The player plays two hands with equal bets and may swap the second cards dealt to each
+// hand before playing them. To pay for that, a blackjack pays even money and a dealer 22 pushes every hand that is
+// still standing.
+type Switch struct {
+	Standard
+}
+
+func (Switch) Hands() int {
+	return 2
+}
+
+func (Switch) Switch() bool {
+	return true
+}
+
+func (Switch) BlackjackPayout() float64 {
+	return 1
+}
+
+func (s Switch) Settle(hand Hand, dealer []deck.Card, blackjackPayout float64) float64 {
+	if Score(dealer...) == 22 && Score(hand.Cards...) <= 21 && !BlackJack(hand.Cards...) {
+		return 0
+	}
+
+	return s.Standard.Settle(hand, dealer, blackjackPayout)
+}
@@ -0,0 +1,72 @@
+package blackjack
+
+import (
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+// switchingAI always switches and then stands on both hands.
+type switchingAI struct {
+	scriptedAI
+	offered [][]deck.Card
+}
+
+func (ai *switchingAI) Switch(hands [][]deck.Card, dealer deck.Card) bool {
+	ai.offered = hands
+	return true
+}
+
+func TestSwitch_Settle(t *testing.T) {
+	tests := []struct {
+		name   string
+		player []deck.Card
+		dealer []deck.Card
+		want   float64
+	}{
+		{"blackjack pays even money", cards(deck.Ace, deck.King), cards(deck.Ten, deck.Nine), 1},
+		{"dealer 22 pushes", cards(deck.Ten, deck.Eight), cards(deck.Ten, deck.Six, deck.Six), 0},
+		{"dealer 22 doesn't push blackjack", cards(deck.Ace, deck.Jack), cards(deck.Ten, deck.Six, deck.Six), 1},
+		{"dealer 22 doesn't save a bust", cards(deck.Ten, deck.Eight, deck.Five), cards(deck.Ten, deck.Six, deck.Six), -1},
+		{"dealer 23 busts", cards(deck.Ten, deck.Eight), cards(deck.Ten, deck.Six, deck.Seven), 1},
+	}
+
+	rules := Switch{}
+
+	for _, tt := range tests {
+		got := rules.Settle(Hand{Cards: tt.player}, tt.dealer, rules.BlackjackPayout())
+		if got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestSwitch_Play(t *testing.T) {
+	// First hand gets 10, 6 and second hand gets 5, Jack. Switching makes them 10, Jack and 5, 6.
+	// The dealer stands on 10, 8.
+	shoe := cards(deck.Ten, deck.Five, deck.Ten, deck.Six, deck.Jack, deck.Eight, deck.Nine)
+
+	ai := &switchingAI{}
+	ai.moves = []Move{MoveStand, MoveHit}
+
+	balance := playOne(Switch{}, shoe, ai)
+
+	if len(ai.offered) != 2 {
+		t.Fatalf("expected to be offered %d hands to switch, got %d", 2, len(ai.offered))
+	}
+
+	want := [][]deck.Card{
+		cards(deck.Ten, deck.Jack),
+		cards(deck.Five, deck.Six, deck.Nine),
+	}
+
+	for i, hand := range ai.results {
+		if Score(hand...) != Score(want[i]...) || len(hand) != len(want[i]) {
+			t.Errorf("hand %d: want %v, got %v", i, want[i], hand)
+		}
+	}
+
+	// 20 and 20 both beat 18.
+	if balance != 200 {
+		t.Errorf("expected a balance of %d, got %d", 200, balance)
+	}
+}
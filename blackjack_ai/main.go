@@ -1,9 +1,14 @@
 package main
 
 import (
+	"flag"
 	"fmt"
 	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/blackjack_ai/server"
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
 	"github.com/jwambugu/gophercises/deck"
+	"log"
+	"os"
 )
 
 type basicAI struct {
@@ -86,17 +91,67 @@ func (ai *basicAI) Bet(shuffled bool) int {
 	}
 }
 
+// train learns a strategy, reports how close it comes to basic strategy and writes it to csvFile if set.
+func train(episodes, decks int, csvFile string) blackjack.AI {
+	table := trainer.Train(trainer.Options{
+		Episodes: episodes,
+		Decks:    decks,
+	})
+
+	fmt.Println("Agreement with basic strategy:", trainer.Compare(table, trainer.Basic{}, 1000))
+
+	if csvFile != "" {
+		f, err := os.Create(csvFile)
+		if err != nil {
+			log.Fatalf("failed to create %s: %v", csvFile, err)
+		}
+		defer f.Close()
+
+		if err := table.WriteCSV(f); err != nil {
+			log.Fatalf("failed to write %s: %v", csvFile, err)
+		}
+	}
+
+	return table
+}
+
 func main() {
+	var episodes, bots int
+	var csvFile, addr string
+
+	flag.IntVar(&episodes, "train", 0, "the number of rounds to train a strategy with, instead of using the basic AI")
+	flag.StringVar(&csvFile, "csv", "", "the file to write the trained strategy to")
+	flag.StringVar(&addr, "serve", "", "the address to host a multiplayer table on, e.g. :4000")
+	flag.IntVar(&bots, "bots", 0, "the number of bots seated at the multiplayer table")
+	flag.Parse()
+
 	decks := 4
+
+	if addr != "" {
+		s := server.New(server.Options{
+			Game: blackjack.Options{Decks: decks},
+			Bots: bots,
+		})
+
+		log.Printf("Table open on %s, join with: nc <host> <port> and send JOIN <name>", addr)
+		log.Fatal(s.ListenAndServe(addr))
+	}
+
 	game := blackjack.New(blackjack.Options{
 		Hands:           10000,
 		Decks:           decks,
 		BlackjackPayout: 1.5,
 	})
 
-	winnings := game.Play(&basicAI{
+	var ai blackjack.AI = &basicAI{
 		decks: decks,
-	})
+	}
+
+	if episodes > 0 {
+		ai = train(episodes, decks, csvFile)
+	}
+
+	winnings := game.Play(ai)
 
 	fmt.Println(winnings)
 }
@@ -0,0 +1,182 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const writeTimeout = 5 * time.Second
+
+// player is a person connected to the table. It implements blackjack.AI by asking them what to do over the
+// connection, falling back to the minimum bet or a stand when they take too long or disconnect.
+type player struct {
+	conn    net.Conn
+	timeout time.Duration
+	lines   chan string
+	mu      sync.Mutex
+	left    bool
+}
+
+func newPlayer(conn net.Conn, timeout time.Duration) *player {
+	return &player{
+		conn:    conn,
+		timeout: timeout,
+		lines:   make(chan string, 16),
+	}
+}
+
+// read passes every line the player sends on to whoever is waiting for an answer, until they leave.
+func (p *player) read(r *bufio.Scanner) {
+	defer close(p.lines)
+	defer p.close()
+
+	for r.Scan() {
+		line := strings.TrimSpace(r.Text())
+
+		if line == "LEAVE" {
+			p.send("BYE")
+			return
+		}
+
+		select {
+		case p.lines <- line:
+		default:
+			p.send("ERROR wait for your turn")
+		}
+	}
+}
+
+func (p *player) send(format string, args ...interface{}) {
+	_ = p.conn.SetWriteDeadline(time.Now().Add(writeTimeout))
+	_, _ = fmt.Fprintf(p.conn, format+"\n", args...)
+}
+
+func (p *player) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.left = true
+	_ = p.conn.Close()
+}
+
+func (p *player) gone() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.left
+}
+
+// ask sends question and passes every answer to accept until it returns true. It returns false if the player took
+// too long or left.
+func (p *player) ask(question string, accept func(fields []string) error) bool {
+	// Anything sent before the question was asked isn't an answer to it.
+	for drained := false; !drained; {
+		select {
+		case _, ok := <-p.lines:
+			if !ok {
+				return false
+			}
+		default:
+			drained = true
+		}
+	}
+
+	p.send(question)
+
+	timer := time.NewTimer(p.timeout)
+	defer timer.Stop()
+
+	for {
+		select {
+		case line, ok := <-p.lines:
+			if !ok {
+				return false
+			}
+
+			if err := accept(strings.Fields(line)); err != nil {
+				p.send("ERROR %s", err)
+				continue
+			}
+
+			return true
+		case <-timer.C:
+			p.send("TIMEOUT")
+			return false
+		}
+	}
+}
+
+func (p *player) Bet(shuffled bool) int {
+	if shuffled {
+		p.send("SHUFFLE")
+	}
+
+	bet := minBet
+
+	p.ask(fmt.Sprintf("BET? %d", minBet), func(fields []string) error {
+		if len(fields) != 2 || fields[0] != "BET" {
+			return fmt.Errorf("expected BET <amount>")
+		}
+
+		n, err := strconv.Atoi(fields[1])
+		if err != nil || n < minBet {
+			return fmt.Errorf("bet must be a number of at least %d", minBet)
+		}
+
+		bet = n
+		return nil
+	})
+
+	return bet
+}
+
+func (p *player) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	moves := map[string]blackjack.Move{
+		"h": blackjack.MoveHit,
+		"s": blackjack.MoveStand,
+	}
+
+	if len(hand) == 2 {
+		moves["d"] = blackjack.MoveDouble
+
+		if hand[0].Rank == hand[1].Rank {
+			moves["p"] = blackjack.MoveSplit
+		}
+	}
+
+	allowed := make([]string, 0, len(moves))
+	for _, m := range []string{"h", "s", "d", "p"} {
+		if _, ok := moves[m]; ok {
+			allowed = append(allowed, m)
+		}
+	}
+
+	move := blackjack.MoveStand
+
+	p.ask("MOVE? "+strings.Join(allowed, " "), func(fields []string) error {
+		if len(fields) != 2 || fields[0] != "MOVE" {
+			return fmt.Errorf("expected MOVE <%s>", strings.Join(allowed, "|"))
+		}
+
+		m, ok := moves[fields[1]]
+		if !ok {
+			return fmt.Errorf("%s isn't allowed, expected one of %s", fields[1], strings.Join(allowed, " "))
+		}
+
+		move = m
+		return nil
+	})
+
+	return move
+}
+
+func (p *player) Results(hands [][]deck.Card, dealer []deck.Card) {
+	// The server announces results to the whole table.
+}
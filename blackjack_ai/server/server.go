@@ -0,0 +1,373 @@
+// Package server hosts a blackjack table over a line based TCP protocol, so people on the same network can play at
+// the same table, alongside bots, from nothing more than netcat or telnet.
+//
+// Every message is a single line of space separated words. A client joins with
+//
+//	JOIN <name>
+//
+// and is answered with SEAT <n>, or ERROR if the table is full. From then on the server sends
+//
+//	ROUND <n>                              a new round is starting
+//	SHUFFLE                                the shoe was just shuffled
+//	BET? <min>                             the client must answer with BET <amount>
+//	TURN <name> <cards> DEALER <cards>     a seat is about to play its hand, against the dealer's up card, or
+//	                                       both of the dealer's cards when the rules expose the hole card
+//	MOVE? <moves>                          the client must answer with MOVE <h|s|d|p>
+//	TIMEOUT                                the client took too long, so the minimum bet or a stand was played
+//	DEALER <cards>                         the dealer's final hand
+//	RESULT <name> <won> <balance> <hands>  what a seat won or lost this round
+//	ERROR <message>                        the last line sent was not understood
+//
+// Cards are written as their rank followed by their suit, like AS or 10H, and joined with commas. A client can leave
+// at any time by sending LEAVE or closing the connection.
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
+	"github.com/jwambugu/gophercises/deck"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+const minBet = blackjack.MinBet
+
+type (
+	Options struct {
+		// Game configures the table's shoe, rules and dealer. Game.Hands is ignored, rounds are played for as long as
+		// someone is seated.
+		Game blackjack.Options
+		// Seats is the most players, including bots, that can sit at the table. Defaults to 7.
+		Seats int
+		// Bots is the number of seats played by Bot.
+		Bots int
+		// Bot returns the AI that plays a bot's seat. Defaults to basic strategy.
+		Bot func() blackjack.AI
+		// Timeout is how long a player has to bet or make a move. Defaults to 30 seconds.
+		Timeout time.Duration
+	}
+
+	// Server runs a single table. Rounds are only played while at least one person is seated.
+	Server struct {
+		opts   Options
+		game   blackjack.Game
+		mu     sync.Mutex
+		seats  []*seat
+		joined chan struct{}
+		quit   chan struct{}
+		once   sync.Once
+		ln     net.Listener
+	}
+
+	// seat wraps the AI playing a seat so that everyone at the table sees how it plays. It is a Switcher and an
+	// ExposedAI so the game offers those to the AI, which is asked in turn if it implements them.
+	seat struct {
+		blackjack.AI
+		name    string
+		server  *Server
+		player  *player
+		balance int
+		hands   [][]deck.Card
+		dealer  []deck.Card
+	}
+)
+
+// New returns a Server with an empty table.
+func New(opts Options) *Server {
+	if opts.Seats == 0 {
+		opts.Seats = 7
+	}
+
+	if opts.Bot == nil {
+		opts.Bot = func() blackjack.AI {
+			return trainer.Player(trainer.Basic{})
+		}
+	}
+
+	if opts.Timeout == 0 {
+		opts.Timeout = 30 * time.Second
+	}
+
+	s := &Server{
+		opts:   opts,
+		game:   blackjack.New(opts.Game),
+		joined: make(chan struct{}, 1),
+		quit:   make(chan struct{}),
+	}
+
+	for i := 0; i < opts.Bots && i < opts.Seats; i++ {
+		s.seats = append(s.seats, &seat{
+			AI:     opts.Bot(),
+			name:   fmt.Sprintf("bot%d", i+1),
+			server: s,
+		})
+	}
+
+	return s
+}
+
+// Serve accepts players on ln and plays rounds for them until Close is called.
+func (s *Server) Serve(ln net.Listener) error {
+	s.mu.Lock()
+	s.ln = ln
+	s.mu.Unlock()
+
+	go s.run()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			select {
+			case <-s.quit:
+				return nil
+			default:
+				return err
+			}
+		}
+
+		go s.handle(conn)
+	}
+}
+
+// ListenAndServe listens on the TCP address addr and then calls Serve.
+func (s *Server) ListenAndServe(addr string) error {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return err
+	}
+
+	return s.Serve(ln)
+}
+
+// Close stops accepting players, disconnects everyone seated and stops playing once the current round is over.
+func (s *Server) Close() error {
+	var err error
+
+	s.once.Do(func() {
+		close(s.quit)
+
+		s.mu.Lock()
+		defer s.mu.Unlock()
+
+		if s.ln != nil {
+			err = s.ln.Close()
+		}
+
+		for _, st := range s.seats {
+			if st.player != nil {
+				st.player.close()
+			}
+		}
+	})
+
+	return err
+}
+
+// handle seats the player on conn once they have sent JOIN.
+func (s *Server) handle(conn net.Conn) {
+	r := bufio.NewScanner(conn)
+	p := newPlayer(conn, s.opts.Timeout)
+
+	_ = conn.SetReadDeadline(time.Now().Add(s.opts.Timeout))
+
+	if !r.Scan() {
+		_ = conn.Close()
+		return
+	}
+
+	_ = conn.SetReadDeadline(time.Time{})
+
+	fields := strings.Fields(r.Text())
+	if len(fields) != 2 || fields[0] != "JOIN" {
+		p.send("ERROR expected JOIN <name>")
+		_ = conn.Close()
+		return
+	}
+
+	st, err := s.sit(fields[1], p)
+	if err != nil {
+		p.send("ERROR " + err.Error())
+		_ = conn.Close()
+		return
+	}
+
+	p.send("SEAT %d", st)
+
+	select {
+	case s.joined <- struct{}{}:
+	default:
+	}
+
+	p.read(r)
+}
+
+// sit gives name a seat at the table, returning the seat number.
+func (s *Server) sit(name string, p *player) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.seats) >= s.opts.Seats {
+		return 0, fmt.Errorf("the table is full")
+	}
+
+	for _, st := range s.seats {
+		if st.name == name {
+			return 0, fmt.Errorf("%s is already seated", name)
+		}
+	}
+
+	s.seats = append(s.seats, &seat{
+		AI:     p,
+		name:   name,
+		server: s,
+		player: p,
+	})
+
+	return len(s.seats), nil
+}
+
+// seated returns everyone at the table, and whether any of them are people rather than bots. Players that left
+// since the last round lose their seats.
+func (s *Server) seated() ([]*seat, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	seats := s.seats[:0]
+	people := false
+
+	for _, st := range s.seats {
+		if st.player != nil && st.player.gone() {
+			continue
+		}
+
+		seats = append(seats, st)
+		people = people || st.player != nil
+	}
+
+	s.seats = seats
+
+	seated := make([]*seat, len(seats))
+	copy(seated, seats)
+
+	return seated, people
+}
+
+// broadcast sends a message to everyone at the table. The players are copied first, so that a slow connection
+// doesn't keep anyone else from joining or leaving while it is written to.
+func (s *Server) broadcast(format string, args ...interface{}) {
+	s.mu.Lock()
+
+	players := make([]*player, 0, len(s.seats))
+	for _, st := range s.seats {
+		if st.player != nil {
+			players = append(players, st.player)
+		}
+	}
+
+	s.mu.Unlock()
+
+	for _, p := range players {
+		p.send(format, args...)
+	}
+}
+
+// run plays rounds for as long as there are people at the table.
+func (s *Server) run() {
+	for round := 1; ; round++ {
+		seated, people := s.seated()
+
+		if !people {
+			select {
+			case <-s.joined:
+				round--
+				continue
+			case <-s.quit:
+				return
+			}
+		}
+
+		s.broadcast("ROUND %d", round)
+
+		ais := make([]blackjack.AI, len(seated))
+		for i, st := range seated {
+			ais[i] = st
+		}
+
+		winnings := s.game.Round(ais...)
+
+		if len(seated) > 0 {
+			s.broadcast("DEALER %s", codes(seated[0].dealer))
+		}
+
+		for i, st := range seated {
+			st.balance += winnings[i]
+
+			hands := make([]string, len(st.hands))
+			for j, hand := range st.hands {
+				hands[j] = codes(hand)
+			}
+
+			s.broadcast("RESULT %s %d %d %s", st.name, winnings[i], st.balance, strings.Join(hands, " "))
+		}
+
+		select {
+		case <-s.quit:
+			return
+		default:
+		}
+	}
+}
+
+func (st *seat) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	st.server.broadcast("TURN %s %s DEALER %s", st.name, codes(hand), code(dealer))
+
+	return st.AI.Play(hand, dealer)
+}
+
+// Switch switches the second cards of the seat's hands if its AI plays Blackjack Switch and wants to.
+func (st *seat) Switch(hands [][]deck.Card, dealer deck.Card) bool {
+	if switcher, ok := st.AI.(blackjack.Switcher); ok {
+		return switcher.Switch(hands, dealer)
+	}
+
+	return false
+}
+
+// PlayExposed shows the seat's AI both of the dealer's cards if it wants to see them, and just the up card otherwise.
+func (st *seat) PlayExposed(hand []deck.Card, dealer []deck.Card) blackjack.Move {
+	st.server.broadcast("TURN %s %s DEALER %s", st.name, codes(hand), codes(dealer))
+
+	if exposed, ok := st.AI.(blackjack.ExposedAI); ok {
+		return exposed.PlayExposed(hand, dealer)
+	}
+
+	return st.AI.Play(hand, dealer[0])
+}
+
+func (st *seat) Results(hands [][]deck.Card, dealer []deck.Card) {
+	st.hands, st.dealer = hands, dealer
+
+	st.AI.Results(hands, dealer)
+}
+
+var ranks = [...]string{"", "A", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K"}
+
+// code returns the short form of a card used by the protocol, like AS or 10H.
+func code(c deck.Card) string {
+	return ranks[c.Rank] + c.Suit.String()[:1]
+}
+
+// codes returns the short forms of cards, joined with commas.
+func codes(cards []deck.Card) string {
+	strs := make([]string, len(cards))
+
+	for i, c := range cards {
+		strs[i] = code(c)
+	}
+
+	return strings.Join(strs, ",")
+}
@@ -0,0 +1,295 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/blackjack_ai/trainer"
+	"github.com/jwambugu/gophercises/deck"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func setup(t *testing.T, opts Options) (string, func()) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %s", err)
+	}
+
+	s := New(opts)
+	go s.Serve(ln)
+
+	return ln.Addr().String(), func() {
+		s.Close()
+	}
+}
+
+type client struct {
+	t    *testing.T
+	conn net.Conn
+	r    *bufio.Reader
+}
+
+func join(t *testing.T, addr, name string) *client {
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("failed to connect: %s", err)
+	}
+
+	c := &client{t: t, conn: conn, r: bufio.NewReader(conn)}
+	c.send("JOIN %s", name)
+
+	return c
+}
+
+func (c *client) send(format string, args ...interface{}) {
+	fmt.Fprintf(c.conn, format+"\n", args...)
+}
+
+func (c *client) read() string {
+	_ = c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	line, err := c.r.ReadString('\n')
+	if err != nil {
+		c.t.Fatalf("failed to read from the server: %s", err)
+	}
+
+	return strings.TrimSpace(line)
+}
+
+// playRound answers every question with answer until the result for name arrives, and returns what it won.
+func (c *client) playRound(name string, answer func(question string) string) (int, []string) {
+	var seen []string
+
+	for {
+		line := c.read()
+		seen = append(seen, line)
+
+		if answer != nil && strings.HasSuffix(strings.Fields(line)[0], "?") {
+			if a := answer(line); a != "" {
+				c.send(a)
+			}
+		}
+
+		if strings.HasPrefix(line, "RESULT "+name+" ") {
+			won, err := strconv.Atoi(strings.Fields(line)[2])
+			if err != nil {
+				c.t.Fatalf("failed to parse %q: %s", line, err)
+			}
+
+			return won, seen
+		}
+	}
+}
+
+func contains(want int, values ...int) bool {
+	for _, v := range values {
+		if v == want {
+			return true
+		}
+	}
+
+	return false
+}
+
+func TestServer_Round(t *testing.T) {
+	addr, teardown := setup(t, Options{})
+	defer teardown()
+
+	c := join(t, addr, "alice")
+	if line := c.read(); line != "SEAT 1" {
+		t.Fatalf("expected SEAT 1, got %q", line)
+	}
+
+	won, seen := c.playRound("alice", func(question string) string {
+		if strings.HasPrefix(question, "BET?") {
+			return "BET 200"
+		}
+
+		return "MOVE s"
+	})
+
+	if !contains(won, -200, 0, 200, 300) {
+		t.Errorf("expected a bet of 200 to be settled, won %d", won)
+	}
+
+	if seen[0] != "ROUND 1" {
+		t.Errorf("expected the round to be announced, got %q", seen[0])
+	}
+}
+
+func TestServer_Timeout(t *testing.T) {
+	addr, teardown := setup(t, Options{Timeout: 50 * time.Millisecond})
+	defer teardown()
+
+	c := join(t, addr, "bob")
+	c.read()
+
+	won, seen := c.playRound("bob", nil)
+
+	if !contains(won, -100, 0, 100, 150) {
+		t.Errorf("expected the minimum bet to be settled, won %d", won)
+	}
+
+	timeouts := 0
+	for _, line := range seen {
+		if line == "TIMEOUT" {
+			timeouts++
+		}
+	}
+
+	if timeouts == 0 {
+		t.Errorf("expected a TIMEOUT, got %v", seen)
+	}
+}
+
+func TestServer_InvalidAnswers(t *testing.T) {
+	addr, teardown := setup(t, Options{Timeout: 200 * time.Millisecond})
+	defer teardown()
+
+	c := join(t, addr, "carol")
+	c.read()
+
+	line := c.read()
+	for !strings.HasPrefix(line, "BET?") {
+		line = c.read()
+	}
+
+	c.send("BET 50")
+	if line := c.read(); !strings.HasPrefix(line, "ERROR") {
+		t.Errorf("expected a bet under the minimum to be rejected, got %q", line)
+	}
+
+	c.send("BET 100")
+
+	won, seen := c.playRound("carol", func(question string) string {
+		return "MOVE x"
+	})
+
+	// The invalid move is rejected until the player runs out of time, which stands.
+	if !strings.HasPrefix(seen[len(seen)-1], "RESULT") || !contains(won, -100, 0, 100, 150) {
+		t.Errorf("expected the round to be settled, got %v", seen)
+	}
+
+	for i, line := range seen {
+		if strings.HasPrefix(line, "MOVE?") && !strings.HasPrefix(seen[i+1], "ERROR") {
+			t.Errorf("expected an invalid move to be rejected, got %q", seen[i+1])
+		}
+	}
+}
+
+func TestServer_Bots(t *testing.T) {
+	addr, teardown := setup(t, Options{Bots: 2, Seats: 3})
+	defer teardown()
+
+	c := join(t, addr, "dave")
+	if line := c.read(); line != "SEAT 3" {
+		t.Fatalf("expected SEAT 3, got %q", line)
+	}
+
+	full := join(t, addr, "erin")
+	if line := full.read(); line != "ERROR the table is full" {
+		t.Errorf("expected the table to be full, got %q", line)
+	}
+
+	_, seen := c.playRound("dave", func(question string) string {
+		if strings.HasPrefix(question, "BET?") {
+			return "BET 100"
+		}
+
+		return "MOVE s"
+	})
+
+	// Bots play before dave, so their results arrive first too.
+	results := 0
+	for _, line := range seen {
+		if strings.HasPrefix(line, "RESULT bot") {
+			results++
+		}
+	}
+
+	if results != 2 {
+		t.Errorf("expected results for %d bots, got %v", 2, seen)
+	}
+}
+
+// variantAI plays basic strategy and counts how often it was offered a switch or shown the dealer's hole card.
+type variantAI struct {
+	blackjack.AI
+	switches int32
+	exposed  int32
+}
+
+func (ai *variantAI) Switch(hands [][]deck.Card, dealer deck.Card) bool {
+	atomic.AddInt32(&ai.switches, 1)
+	return false
+}
+
+func (ai *variantAI) PlayExposed(hand []deck.Card, dealer []deck.Card) blackjack.Move {
+	atomic.AddInt32(&ai.exposed, 1)
+	return ai.AI.Play(hand, dealer[0])
+}
+
+func TestServer_Variants(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules blackjack.Rules
+		// calls returns how often the bot was asked to do what the variant is about.
+		calls func(ai *variantAI) int32
+	}{
+		{
+			name:  "switch",
+			rules: blackjack.Switch{},
+			calls: func(ai *variantAI) int32 { return atomic.LoadInt32(&ai.switches) },
+		},
+		{
+			name:  "double exposure",
+			rules: blackjack.DoubleExposure{},
+			calls: func(ai *variantAI) int32 { return atomic.LoadInt32(&ai.exposed) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bot := &variantAI{AI: trainer.Player(trainer.Basic{})}
+
+			addr, teardown := setup(t, Options{
+				Game: blackjack.Options{Decks: 1, Rules: tt.rules},
+				Bots: 1,
+				Bot:  func() blackjack.AI { return bot },
+			})
+			defer teardown()
+
+			c := join(t, addr, "frank")
+			c.read()
+
+			// The bot plays its hands against a blackjack too, which is settled without asking it anything, so a
+			// few rounds are played.
+			for i := 0; i < 5 && tt.calls(bot) == 0; i++ {
+				c.playRound("frank", func(question string) string {
+					if strings.HasPrefix(question, "BET?") {
+						return "BET 100"
+					}
+
+					return "MOVE s"
+				})
+			}
+
+			if tt.calls(bot) == 0 {
+				t.Errorf("expected the %s rules to reach the bot's AI", tt.name)
+			}
+		})
+	}
+}
+
+func TestCodes(t *testing.T) {
+	cards := []deck.Card{{Rank: deck.Ace, Suit: deck.Spade}, {Rank: deck.Ten, Suit: deck.Heart}}
+
+	if got := codes(cards); got != "AS,10H" {
+		t.Errorf("codes(): want %s, got %s", "AS,10H", got)
+	}
+}
@@ -0,0 +1,179 @@
+package trainer
+
+import (
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+)
+
+// Strategy returns the action to take in a situation.
+type Strategy interface {
+	Action(k Key) blackjack.Action
+}
+
+// Basic is basic strategy for a multi deck shoe where doubling after a split is allowed. The dealer hits soft 17
+// unless S17 is set. Where the chart says to double, doubling is assumed to be allowed.
+type Basic struct {
+	S17 bool
+}
+
+func (b Basic) Action(k Key) blackjack.Action {
+	if b.S17 {
+		// Standing on soft 17 makes the dealer a little weaker, which changes three decisions.
+		switch {
+		case !k.Pair && !k.Soft && k.Total == 11 && k.Dealer == 11:
+			return blackjack.ActionHit
+		case !k.Pair && k.Soft && k.Total == 19 && k.Dealer == 6:
+			return blackjack.ActionStand
+		case !k.Pair && k.Soft && k.Total == 18 && k.Dealer == 2:
+			return blackjack.ActionStand
+		}
+	}
+
+	switch {
+	case k.Pair:
+		return basicPair(k.Total/2, k.Soft, k.Dealer)
+	case k.Soft:
+		return basicSoft(k.Total, k.Dealer)
+	default:
+		return basicHard(k.Total, k.Dealer)
+	}
+}
+
+func between(n, lo, hi int) bool {
+	return n >= lo && n <= hi
+}
+
+func basicPair(card int, aces bool, dealer int) blackjack.Action {
+	switch {
+	case aces, card == 8:
+		return blackjack.ActionSplit
+	case card == 10:
+		return blackjack.ActionStand
+	case card == 9:
+		if dealer == 7 || dealer >= 10 {
+			return blackjack.ActionStand
+		}
+		return blackjack.ActionSplit
+	case card == 7, card == 3, card == 2:
+		if dealer <= 7 {
+			return blackjack.ActionSplit
+		}
+		return blackjack.ActionHit
+	case card == 6:
+		if dealer <= 6 {
+			return blackjack.ActionSplit
+		}
+		return blackjack.ActionHit
+	case card == 4:
+		if between(dealer, 5, 6) {
+			return blackjack.ActionSplit
+		}
+		return blackjack.ActionHit
+	default:
+		return basicHard(card*2, dealer)
+	}
+}
+
+func basicSoft(total, dealer int) blackjack.Action {
+	switch {
+	case total >= 20:
+		return blackjack.ActionStand
+	case total == 19:
+		if dealer == 6 {
+			return blackjack.ActionDouble
+		}
+		return blackjack.ActionStand
+	case total == 18:
+		switch {
+		case dealer <= 6:
+			return blackjack.ActionDouble
+		case dealer <= 8:
+			return blackjack.ActionStand
+		default:
+			return blackjack.ActionHit
+		}
+	case total == 17:
+		if between(dealer, 3, 6) {
+			return blackjack.ActionDouble
+		}
+		return blackjack.ActionHit
+	case total >= 15:
+		if between(dealer, 4, 6) {
+			return blackjack.ActionDouble
+		}
+		return blackjack.ActionHit
+	case total >= 13:
+		if between(dealer, 5, 6) {
+			return blackjack.ActionDouble
+		}
+		return blackjack.ActionHit
+	default:
+		return blackjack.ActionHit
+	}
+}
+
+func basicHard(total, dealer int) blackjack.Action {
+	switch {
+	case total >= 17:
+		return blackjack.ActionStand
+	case total >= 13:
+		if dealer <= 6 {
+			return blackjack.ActionStand
+		}
+		return blackjack.ActionHit
+	case total == 12:
+		if between(dealer, 4, 6) {
+			return blackjack.ActionStand
+		}
+		return blackjack.ActionHit
+	case total == 11:
+		return blackjack.ActionDouble
+	case total == 10:
+		if dealer <= 9 {
+			return blackjack.ActionDouble
+		}
+		return blackjack.ActionHit
+	case total == 9:
+		if between(dealer, 3, 6) {
+			return blackjack.ActionDouble
+		}
+		return blackjack.ActionHit
+	default:
+		return blackjack.ActionHit
+	}
+}
+
+type strategyAI struct {
+	strategy Strategy
+}
+
+// Player returns an AI that plays strategy, as recommended by Recommend, betting the minimum.
+func Player(strategy Strategy) blackjack.AI {
+	return strategyAI{strategy: strategy}
+}
+
+func (ai strategyAI) Bet(shuffled bool) int {
+	return minBet
+}
+
+func (ai strategyAI) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	return Recommend(ai.strategy, hand, dealer).Move()
+}
+
+// Recommend returns the action strategy takes with hand. When the strategy says to double or split a hand that
+// can't be, it hits instead.
+func Recommend(strategy Strategy, hand []deck.Card, dealer deck.Card) blackjack.Action {
+	action := strategy.Action(KeyFor(hand, dealer))
+
+	for _, a := range legal(hand) {
+		if a == action {
+			return action
+		}
+	}
+
+	return blackjack.ActionHit
+}
+
+func (ai strategyAI) Results(hands [][]deck.Card, dealer []deck.Card) {
+	// DO NOTHING
+}
@@ -0,0 +1,32 @@
+package trainer
+
+import (
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+)
+
+// HiLo returns what card adds to the running count in the Hi-Lo counting system: +1 for 2 to 6, -1 for tens and
+// aces, and 0 for everything in between.
+func HiLo(card deck.Card) int {
+	score := blackjack.Score(card)
+
+	switch {
+	case score >= 10:
+		return -1
+	case score <= 6:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// RunningCount returns the Hi-Lo count of cards.
+func RunningCount(cards ...deck.Card) int {
+	count := 0
+
+	for _, c := range cards {
+		count += HiLo(c)
+	}
+
+	return count
+}
@@ -0,0 +1,239 @@
+package trainer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+	"io"
+	"sort"
+	"strconv"
+)
+
+// actions are every action, in the order the table keeps their estimates.
+var actions = [...]blackjack.Action{blackjack.ActionHit, blackjack.ActionStand, blackjack.ActionDouble, blackjack.ActionSplit}
+
+// letter returns the letter basic strategy charts use for the action.
+func letter(a blackjack.Action) string {
+	switch a {
+	case blackjack.ActionHit:
+		return "H"
+	case blackjack.ActionStand:
+		return "S"
+	case blackjack.ActionDouble:
+		return "D"
+	case blackjack.ActionSplit:
+		return "P"
+	default:
+		return a.String()
+	}
+}
+
+// Key is the situation a decision is made in.
+type Key struct {
+	Total  int
+	Soft   bool
+	Pair   bool
+	Dealer int
+}
+
+// String describes the situation the way a player would, like "soft 18 vs 9".
+func (k Key) String() string {
+	dealer := strconv.Itoa(k.Dealer)
+	if k.Dealer == 11 {
+		dealer = "A"
+	}
+
+	switch {
+	case k.Pair && k.Soft:
+		return "pair of As vs " + dealer
+	case k.Pair:
+		return fmt.Sprintf("pair of %ds vs %s", k.Total/2, dealer)
+	case k.Soft:
+		return fmt.Sprintf("soft %d vs %s", k.Total, dealer)
+	default:
+		return fmt.Sprintf("hard %d vs %s", k.Total, dealer)
+	}
+}
+
+// KeyFor returns the Key for a hand played against the dealer's up card.
+func KeyFor(hand []deck.Card, dealer deck.Card) Key {
+	return Key{
+		Total:  blackjack.Score(hand...),
+		Soft:   blackjack.Soft(hand...),
+		Pair:   len(hand) == 2 && hand[0].Rank == hand[1].Rank,
+		Dealer: blackjack.Score(dealer),
+	}
+}
+
+// legal returns the actions the engine accepts for hand.
+func legal(hand []deck.Card) []blackjack.Action {
+	switch {
+	case len(hand) < 2:
+		// A freshly split hand only has one card, so the only sensible thing to do is draw.
+		return []blackjack.Action{blackjack.ActionHit}
+	case len(hand) > 2:
+		return []blackjack.Action{blackjack.ActionHit, blackjack.ActionStand}
+	case hand[0].Rank == hand[1].Rank:
+		return []blackjack.Action{blackjack.ActionHit, blackjack.ActionStand, blackjack.ActionDouble, blackjack.ActionSplit}
+	default:
+		return []blackjack.Action{blackjack.ActionHit, blackjack.ActionStand, blackjack.ActionDouble}
+	}
+}
+
+type entry struct {
+	value  [len(actions)]float64
+	visits [len(actions)]int
+}
+
+// Table is a learned decision table. It maps every situation to the estimated return of each action, as a multiple
+// of the bet, and plays the action with the best estimate.
+//
+// Table implements blackjack.AI, always betting the minimum.
+type Table struct {
+	entries map[Key]*entry
+}
+
+func newTable() *Table {
+	return &Table{
+		entries: make(map[Key]*entry),
+	}
+}
+
+func (t *Table) entry(k Key) *entry {
+	e, ok := t.entries[k]
+	if !ok {
+		e = &entry{}
+		t.entries[k] = e
+	}
+
+	return e
+}
+
+// update folds the return of one more visit into the estimate for action a in k.
+func (t *Table) update(k Key, a blackjack.Action, ret float64) {
+	e := t.entry(k)
+	e.visits[a]++
+	e.value[a] += (ret - e.value[a]) / float64(e.visits[a])
+}
+
+// best returns the action in choices with the highest estimate for k. Actions that were never tried lose to those
+// that were.
+func (t *Table) best(k Key, choices []blackjack.Action) blackjack.Action {
+	e, ok := t.entries[k]
+	if !ok {
+		return choices[0]
+	}
+
+	best := choices[0]
+
+	for _, a := range choices[1:] {
+		switch {
+		case e.visits[a] == 0:
+			continue
+		case e.visits[best] == 0, e.value[a] > e.value[best]:
+			best = a
+		}
+	}
+
+	return best
+}
+
+// Action returns the best action for k, assuming any action is allowed.
+func (t *Table) Action(k Key) blackjack.Action {
+	choices := []blackjack.Action{blackjack.ActionHit, blackjack.ActionStand, blackjack.ActionDouble}
+	if k.Pair {
+		choices = append(choices, blackjack.ActionSplit)
+	}
+
+	return t.best(k, choices)
+}
+
+// Keys returns every situation the table has seen, ordered by pairs, soft and then hard totals.
+func (t *Table) Keys() []Key {
+	keys := make([]Key, 0, len(t.entries))
+
+	for k := range t.entries {
+		keys = append(keys, k)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+
+		switch {
+		case a.Pair != b.Pair:
+			return a.Pair
+		case a.Soft != b.Soft:
+			return a.Soft
+		case a.Total != b.Total:
+			return a.Total < b.Total
+		default:
+			return a.Dealer < b.Dealer
+		}
+	})
+
+	return keys
+}
+
+// Visits returns how many times k was seen while training.
+func (t *Table) Visits(k Key) int {
+	e, ok := t.entries[k]
+	if !ok {
+		return 0
+	}
+
+	total := 0
+	for _, n := range e.visits {
+		total += n
+	}
+
+	return total
+}
+
+func (t *Table) Bet(shuffled bool) int {
+	return minBet
+}
+
+func (t *Table) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	return t.best(KeyFor(hand, dealer), legal(hand)).Move()
+}
+
+func (t *Table) Results(hands [][]deck.Card, dealer []deck.Card) {
+	// DO NOTHING
+}
+
+var csvHeader = []string{"total", "soft", "pair", "dealer", "action", "hit", "stand", "double", "split", "visits"}
+
+// WriteCSV writes the table to w, one situation per row, with the estimated return of every action.
+func (t *Table) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, k := range t.Keys() {
+		e := t.entries[k]
+
+		row := []string{
+			strconv.Itoa(k.Total),
+			strconv.FormatBool(k.Soft),
+			strconv.FormatBool(k.Pair),
+			strconv.Itoa(k.Dealer),
+			letter(t.Action(k)),
+		}
+
+		for _, v := range e.value {
+			row = append(row, strconv.FormatFloat(v, 'f', 4, 64))
+		}
+
+		row = append(row, strconv.Itoa(t.Visits(k)))
+
+		if err := cw.Write(row); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,176 @@
+// Package trainer discovers blackjack strategies by playing against the blackjack engine, rather than having them
+// coded by hand.
+//
+// It uses Monte Carlo control: every round is played with an epsilon-greedy version of the current table, and each
+// decision made during the round is credited with what the round won or lost.
+package trainer
+
+import (
+	"fmt"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+	"math/rand"
+	"time"
+)
+
+const minBet = blackjack.MinBet
+
+type (
+	Options struct {
+		// Episodes is the number of rounds to learn from. Defaults to 1,000,000.
+		Episodes int
+		// Epsilon is how often a random action is explored instead of the best known one. Defaults to 0.1.
+		Epsilon float64
+		// Decks is the number of decks in the shoe. Defaults to 4.
+		Decks int
+		// Rules is the variant of blackjack being learned. Defaults to blackjack.Standard.
+		Rules blackjack.Rules
+		// Dealer plays the dealer's hand. Defaults to blackjack.H17.
+		Dealer blackjack.Dealer
+		// Seed seeds exploration and the shuffling of every shoe, so the same seed always trains the same table.
+		// Defaults to the current time.
+		Seed int64
+	}
+
+	visit struct {
+		key    Key
+		action blackjack.Action
+	}
+
+	// learner is the AI that plays while a table is being trained.
+	learner struct {
+		table   *Table
+		rand    *rand.Rand
+		epsilon float64
+		visits  []visit
+	}
+)
+
+func (l *learner) Bet(shuffled bool) int {
+	return minBet
+}
+
+func (l *learner) Play(hand []deck.Card, dealer deck.Card) blackjack.Move {
+	key := KeyFor(hand, dealer)
+	choices := legal(hand)
+
+	action := l.table.best(key, choices)
+	if l.rand.Float64() < l.epsilon {
+		action = choices[l.rand.Intn(len(choices))]
+	}
+
+	l.visits = append(l.visits, visit{key: key, action: action})
+
+	return action.Move()
+}
+
+func (l *learner) Results(hands [][]deck.Card, dealer []deck.Card) {
+	// DO NOTHING
+}
+
+// Train learns a Table by playing opts.Episodes rounds of blackjack.
+func Train(opts Options) *Table {
+	if opts.Episodes == 0 {
+		opts.Episodes = 1000000
+	}
+
+	if opts.Epsilon == 0 {
+		opts.Epsilon = 0.1
+	}
+
+	if opts.Decks == 0 {
+		opts.Decks = 4
+	}
+
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+
+	l := &learner{
+		table:   newTable(),
+		rand:    rand.New(rand.NewSource(opts.Seed)),
+		epsilon: opts.Epsilon,
+	}
+
+	for i := 0; i < opts.Episodes; i++ {
+		// Each round gets its own game so the balance it returns is exactly what the round won or lost.
+		game := blackjack.New(blackjack.Options{
+			Decks:  opts.Decks,
+			Hands:  1,
+			Rules:  opts.Rules,
+			Dealer: opts.Dealer,
+			Rand:   l.rand,
+		})
+
+		l.visits = l.visits[:0]
+		ret := float64(game.Play(l)) / minBet
+
+		for _, v := range l.visits {
+			l.table.update(v.key, v.action, ret)
+		}
+	}
+
+	return l.table
+}
+
+// Agreement counts how many situations two strategies agree on.
+type Agreement struct {
+	Matches int
+	Total   int
+}
+
+// Percent returns the share of situations agreed on, from 0 to 100.
+func (a Agreement) Percent() float64 {
+	if a.Total == 0 {
+		return 0
+	}
+
+	return float64(a.Matches) * 100 / float64(a.Total)
+}
+
+// Report compares a table with another strategy, split by the kind of hand.
+type Report struct {
+	Hard  Agreement
+	Soft  Agreement
+	Pairs Agreement
+}
+
+// Overall returns the agreement across every kind of hand.
+func (r Report) Overall() Agreement {
+	return Agreement{
+		Matches: r.Hard.Matches + r.Soft.Matches + r.Pairs.Matches,
+		Total:   r.Hard.Total + r.Soft.Total + r.Pairs.Total,
+	}
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("hard %.1f%%, soft %.1f%%, pairs %.1f%%, overall %.1f%%",
+		r.Hard.Percent(), r.Soft.Percent(), r.Pairs.Percent(), r.Overall().Percent())
+}
+
+// Compare reports how closely the table follows strategy in every situation that can be reached with two cards and
+// that was visited at least minVisits times while training.
+func Compare(t *Table, strategy Strategy, minVisits int) Report {
+	var r Report
+
+	for _, k := range t.Keys() {
+		if k.Total < 4 || t.Visits(k) < minVisits {
+			continue
+		}
+
+		agreement := &r.Hard
+		switch {
+		case k.Pair:
+			agreement = &r.Pairs
+		case k.Soft:
+			agreement = &r.Soft
+		}
+
+		agreement.Total++
+		if t.Action(k) == strategy.Action(k) {
+			agreement.Matches++
+		}
+	}
+
+	return r
+}
@@ -0,0 +1,176 @@
+package trainer
+
+import (
+	"bytes"
+	"encoding/csv"
+	"github.com/jwambugu/gophercises/blackjack_ai/blackjack"
+	"github.com/jwambugu/gophercises/deck"
+	"testing"
+)
+
+func TestTrain(t *testing.T) {
+	// The seed also shuffles the shoe, so every run learns the same table and these situations have settled.
+	opts := Options{
+		Episodes: 50000,
+		Decks:    1,
+		Seed:     1,
+	}
+
+	table := Train(opts)
+
+	tests := []struct {
+		name string
+		key  Key
+		want blackjack.Action
+	}{
+		{"hard 20 vs 6", Key{Total: 20, Dealer: 6}, blackjack.ActionStand},
+		{"hard 19 vs 10", Key{Total: 19, Dealer: 10}, blackjack.ActionStand},
+		{"hard 5 vs 10", Key{Total: 5, Dealer: 10}, blackjack.ActionHit},
+		{"hard 11 vs 6", Key{Total: 11, Dealer: 6}, blackjack.ActionDouble},
+	}
+
+	for _, tt := range tests {
+		if got := table.Action(tt.key); got != tt.want {
+			t.Errorf("%s: want %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	again := Train(opts)
+	for _, k := range table.Keys() {
+		if table.Visits(k) != again.Visits(k) || table.Action(k) != again.Action(k) {
+			t.Fatalf("%s: expected the same seed to train the same table", k)
+		}
+	}
+
+	report := Compare(table, Basic{}, 100)
+	if report.Overall().Total == 0 {
+		t.Fatal("expected some situations to be compared")
+	}
+
+	if pct := report.Overall().Percent(); pct < 50 {
+		t.Errorf("expected the table to agree with basic strategy more often than not, got %s", report)
+	}
+}
+
+func TestTable_Play(t *testing.T) {
+	table := newTable()
+	key := Key{Total: 16, Dealer: 10}
+
+	table.update(key, blackjack.ActionHit, -0.5)
+	table.update(key, blackjack.ActionStand, -0.6)
+	table.update(key, blackjack.ActionDouble, 0.5)
+
+	two := []deck.Card{{Rank: deck.Ten}, {Rank: deck.Six}}
+	three := []deck.Card{{Rank: deck.Ten}, {Rank: deck.Two}, {Rank: deck.Four}}
+
+	if got := table.best(KeyFor(two, deck.Card{Rank: deck.King}), legal(two)); got != blackjack.ActionDouble {
+		t.Errorf("two cards: want %v, got %v", blackjack.ActionDouble, got)
+	}
+
+	// Doubling isn't allowed on three cards, so the next best action is played.
+	if got := table.best(KeyFor(three, deck.Card{Rank: deck.King}), legal(three)); got != blackjack.ActionHit {
+		t.Errorf("three cards: want %v, got %v", blackjack.ActionHit, got)
+	}
+}
+
+func TestTable_WriteCSV(t *testing.T) {
+	table := newTable()
+	table.update(Key{Total: 12, Dealer: 2}, blackjack.ActionHit, -0.25)
+	table.update(Key{Total: 12, Soft: true, Pair: true, Dealer: 11}, blackjack.ActionSplit, 0.5)
+
+	var buf bytes.Buffer
+	if err := table.WriteCSV(&buf); err != nil {
+		t.Fatalf("WriteCSV() received an error: %s", err)
+	}
+
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("failed to read the CSV back: %s", err)
+	}
+
+	if len(rows) != 3 {
+		t.Fatalf("expected %d rows, got %d", 3, len(rows))
+	}
+
+	// Pairs are written first.
+	want := []string{"12", "true", "true", "11", "P", "0.0000", "0.0000", "0.0000", "0.5000", "1"}
+	for i := range want {
+		if rows[1][i] != want[i] {
+			t.Errorf("rows[1][%d]: want %s, got %s", i, want[i], rows[1][i])
+		}
+	}
+}
+
+func TestBasic_Action(t *testing.T) {
+	tests := []struct {
+		key  Key
+		want blackjack.Action
+	}{
+		{Key{Total: 16, Dealer: 10}, blackjack.ActionHit},
+		{Key{Total: 16, Dealer: 6}, blackjack.ActionStand},
+		{Key{Total: 18, Soft: true, Dealer: 9}, blackjack.ActionHit},
+		{Key{Total: 12, Soft: true, Pair: true, Dealer: 11}, blackjack.ActionSplit},
+		{Key{Total: 20, Pair: true, Dealer: 6}, blackjack.ActionStand},
+		{Key{Total: 10, Pair: true, Dealer: 6}, blackjack.ActionDouble},
+	}
+
+	for _, tt := range tests {
+		if got := (Basic{}).Action(tt.key); got != tt.want {
+			t.Errorf("%+v: want %v, got %v", tt.key, tt.want, got)
+		}
+	}
+}
+
+func TestBasic_S17(t *testing.T) {
+	tests := []struct {
+		key      Key
+		h17, s17 blackjack.Action
+	}{
+		{Key{Total: 11, Dealer: 11}, blackjack.ActionDouble, blackjack.ActionHit},
+		{Key{Total: 19, Soft: true, Dealer: 6}, blackjack.ActionDouble, blackjack.ActionStand},
+		{Key{Total: 18, Soft: true, Dealer: 2}, blackjack.ActionDouble, blackjack.ActionStand},
+		{Key{Total: 16, Dealer: 10}, blackjack.ActionHit, blackjack.ActionHit},
+	}
+
+	for _, tt := range tests {
+		if got := (Basic{}).Action(tt.key); got != tt.h17 {
+			t.Errorf("%v H17: want %v, got %v", tt.key, tt.h17, got)
+		}
+
+		if got := (Basic{S17: true}).Action(tt.key); got != tt.s17 {
+			t.Errorf("%v S17: want %v, got %v", tt.key, tt.s17, got)
+		}
+	}
+}
+
+func TestRecommend(t *testing.T) {
+	three := []deck.Card{{Rank: deck.Two}, {Rank: deck.Four}, {Rank: deck.Five}}
+
+	// 11 says double, but three cards can't be doubled.
+	if got := Recommend(Basic{}, three, deck.Card{Rank: deck.Six}); got != blackjack.ActionHit {
+		t.Errorf("want %v, got %v", blackjack.ActionHit, got)
+	}
+}
+
+func TestKey_String(t *testing.T) {
+	tests := map[Key]string{
+		{Total: 16, Dealer: 10}:                        "hard 16 vs 10",
+		{Total: 18, Soft: true, Dealer: 11}:            "soft 18 vs A",
+		{Total: 16, Pair: true, Dealer: 6}:             "pair of 8s vs 6",
+		{Total: 12, Soft: true, Pair: true, Dealer: 2}: "pair of As vs 2",
+	}
+
+	for k, want := range tests {
+		if got := k.String(); got != want {
+			t.Errorf("want %q, got %q", want, got)
+		}
+	}
+}
+
+func TestRunningCount(t *testing.T) {
+	cards := []deck.Card{{Rank: deck.Two}, {Rank: deck.Six}, {Rank: deck.Seven}, {Rank: deck.King}, {Rank: deck.Ace}, {Rank: deck.Five}}
+
+	if got := RunningCount(cards...); got != 1 {
+		t.Errorf("want %d, got %d", 1, got)
+	}
+}
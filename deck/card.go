@@ -112,9 +112,20 @@ func Sort(less func(cards []Card) func(i, j int) bool) func([]Card) []Card {
 
 // Shuffle shuffles a deck in random order
 func Shuffle(cards []Card) []Card {
+	return shuffle(cards, shuffleRand)
+}
+
+// ShuffleWith shuffles a deck in an order picked by r, so the same seed always gives the same deck
+func ShuffleWith(r *rand.Rand) func([]Card) []Card {
+	return func(cards []Card) []Card {
+		return shuffle(cards, r)
+	}
+}
+
+func shuffle(cards []Card, r *rand.Rand) []Card {
 	shuffledCards := make([]Card, len(cards))
 
-	perm := shuffleRand.Perm(len(cards))
+	perm := r.Perm(len(cards))
 
 	for i, j := range perm {
 		shuffledCards[i] = cards[j]
@@ -77,6 +77,17 @@ func TestShuffle(t *testing.T) {
 
 }
 
+func TestShuffleWith(t *testing.T) {
+	first := New(ShuffleWith(rand.New(rand.NewSource(1))))
+	second := New(ShuffleWith(rand.New(rand.NewSource(1))))
+
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("expected the same seed to shuffle the same deck, got %q and %q at %d", first[i], second[i], i)
+		}
+	}
+}
+
 func TestJokers(t *testing.T) {
 	jokers := 3
 
@@ -0,0 +1,79 @@
+// Command hn-mock serves a fake Hacker News API, so quiet_hn can be developed and tested without a network:
+//
+//	go run ./cmd/hn-mock -stories 500 -latency 50ms -error_rate 0.05
+//	go run . -api http://localhost:8080/v0
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn/hntest"
+	"log"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+func main() {
+	var port, stories int
+	var dir string
+	var seed int64
+	var latency, jitter, churn time.Duration
+	var errorRate float64
+	var errorStatus int
+
+	flag.IntVar(&port, "port", 8080, "the port to serve the fake API on")
+	flag.StringVar(&dir, "dir", "", "serve the fixtures in this directory instead of generated data")
+	flag.IntVar(&stories, "stories", 500, "the number of stories to generate")
+	flag.Int64Var(&seed, "seed", 1, "the seed to generate data and inject latency and errors with")
+	flag.DurationVar(&latency, "latency", 0, "latency to add to every response")
+	flag.DurationVar(&jitter, "jitter", 0, "up to this much random latency to add on top of -latency")
+	flag.Float64Var(&errorRate, "error_rate", 0, "the fraction of requests, from 0 to 1, to fail")
+	flag.IntVar(&errorStatus, "error_status", http.StatusServiceUnavailable, "the status code failed requests get")
+	flag.DurationVar(&churn, "churn", 0, "change a random top story's score this often, to exercise the updates feed")
+	flag.Parse()
+
+	ds := hntest.Generate(seed, stories)
+
+	if dir != "" {
+		var err error
+		if ds, err = hntest.LoadDir(dir); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	h := hntest.NewHandler(ds, hntest.Options{
+		Latency:     latency,
+		Jitter:      jitter,
+		ErrorRate:   errorRate,
+		ErrorStatus: errorStatus,
+		Seed:        seed,
+	})
+
+	if churn > 0 {
+		go changeScores(h, ds, seed, churn)
+	}
+
+	log.Printf("Serving %d items on http://localhost:%d/v0", len(ds.Items), port)
+	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), h))
+}
+
+// changeScores upvotes a random top story every interval and moves it up the top stories accordingly.
+func changeScores(h *hntest.Handler, ds *hntest.Dataset, seed int64, interval time.Duration) {
+	r := rand.New(rand.NewSource(seed))
+	top := append([]int(nil), ds.Lists["topstories"]...)
+
+	for range time.Tick(interval) {
+		if len(top) < 2 {
+			return
+		}
+
+		i := 1 + r.Intn(len(top)-1)
+		story, _ := h.Item(top[i])
+		story.Score += 1 + r.Intn(10)
+		h.SetItem(story)
+
+		top[i-1], top[i] = top[i], top[i-1]
+		h.SetList("topstories", append([]int(nil), top...))
+	}
+}
@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/digest"
+	"github.com/jwambugu/gophercises/quiet_hn/history"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"io/ioutil"
+	"net"
+	"net/smtp"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// digestCandidates is the number of stories from each HN list considered for a digest.
+const digestCandidates = 100
+
+// digestConfig is the digest config file. See digest.example.json.
+type digestConfig struct {
+	// Schedule is daily or weekly, Weekday is the day weekly digests are sent and At the time of day.
+	Schedule string `json:"schedule"`
+	Weekday  string `json:"weekday"`
+	At       string `json:"at"`
+	Stories  int    `json:"stories"`
+	SentLog  string `json:"sent_log"`
+
+	Email []struct {
+		Addr     string   `json:"addr"`
+		Username string   `json:"username"`
+		Password string   `json:"password"`
+		From     string   `json:"from"`
+		To       []string `json:"to"`
+		// Subject is a template, Template the path of a template file for the body.
+		Subject  string `json:"subject"`
+		Template string `json:"template"`
+	} `json:"email"`
+
+	Webhooks []struct {
+		URL      string `json:"url"`
+		Template string `json:"template"`
+	} `json:"webhooks"`
+
+	Files []struct {
+		Path     string `json:"path"`
+		Template string `json:"template"`
+	} `json:"files"`
+}
+
+// loadDigest reads the digest config file at path into a job. The job still needs a source.
+func loadDigest(path string) (*digest.Job, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var config digestConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, err
+	}
+
+	job := &digest.Job{Name: config.Schedule, Limit: config.Stories}
+
+	switch config.Schedule {
+	case "daily":
+	case "weekly":
+		job.Schedule.Weekly = true
+		job.Schedule.Weekday = time.Monday
+
+		if config.Weekday != "" {
+			if job.Schedule.Weekday, err = parseWeekday(config.Weekday); err != nil {
+				return nil, err
+			}
+		}
+	default:
+		return nil, fmt.Errorf("schedule must be daily or weekly, not %q", config.Schedule)
+	}
+
+	if config.At != "" {
+		at, err := time.Parse("15:04", config.At)
+		if err != nil {
+			return nil, fmt.Errorf("at must be a time like 08:00, not %q", config.At)
+		}
+
+		job.Schedule.At = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+	}
+
+	// Without a sent log, stories are only remembered until a restart.
+	job.Sent = &digest.SentLog{}
+	if config.SentLog != "" {
+		if job.Sent, err = digest.OpenSentLog(config.SentLog, 60*24*time.Hour); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, c := range config.Email {
+		if c.Addr == "" || c.From == "" || len(c.To) == 0 {
+			return nil, errors.New("email needs an addr, a from address and to addresses")
+		}
+
+		n := &digest.SMTP{Addr: c.Addr, From: c.From, To: c.To}
+
+		if c.Username != "" {
+			host, _, _ := net.SplitHostPort(c.Addr)
+			n.Auth = smtp.PlainAuth("", c.Username, c.Password, host)
+		}
+
+		if c.Subject != "" {
+			if n.Subject, err = digest.Parse("subject", c.Subject); err != nil {
+				return nil, err
+			}
+		}
+
+		if n.Text, err = loadTemplate(c.Template); err != nil {
+			return nil, err
+		}
+
+		job.Notifiers = append(job.Notifiers, n)
+	}
+
+	for _, c := range config.Webhooks {
+		if !strings.HasPrefix(c.URL, "http://") && !strings.HasPrefix(c.URL, "https://") {
+			return nil, fmt.Errorf("webhook url must be http or https, not %q", c.URL)
+		}
+
+		n := &digest.Webhook{URL: c.URL}
+		if n.Template, err = loadTemplate(c.Template); err != nil {
+			return nil, err
+		}
+
+		job.Notifiers = append(job.Notifiers, n)
+	}
+
+	for _, c := range config.Files {
+		if c.Path == "" {
+			return nil, errors.New("files need a path")
+		}
+
+		n := &digest.File{Path: c.Path}
+		if n.Template, err = loadTemplate(c.Template); err != nil {
+			return nil, err
+		}
+
+		job.Notifiers = append(job.Notifiers, n)
+	}
+
+	if len(job.Notifiers) == 0 {
+		return nil, errors.New("there is nowhere to send the digest, add email, webhooks or files")
+	}
+
+	return job, nil
+}
+
+func parseWeekday(s string) (time.Weekday, error) {
+	for d := time.Sunday; d <= time.Saturday; d++ {
+		if strings.EqualFold(d.String(), s) {
+			return d, nil
+		}
+	}
+
+	return 0, fmt.Errorf("%q isn't a day of the week", s)
+}
+
+// loadTemplate parses the template file at path, or returns nil for the notifier's default if there's no path.
+func loadTemplate(path string) (*template.Template, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return digest.Parse(path, string(data))
+}
+
+// digestSource gathers the stories posted in a digest's period from the top and best lists and, when ranks are
+// recorded, every story that was ranked in the period. Stories hidden by the rules are left out, the rest are sorted
+// by score.
+func digestSource(client *hn.Client, rules *ruleSet, store *history.Store, batch hn.BatchOptions) digest.Source {
+	return func(ctx context.Context, from, to time.Time) ([]digest.Story, error) {
+		var ids []int
+
+		for _, list := range []func(*hn.Client, context.Context) ([]int, error){
+			(*hn.Client).TopItemsContext,
+			(*hn.Client).BestStoriesContext,
+		} {
+			more, err := list(client, ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			if len(more) > digestCandidates {
+				more = more[:digestCandidates]
+			}
+
+			ids = append(ids, more...)
+		}
+
+		if store != nil {
+			for _, p := range store.Peaks(from, to) {
+				ids = append(ids, p.ID)
+			}
+		}
+
+		seen := make(map[int]bool)
+		unique := ids[:0]
+
+		for _, id := range ids {
+			if !seen[id] {
+				seen[id] = true
+				unique = append(unique, id)
+			}
+		}
+
+		var stories []item
+
+		for _, r := range client.GetItems(ctx, unique, batch) {
+			if r.Err != nil {
+				continue
+			}
+
+			story := parseHNItem(r.Item)
+			posted := time.Unix(int64(story.Time), 0)
+
+			if !isStory(story) || posted.Before(from) || !posted.Before(to) {
+				continue
+			}
+
+			if _, rule := rules.apply(story, to); rule != "" {
+				continue
+			}
+
+			stories = append(stories, story)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		sort.SliceStable(stories, func(i, j int) bool {
+			return stories[i].Score > stories[j].Score
+		})
+
+		ret := make([]digest.Story, len(stories))
+
+		for i, story := range stories {
+			ret[i] = digest.Story{
+				ID:         story.ID,
+				Title:      story.Title,
+				URL:        story.URL,
+				Host:       story.Host,
+				By:         story.By,
+				Score:      story.Score,
+				Comments:   story.Descendants,
+				Time:       time.Unix(int64(story.Time), 0),
+				Discussion: discussion(story.ID),
+			}
+		}
+
+		return ret, nil
+	}
+}
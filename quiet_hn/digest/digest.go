@@ -0,0 +1,267 @@
+// Package digest gathers the top stories of a period and delivers them through notifiers, such as email or a webhook,
+// on a schedule. Stories a notifier already delivered aren't sent through it again.
+package digest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Story is a story in a digest.
+type Story struct {
+	ID         int       `json:"id"`
+	Title      string    `json:"title"`
+	URL        string    `json:"url,omitempty"`
+	Host       string    `json:"host,omitempty"`
+	By         string    `json:"by"`
+	Score      int       `json:"score"`
+	Comments   int       `json:"comments"`
+	Time       time.Time `json:"time"`
+	Discussion string    `json:"discussion"`
+}
+
+// Digest is the stories of a period, best first.
+type Digest struct {
+	// Name is the kind of digest, such as daily.
+	Name    string    `json:"name"`
+	From    time.Time `json:"from"`
+	To      time.Time `json:"to"`
+	Stories []Story   `json:"stories"`
+}
+
+// Notifier delivers digests.
+type Notifier interface {
+	// Name identifies the notifier, the stories it sent are remembered by it.
+	Name() string
+	Notify(ctx context.Context, d Digest) error
+}
+
+// Source returns the candidate stories posted from from up to to, best first.
+type Source func(ctx context.Context, from, to time.Time) ([]Story, error)
+
+// Schedule is when a digest is sent: every day, or every week on Weekday, At a time after midnight.
+type Schedule struct {
+	Weekly  bool
+	Weekday time.Weekday
+	At      time.Duration
+}
+
+// Period is how far back a digest on the schedule looks.
+func (s Schedule) Period() time.Duration {
+	if s.Weekly {
+		return 7 * 24 * time.Hour
+	}
+
+	return 24 * time.Hour
+}
+
+// Next returns the first time on the schedule after t, in t's location.
+func (s Schedule) Next(t time.Time) time.Time {
+	y, m, d := t.Date()
+	next := time.Date(y, m, d, 0, 0, 0, 0, t.Location()).Add(s.At)
+
+	for !next.After(t) || (s.Weekly && next.Weekday() != s.Weekday) {
+		y, m, d = next.Date()
+		// Adding a day to the date rather than 24 hours keeps the time of day across daylight saving changes.
+		next = time.Date(y, m, d+1, 0, 0, 0, 0, t.Location()).Add(s.At)
+	}
+
+	return next
+}
+
+// Job sends a digest through each of its notifiers on a schedule.
+type Job struct {
+	Name      string
+	Schedule  Schedule
+	Source    Source
+	Notifiers []Notifier
+	// Sent remembers the stories each notifier sent. Defaults to a log that is only kept in memory.
+	Sent *SentLog
+	// Limit is the most stories in a digest. Defaults to 20.
+	Limit int
+
+	// mutex serialises runs, so a digest sent on demand and one sent on schedule can't both send the same stories.
+	mutex sync.Mutex
+}
+
+// Run sends the digest of the period up to now. Each notifier gets the best stories it hasn't sent before, and is
+// skipped if there are none. Run carries on with the other notifiers if one fails, and returns all their errors.
+// Runs happen one at a time.
+func (j *Job) Run(ctx context.Context, now time.Time) error {
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+
+	if j.Sent == nil {
+		j.Sent = &SentLog{}
+	}
+
+	limit := j.Limit
+	if limit <= 0 {
+		limit = 20
+	}
+
+	from := now.Add(-j.Schedule.Period())
+
+	stories, err := j.Source(ctx, from, now)
+	if err != nil {
+		return fmt.Errorf("digest: failed to gather stories: %w", err)
+	}
+
+	var errs []string
+
+	for _, n := range j.Notifiers {
+		d := Digest{Name: j.Name, From: from, To: now}
+
+		for _, s := range stories {
+			if len(d.Stories) == limit {
+				break
+			}
+
+			if !j.Sent.Sent(n.Name(), s.ID) {
+				d.Stories = append(d.Stories, s)
+			}
+		}
+
+		if len(d.Stories) == 0 {
+			continue
+		}
+
+		if err := n.Notify(ctx, d); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", n.Name(), err))
+			continue
+		}
+
+		if err := j.Sent.Mark(n.Name(), d.Stories, now); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: failed to remember the stories sent: %v", n.Name(), err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return fmt.Errorf("digest: %s", strings.Join(errs, "; "))
+	}
+
+	return nil
+}
+
+// Start runs the job on its schedule until ctx is cancelled. Failures are logged.
+func (j *Job) Start(ctx context.Context) {
+	for {
+		next := j.Schedule.Next(time.Now())
+		timer := time.NewTimer(time.Until(next))
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := j.Run(ctx, next); err != nil {
+			log.Printf("failed to send the %s digest: %v", j.Name, err)
+		}
+	}
+}
+
+// SentLog remembers which stories each notifier sent, in a JSON file if it has a path.
+type SentLog struct {
+	path string
+	// keep is how long sent stories are remembered, they are well out of any digest by then.
+	keep time.Duration
+
+	mutex sync.Mutex
+	sent  map[string]map[int]time.Time
+}
+
+// OpenSentLog reads the log in path, creating it when stories are first marked as sent. Stories are forgotten after
+// keep, a keep of 0 remembers them forever.
+func OpenSentLog(path string, keep time.Duration) (*SentLog, error) {
+	l := &SentLog{path: path, keep: keep}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return l, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &l.sent); err != nil {
+		return nil, fmt.Errorf("digest: %s is corrupt: %w", path, err)
+	}
+
+	return l, nil
+}
+
+// Sent reports whether the notifier sent the story.
+func (l *SentLog) Sent(notifier string, id int) bool {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	_, ok := l.sent[notifier][id]
+	return ok
+}
+
+// Mark remembers that the notifier sent the stories at t, and forgets the ones sent too long ago.
+func (l *SentLog) Mark(notifier string, stories []Story, t time.Time) error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if l.sent == nil {
+		l.sent = make(map[string]map[int]time.Time)
+	}
+
+	sent, ok := l.sent[notifier]
+	if !ok {
+		sent = make(map[int]time.Time)
+		l.sent[notifier] = sent
+	}
+
+	for _, s := range stories {
+		sent[s.ID] = t
+	}
+
+	if l.keep > 0 {
+		for _, sent := range l.sent {
+			for id, at := range sent {
+				if t.Sub(at) > l.keep {
+					delete(sent, id)
+				}
+			}
+		}
+	}
+
+	if l.path == "" {
+		return nil
+	}
+
+	data, err := json.Marshal(l.sent)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(l.path), filepath.Base(l.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), l.path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+
+	return err
+}
@@ -0,0 +1,476 @@
+package digest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+var now = time.Date(2020, 6, 8, 8, 0, 0, 0, time.UTC)
+
+func stories(ids ...int) []Story {
+	ret := make([]Story, len(ids))
+	for i, id := range ids {
+		ret[i] = Story{
+			ID:         id,
+			Title:      fmt.Sprintf("Story %d", id),
+			URL:        fmt.Sprintf("https://example.com/%d", id),
+			Host:       "example.com",
+			By:         "pg",
+			Score:      100 - id,
+			Time:       now.Add(-time.Hour),
+			Discussion: fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id),
+		}
+	}
+
+	return ret
+}
+
+func TestSchedule_Next(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule Schedule
+		t        time.Time
+		want     time.Time
+	}{
+		{name: "later today", schedule: Schedule{At: 9 * time.Hour}, t: now, want: now.Add(time.Hour)},
+		{name: "tomorrow", schedule: Schedule{At: 8 * time.Hour}, t: now, want: now.AddDate(0, 0, 1)},
+		{name: "next week", schedule: Schedule{Weekly: true, Weekday: time.Monday, At: 8 * time.Hour}, t: now,
+			want: now.AddDate(0, 0, 7)},
+		{name: "this week", schedule: Schedule{Weekly: true, Weekday: time.Friday, At: 7 * time.Hour}, t: now,
+			want: time.Date(2020, 6, 12, 7, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.schedule.Next(tt.t); !got.Equal(tt.want) {
+				t.Errorf("Next(): want %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+// recorder is a Notifier that keeps the digests it was sent.
+type recorder struct {
+	name    string
+	err     error
+	digests []Digest
+}
+
+func (r *recorder) Name() string {
+	return r.name
+}
+
+func (r *recorder) Notify(ctx context.Context, d Digest) error {
+	if r.err != nil {
+		return r.err
+	}
+
+	r.digests = append(r.digests, d)
+	return nil
+}
+
+func ids(d Digest) []int {
+	var ret []int
+	for _, s := range d.Stories {
+		ret = append(ret, s.ID)
+	}
+
+	return ret
+}
+
+func TestJob_Run(t *testing.T) {
+	dir, err := ioutil.TempDir("", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	sent, err := OpenSentLog(filepath.Join(dir, "sent.json"), 30*24*time.Hour)
+	if err != nil {
+		t.Fatalf("OpenSentLog() received an error: %s", err.Error())
+	}
+
+	available := stories(1, 2, 3)
+
+	var window [2]time.Time
+	email, hook := &recorder{name: "email"}, &recorder{name: "hook"}
+
+	j := &Job{
+		Name:     "daily",
+		Schedule: Schedule{At: 8 * time.Hour},
+		Source: func(ctx context.Context, from, to time.Time) ([]Story, error) {
+			window = [2]time.Time{from, to}
+			return available, nil
+		},
+		Notifiers: []Notifier{email, hook},
+		Sent:      sent,
+		Limit:     2,
+	}
+
+	if err := j.Run(context.Background(), now); err != nil {
+		t.Fatalf("j.Run() received an error: %s", err.Error())
+	}
+
+	if want := [2]time.Time{now.Add(-24 * time.Hour), now}; window != want {
+		t.Errorf("window: want %v, got %v", want, window)
+	}
+
+	if got := ids(email.digests[0]); !reflect.DeepEqual(got, []int{1, 2}) {
+		t.Errorf("first digest: want %v, got %v", []int{1, 2}, got)
+	}
+
+	// The hook fails the next time, so it is sent its stories again after that.
+	hook.err = errors.New("down")
+	available = stories(1, 2, 3, 4, 5)
+
+	if err := j.Run(context.Background(), now.AddDate(0, 0, 1)); err == nil || !strings.Contains(err.Error(), "hook: down") {
+		t.Errorf("expected the hook's error, got %v", err)
+	}
+
+	if got := ids(email.digests[1]); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("second digest: want %v, got %v", []int{3, 4}, got)
+	}
+
+	hook.err = nil
+
+	// The log is read back from its file, as it would be after a restart.
+	if j.Sent, err = OpenSentLog(filepath.Join(dir, "sent.json"), 30*24*time.Hour); err != nil {
+		t.Fatalf("OpenSentLog() received an error: %s", err.Error())
+	}
+
+	if err := j.Run(context.Background(), now.AddDate(0, 0, 2)); err != nil {
+		t.Fatalf("j.Run() received an error: %s", err.Error())
+	}
+
+	if got := ids(email.digests[2]); !reflect.DeepEqual(got, []int{5}) {
+		t.Errorf("third digest: want %v, got %v", []int{5}, got)
+	}
+
+	if got := ids(hook.digests[1]); !reflect.DeepEqual(got, []int{3, 4}) {
+		t.Errorf("the hook's second digest: want %v, got %v", []int{3, 4}, got)
+	}
+
+	// Nothing new, so nothing is sent.
+	if err := j.Run(context.Background(), now.AddDate(0, 0, 3)); err != nil {
+		t.Fatalf("j.Run() received an error: %s", err.Error())
+	}
+
+	if len(email.digests) != 3 {
+		t.Errorf("expected an empty digest not to be sent, got %d digests", len(email.digests))
+	}
+}
+
+func TestJob_Run_concurrent(t *testing.T) {
+	email := &recorder{name: "email"}
+
+	// The job has no sent log, as when it is only kept in memory.
+	j := &Job{
+		Name: "daily",
+		Source: func(ctx context.Context, from, to time.Time) ([]Story, error) {
+			return stories(1, 2), nil
+		},
+		Notifiers: []Notifier{email},
+	}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if err := j.Run(context.Background(), now); err != nil {
+				t.Errorf("j.Run() received an error: %s", err.Error())
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if len(email.digests) != 1 {
+		t.Errorf("expected the stories to be sent once, got %d digests", len(email.digests))
+	}
+}
+
+// fakeSMTP is a local SMTP stand-in that accepts every message.
+type fakeSMTP struct {
+	listener net.Listener
+
+	mutex    sync.Mutex
+	from     string
+	to       []string
+	messages []string
+}
+
+func newFakeSMTP(t *testing.T) *fakeSMTP {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s := &fakeSMTP{listener: l}
+	go s.serve()
+
+	return s
+}
+
+func (s *fakeSMTP) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go s.handle(conn)
+	}
+}
+
+func (s *fakeSMTP) handle(conn net.Conn) {
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	reply := func(line string) {
+		fmt.Fprintf(conn, "%s\r\n", line)
+	}
+
+	reply("220 localhost fake SMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+
+		cmd := strings.ToUpper(strings.TrimSpace(line))
+
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			reply("250 localhost")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mutex.Lock()
+			s.from = strings.TrimSpace(line)[len("MAIL FROM:"):]
+			s.mutex.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			s.mutex.Lock()
+			s.to = append(s.to, strings.TrimSpace(line)[len("RCPT TO:"):])
+			s.mutex.Unlock()
+			reply("250 OK")
+		case cmd == "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+
+			var msg strings.Builder
+			for {
+				line, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if line == ".\r\n" {
+					break
+				}
+
+				msg.WriteString(strings.TrimPrefix(line, "."))
+			}
+
+			s.mutex.Lock()
+			s.messages = append(s.messages, msg.String())
+			s.mutex.Unlock()
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Not implemented")
+		}
+	}
+}
+
+func TestSMTP_Notify(t *testing.T) {
+	server := newFakeSMTP(t)
+	defer server.listener.Close()
+
+	n := &SMTP{
+		Addr: server.listener.Addr().String(),
+		From: "digest@example.com",
+		To:   []string{"alice@example.com", "bob@example.com"},
+	}
+
+	d := Digest{Name: "daily", From: now.Add(-24 * time.Hour), To: now, Stories: stories(1, 2)}
+	d.Stories[1].Title = ".dotfiles are great"
+
+	if err := n.Notify(context.Background(), d); err != nil {
+		t.Fatalf("n.Notify() received an error: %s", err.Error())
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if server.from != "<digest@example.com>" {
+		t.Errorf("from: want %s, got %s", "<digest@example.com>", server.from)
+	}
+
+	if want := []string{"<alice@example.com>", "<bob@example.com>"}; !reflect.DeepEqual(server.to, want) {
+		t.Errorf("to: want %v, got %v", want, server.to)
+	}
+
+	if len(server.messages) != 1 {
+		t.Fatalf("expected a message, got %d", len(server.messages))
+	}
+
+	msg := server.messages[0]
+
+	for _, want := range []string{
+		"Subject: Quiet Hacker News daily digest: 2 stories to Mon Jun 8\r\n",
+		"Content-Type: text/plain; charset=utf-8\r\n",
+		"1. Story 1 (example.com)\r\n",
+		"   99 points by pg, 0 comments\r\n",
+		"   https://news.ycombinator.com/item?id=1\r\n",
+		"2. .dotfiles are great (example.com)\r\n",
+	} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("expected the message to contain %q, got:\n%s", want, msg)
+		}
+	}
+}
+
+func TestSMTP_Notify_noAuth(t *testing.T) {
+	server := newFakeSMTP(t)
+	defer server.listener.Close()
+
+	n := &SMTP{
+		Addr: server.listener.Addr().String(),
+		From: "digest@example.com",
+		To:   []string{"alice@example.com"},
+		Auth: smtp.PlainAuth("", "digest", "secret", "127.0.0.1"),
+	}
+
+	// The fake server doesn't offer AUTH, so the credentials can't be used.
+	if err := n.Notify(context.Background(), Digest{Stories: stories(1)}); err == nil {
+		t.Errorf("expected an error when the server doesn't support AUTH")
+	}
+
+	server.mutex.Lock()
+	defer server.mutex.Unlock()
+
+	if len(server.messages) != 0 {
+		t.Errorf("expected nothing to be sent without authenticating, got %d messages", len(server.messages))
+	}
+}
+
+func TestSMTP_Notify_timeout(t *testing.T) {
+	// A server that accepts connections but never says anything.
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer l.Close()
+
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+
+	n := &SMTP{Addr: l.Addr().String(), From: "a@example.com", To: []string{"b@example.com"}, Timeout: 50 * time.Millisecond}
+
+	start := time.Now()
+	if err := n.Notify(context.Background(), Digest{Stories: stories(1)}); err == nil {
+		t.Errorf("expected a silent server to time out")
+	}
+
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("expected the timeout to stop the email, it took %v", elapsed)
+	}
+}
+
+func TestWebhook_Notify(t *testing.T) {
+	var got map[string]interface{}
+	var contentType string
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		contentType = r.Header.Get("Content-Type")
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Errorf("expected a JSON body: %v", err)
+		}
+	}))
+	defer server.Close()
+
+	d := Digest{Name: "weekly", Stories: stories(1)}
+	d.Stories[0].Title = `A "quoted" title`
+
+	if err := (&Webhook{URL: server.URL}).Notify(context.Background(), d); err != nil {
+		t.Fatalf("Notify() received an error: %s", err.Error())
+	}
+
+	if contentType != "application/json" {
+		t.Errorf("Content-Type: want %s, got %s", "application/json", contentType)
+	}
+
+	if got["text"] != "Quiet Hacker News weekly digest: 1 stories" {
+		t.Errorf("text: want %s, got %v", "Quiet Hacker News weekly digest: 1 stories", got["text"])
+	}
+
+	first := got["stories"].([]interface{})[0].(map[string]interface{})
+	if first["title"] != `A "quoted" title` {
+		t.Errorf("title: want %s, got %v", `A "quoted" title`, first["title"])
+	}
+
+	custom := &Webhook{URL: server.URL, Template: MustParse("slack", `{"text": "{{range .Stories}}{{.Title}}{{end}}"}`)}
+	if err := custom.Notify(context.Background(), d); err == nil {
+		t.Errorf("expected an error for a template that makes invalid JSON")
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	if err := (&Webhook{URL: failing.URL}).Notify(context.Background(), d); err == nil {
+		t.Errorf("expected an error for a failed request")
+	}
+}
+
+func TestFile_Notify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "digest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	f := &File{
+		Path:     filepath.Join(dir, "digest.txt"),
+		Template: MustParse("file", `{{.Name}}:{{range .Stories}} {{.ID}}{{end}}`),
+	}
+
+	for _, d := range []Digest{{Name: "daily", Stories: stories(1, 2)}, {Name: "daily", Stories: stories(3)}} {
+		if err := f.Notify(context.Background(), d); err != nil {
+			t.Fatalf("f.Notify() received an error: %s", err.Error())
+		}
+	}
+
+	data, err := ioutil.ReadFile(f.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if want := "daily: 1 2\ndaily: 3\n"; string(data) != want {
+		t.Errorf("file: want %q, got %q", want, string(data))
+	}
+}
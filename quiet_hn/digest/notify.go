@@ -0,0 +1,281 @@
+package digest
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// funcs are the functions notifier templates can use.
+var funcs = template.FuncMap{
+	// json writes a value as JSON, so webhook templates can embed strings safely.
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"date": func(t time.Time) string {
+		return t.Format("Mon Jan 2")
+	},
+	// add numbers lists from 1.
+	"add": func(a, b int) int {
+		return a + b
+	},
+}
+
+// MustParse parses a notifier template, panicking if it is invalid.
+func MustParse(name, text string) *template.Template {
+	return template.Must(Parse(name, text))
+}
+
+// Parse parses a notifier template, with the json, date and add functions.
+func Parse(name, text string) (*template.Template, error) {
+	return template.New(name).Funcs(funcs).Parse(text)
+}
+
+// The default templates.
+var (
+	DefaultSubject = MustParse("subject", `Quiet Hacker News {{.Name}} digest: {{len .Stories}} stories to {{date .To}}`)
+
+	DefaultText = MustParse("text", `Quiet Hacker News {{.Name}} digest, {{date .From}} to {{date .To}}
+{{range $i, $s := .Stories}}
+{{add $i 1}}. {{.Title}}{{if .Host}} ({{.Host}}){{end}}
+   {{.Score}} points by {{.By}}, {{.Comments}} comments
+   {{if .URL}}{{.URL}}
+   {{end}}{{.Discussion}}
+{{end}}`)
+
+	DefaultWebhook = MustParse("webhook", `{"text": {{json (printf "Quiet Hacker News %s digest: %d stories" .Name (len .Stories))}},
+"stories": [{{range $i, $s := .Stories}}{{if $i}}, {{end}}{{json $s}}{{end}}]}`)
+)
+
+func execute(tpl *template.Template, d Digest) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, d); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// SMTP emails digests as plain text.
+type SMTP struct {
+	// Addr is the host:port of the mail server.
+	Addr string
+	// Auth is used if the server supports it. Leave it nil for servers that don't need it.
+	Auth smtp.Auth
+	From string
+	To   []string
+	// Subject and Text are the templates of the email. They default to DefaultSubject and DefaultText.
+	Subject *template.Template
+	Text    *template.Template
+	// Timeout limits how long sending an email may take. Defaults to 30 seconds.
+	Timeout time.Duration
+}
+
+func (s *SMTP) Name() string {
+	return "smtp:" + strings.Join(s.To, ",")
+}
+
+func (s *SMTP) Notify(ctx context.Context, d Digest) error {
+	subjectTpl, textTpl := s.Subject, s.Text
+	if subjectTpl == nil {
+		subjectTpl = DefaultSubject
+	}
+	if textTpl == nil {
+		textTpl = DefaultText
+	}
+
+	subject, err := execute(subjectTpl, d)
+	if err != nil {
+		return err
+	}
+
+	text, err := execute(textTpl, d)
+	if err != nil {
+		return err
+	}
+
+	timeout := s.Timeout
+	if timeout == 0 {
+		timeout = 30 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	// The connection is closed if ctx is done first, so a stuck server can't hold the job up.
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+	}
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return err
+		}
+	}
+
+	if s.Auth != nil {
+		// Sending without the credentials would most likely be refused, or relayed without them being checked.
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("digest: the server doesn't support AUTH, so the username and password can't be used")
+		}
+
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+
+	if err := c.Mail(s.From); err != nil {
+		return err
+	}
+
+	for _, to := range s.To {
+		if err := c.Rcpt(to); err != nil {
+			return err
+		}
+	}
+
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+
+	headers := []string{
+		"From: " + s.From,
+		"To: " + strings.Join(s.To, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", strings.TrimSpace(string(subject))),
+		"Date: " + d.To.Format(time.RFC1123Z),
+		"MIME-Version: 1.0",
+		"Content-Type: text/plain; charset=utf-8",
+		"Content-Transfer-Encoding: 8bit",
+	}
+
+	// The data writer ends lines with CRLF and escapes lines starting with a dot.
+	msg := strings.Join(headers, "\n") + "\n\n" + string(text)
+
+	if _, err := w.Write([]byte(msg)); err != nil {
+		return err
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	return c.Quit()
+}
+
+// Webhook posts digests as JSON.
+type Webhook struct {
+	URL string
+	// Client sends the requests. Defaults to a client with a 30 second timeout.
+	Client *http.Client
+	// Template writes the body, which must be valid JSON. Defaults to DefaultWebhook.
+	Template *template.Template
+}
+
+var defaultWebhookClient = &http.Client{Timeout: 30 * time.Second}
+
+func (wh *Webhook) Name() string {
+	return "webhook:" + wh.URL
+}
+
+func (wh *Webhook) Notify(ctx context.Context, d Digest) error {
+	tpl := wh.Template
+	if tpl == nil {
+		tpl = DefaultWebhook
+	}
+
+	body, err := execute(tpl, d)
+	if err != nil {
+		return err
+	}
+
+	if !json.Valid(body) {
+		return errors.New("the webhook template didn't make valid JSON")
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	client := wh.Client
+	if client == nil {
+		client = defaultWebhookClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s returned %d", wh.URL, resp.StatusCode)
+	}
+
+	return nil
+}
+
+// File appends digests to a plain text file.
+type File struct {
+	Path string
+	// Template writes each digest. Defaults to DefaultText.
+	Template *template.Template
+}
+
+func (f *File) Name() string {
+	return "file:" + f.Path
+}
+
+func (f *File) Notify(ctx context.Context, d Digest) error {
+	tpl := f.Template
+	if tpl == nil {
+		tpl = DefaultText
+	}
+
+	text, err := execute(tpl, d)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(f.Path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(append(text, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
@@ -0,0 +1,299 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// reserved are the networks that aren't reachable on the public internet, such as loopback, private and link-local
+// addresses.
+var reserved = func() []*net.IPNet {
+	var nets []*net.IPNet
+
+	for _, cidr := range []string{
+		"0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "127.0.0.0/8", "169.254.0.0/16", "172.16.0.0/12",
+		"192.0.0.0/24", "192.0.2.0/24", "192.168.0.0/16", "198.18.0.0/15", "198.51.100.0/24", "203.0.113.0/24",
+		"224.0.0.0/4", "240.0.0.0/4", "::/128", "::1/128", "64:ff9b::/96", "100::/64", "2001:db8::/32", "fc00::/7",
+		"fe80::/10", "ff00::/8",
+	} {
+		_, n, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+
+		nets = append(nets, n)
+	}
+
+	return nets
+}()
+
+// public reports whether ip is an address on the public internet.
+func public(ip net.IP) bool {
+	// IPv4 addresses mapped into IPv6 are checked as IPv4.
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+
+	for _, n := range reserved {
+		if n.Contains(ip) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// publicOnly is a net.Dialer Control hook that refuses to connect to addresses that aren't public. It runs after the
+// host name is resolved, so a name pointing at an internal address is refused as well.
+func publicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+
+	if ip := net.ParseIP(host); ip == nil || !public(ip) {
+		return fmt.Errorf("enrich: %s isn't a public address", host)
+	}
+
+	return nil
+}
+
+// publicClient only connects to public addresses, so story links can't be used to reach the server's own network or
+// its cloud metadata service. It doesn't use a proxy, the proxy would make the connections instead.
+var publicClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 5 * time.Second,
+			Control: publicOnly,
+		}).DialContext,
+		TLSHandshakeTimeout: 5 * time.Second,
+		MaxIdleConns:        100,
+		IdleConnTimeout:     90 * time.Second,
+	},
+}
+
+// Fetcher fetches pages with strict limits, so a slow or huge page can't hold anything up.
+type Fetcher struct {
+	// Client sends the requests. Defaults to a client that only connects to public addresses, including after
+	// redirects. A Client set here is used as it is.
+	Client *http.Client
+	// MaxBytes is how much of a page is read. Defaults to 512KB, which covers the metadata and most articles.
+	MaxBytes int64
+	// Timeout limits how long fetching a page may take. Defaults to 5 seconds.
+	Timeout   time.Duration
+	UserAgent string
+}
+
+// Fetch fetches the page at rawURL and extracts its metadata. Only HTML pages are parsed.
+func (f *Fetcher) Fetch(ctx context.Context, rawURL string) (Metadata, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Metadata{}, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Metadata{}, fmt.Errorf("enrich: can't fetch %q", rawURL)
+	}
+
+	timeout := f.Timeout
+	if timeout == 0 {
+		timeout = 5 * time.Second
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+	if f.UserAgent != "" {
+		req.Header.Set("User-Agent", f.UserAgent)
+	}
+
+	client := f.Client
+	if client == nil {
+		client = publicClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return Metadata{}, fmt.Errorf("enrich: %s returned %d", rawURL, resp.StatusCode)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType != "text/html" && mediaType != "application/xhtml+xml" {
+		return Metadata{}, fmt.Errorf("enrich: %s is %q, not HTML", rawURL, mediaType)
+	}
+
+	maxBytes := f.MaxBytes
+	if maxBytes == 0 {
+		maxBytes = 512 * 1024
+	}
+
+	// The page's own URL, after redirects, is what its relative URLs are relative to.
+	return Parse(io.LimitReader(resp.Body, maxBytes), resp.Request.URL)
+}
+
+// Options configures an Enricher.
+type Options struct {
+	// Workers is how many pages are fetched at the same time. Defaults to 4.
+	Workers int
+	// Queue is how many pages can wait to be fetched. Pages asked for while the queue is full are skipped until they
+	// are asked for again. Defaults to 256.
+	Queue int
+	// TTL is how long metadata is cached. Defaults to 6 hours. Pages that couldn't be fetched are retried after
+	// FailureTTL, which defaults to 30 minutes.
+	TTL        time.Duration
+	FailureTTL time.Duration
+	// MaxEntries is the most pages cached, the oldest are dropped to make room. Defaults to 5000.
+	MaxEntries int
+}
+
+type entry struct {
+	meta    Metadata
+	ok      bool
+	expires time.Time
+	added   time.Time
+}
+
+// Enricher fetches metadata in the background and caches it. Looking metadata up never waits for a fetch.
+type Enricher struct {
+	fetcher *Fetcher
+	opts    Options
+	now     func() time.Time
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	queue  chan string
+
+	mutex   sync.Mutex
+	entries map[string]*entry
+	pending map[string]bool
+}
+
+// NewEnricher starts an Enricher fetching pages with f. Close stops it.
+func NewEnricher(f *Fetcher, opts Options) *Enricher {
+	if opts.Workers <= 0 {
+		opts.Workers = 4
+	}
+	if opts.Queue <= 0 {
+		opts.Queue = 256
+	}
+	if opts.TTL == 0 {
+		opts.TTL = 6 * time.Hour
+	}
+	if opts.FailureTTL == 0 {
+		opts.FailureTTL = 30 * time.Minute
+	}
+	if opts.MaxEntries <= 0 {
+		opts.MaxEntries = 5000
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := &Enricher{
+		fetcher: f,
+		opts:    opts,
+		now:     time.Now,
+		ctx:     ctx,
+		cancel:  cancel,
+		queue:   make(chan string, opts.Queue),
+		entries: make(map[string]*entry),
+		pending: make(map[string]bool),
+	}
+
+	for i := 0; i < opts.Workers; i++ {
+		e.wg.Add(1)
+		go e.work()
+	}
+
+	return e
+}
+
+// Get returns the cached metadata of the page at url. If there is none, or it expired, the page is queued to be
+// fetched and Get returns false.
+func (e *Enricher) Get(url string) (Metadata, bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	en, cached := e.entries[url]
+	if cached && e.now().Before(en.expires) {
+		return en.meta, en.ok
+	}
+
+	if !e.pending[url] {
+		select {
+		case e.queue <- url:
+			e.pending[url] = true
+		default:
+		}
+	}
+
+	if cached {
+		// Stale metadata is better than none while it's fetched again.
+		return en.meta, en.ok
+	}
+
+	return Metadata{}, false
+}
+
+// Close stops fetching pages and waits for the fetches in progress to be abandoned.
+func (e *Enricher) Close() {
+	e.cancel()
+	e.wg.Wait()
+}
+
+func (e *Enricher) work() {
+	defer e.wg.Done()
+
+	for {
+		select {
+		case <-e.ctx.Done():
+			return
+		case url := <-e.queue:
+			meta, err := e.fetcher.Fetch(e.ctx, url)
+			e.store(url, meta, err == nil)
+		}
+	}
+}
+
+func (e *Enricher) store(url string, meta Metadata, ok bool) {
+	e.mutex.Lock()
+	defer e.mutex.Unlock()
+
+	delete(e.pending, url)
+
+	ttl := e.opts.TTL
+	if !ok {
+		ttl = e.opts.FailureTTL
+	}
+
+	now := e.now()
+	e.entries[url] = &entry{meta: meta, ok: ok, expires: now.Add(ttl), added: now}
+
+	if len(e.entries) > e.opts.MaxEntries {
+		var oldest string
+		for u, en := range e.entries {
+			if oldest == "" || en.added.Before(e.entries[oldest].added) {
+				oldest = u
+			}
+		}
+		delete(e.entries, oldest)
+	}
+}
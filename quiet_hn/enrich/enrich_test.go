@@ -0,0 +1,230 @@
+package enrich
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	base, _ := url.Parse("https://blog.example.com/posts/go-gc?ref=hn")
+
+	tests := []struct {
+		name string
+		file string
+		want Metadata
+	}{
+		{
+			name: "opengraph",
+			file: "testdata/article.html",
+			want: Metadata{
+				Title:        "Understanding Go's garbage collector",
+				Description:  "How the concurrent mark and sweep collector keeps pauses short.",
+				Image:        "https://blog.example.com/images/gc.png",
+				CanonicalURL: "https://blog.example.com/go-gc",
+				Words:        77,
+				ReadingTime:  time.Minute,
+			},
+		},
+		{
+			name: "fallbacks",
+			file: "testdata/fallback.html",
+			want: Metadata{
+				Title:       "A page without OpenGraph",
+				Description: "Just the basics.",
+				Image:       "https://cdn.example.com/card.jpg",
+				Words:       1,
+				ReadingTime: time.Minute,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := os.Open(tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+
+			got, err := Parse(f, base)
+			if err != nil {
+				t.Fatalf("Parse() received an error: %s", err.Error())
+			}
+			if got != tt.want {
+				t.Errorf("Parse():\nwant %+v\n got %+v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParse_truncated(t *testing.T) {
+	m, err := Parse(strings.NewReader(`<html><head><meta property="og:title" content="Cut off"><meta property="og:descr`), nil)
+	if err != nil {
+		t.Fatalf("Parse() received an error: %s", err.Error())
+	}
+	if m.Title != "Cut off" {
+		t.Errorf("m.Title: want %s, got %s", "Cut off", m.Title)
+	}
+}
+
+func TestParse_unsafeURLs(t *testing.T) {
+	m, _ := Parse(strings.NewReader(`<meta property="og:image" content="javascript:alert(1)"><link rel="canonical" href="data:text/html,hi">`), nil)
+	if m.Image != "" || m.CanonicalURL != "" {
+		t.Errorf("expected non-http URLs to be dropped, got %q and %q", m.Image, m.CanonicalURL)
+	}
+}
+
+// fixtureServer serves the fixture pages, a page that never finishes, a huge page and an image.
+func fixtureServer() *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/article", func(w http.ResponseWriter, r *http.Request) {
+		http.ServeFile(w, r, "testdata/article.html")
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html")
+		fmt.Fprint(w, "<html><head>")
+		w.(http.Flusher).Flush()
+		<-r.Context().Done()
+	})
+	mux.HandleFunc("/huge", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, `<html><head><meta property="og:title" content="Huge"></head><body>`)
+		for i := 0; i < 100000; i++ {
+			fmt.Fprint(w, "<p>word word word word word word word word word word</p>\n")
+		}
+	})
+	mux.HandleFunc("/image.png", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "image/png")
+		w.Write([]byte("\x89PNG"))
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestFetcher_Fetch(t *testing.T) {
+	server := fixtureServer()
+	defer server.Close()
+
+	f := &Fetcher{Client: server.Client(), MaxBytes: 4096, Timeout: 100 * time.Millisecond}
+
+	m, err := f.Fetch(context.Background(), server.URL+"/article")
+	if err != nil {
+		t.Fatalf("f.Fetch() received an error: %s", err.Error())
+	}
+	if m.Image != server.URL+"/images/gc.png" {
+		t.Errorf("m.Image: want %s, got %s", server.URL+"/images/gc.png", m.Image)
+	}
+
+	m, err = f.Fetch(context.Background(), server.URL+"/huge")
+	if err != nil {
+		t.Fatalf("f.Fetch() received an error: %s", err.Error())
+	}
+	if m.Title != "Huge" || m.Words > 4096/5 {
+		t.Errorf("expected only the first 4096 bytes to be read, got %q with %d words", m.Title, m.Words)
+	}
+
+	start := time.Now()
+	if _, err := f.Fetch(context.Background(), server.URL+"/slow"); err == nil {
+		t.Errorf("expected the slow page to time out")
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the timeout to stop the fetch, it took %v", elapsed)
+	}
+
+	if _, err := f.Fetch(context.Background(), server.URL+"/image.png"); err == nil {
+		t.Errorf("expected an error for an image")
+	}
+
+	if _, err := f.Fetch(context.Background(), "file:///etc/passwd"); err == nil {
+		t.Errorf("expected an error for a file URL")
+	}
+}
+
+func TestFetcher_Fetch_publicOnly(t *testing.T) {
+	server := fixtureServer()
+	defer server.Close()
+
+	f := &Fetcher{Timeout: time.Second}
+
+	for _, u := range []string{
+		server.URL + "/article",
+		strings.Replace(server.URL, "127.0.0.1", "localhost", 1) + "/article",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.1/",
+		"http://[::1]/",
+		"ftp://example.com/",
+	} {
+		if _, err := f.Fetch(context.Background(), u); err == nil {
+			t.Errorf("f.Fetch(%q): expected an error", u)
+		}
+	}
+}
+
+func TestPublic(t *testing.T) {
+	tests := map[string]bool{
+		"93.184.216.34":    true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"::ffff:127.0.0.1": false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+	}
+
+	for addr, want := range tests {
+		if got := public(net.ParseIP(addr)); got != want {
+			t.Errorf("public(%s): want %v, got %v", addr, want, got)
+		}
+	}
+}
+
+func TestEnricher(t *testing.T) {
+	server := fixtureServer()
+	defer server.Close()
+
+	e := NewEnricher(&Fetcher{Client: server.Client(), Timeout: time.Second}, Options{Workers: 2})
+	defer e.Close()
+
+	if _, ok := e.Get(server.URL + "/article"); ok {
+		t.Fatalf("expected the first Get to return straight away without metadata")
+	}
+	e.Get(server.URL + "/image.png")
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		m, ok := e.Get(server.URL + "/article")
+		if ok {
+			if m.Title != "Understanding Go's garbage collector" {
+				t.Errorf("m.Title: want %s, got %s", "Understanding Go's garbage collector", m.Title)
+			}
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the metadata to be fetched in the background")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	e.mutex.Lock()
+	_, failed := e.entries[server.URL+"/image.png"]
+	e.mutex.Unlock()
+	if !failed {
+		t.Errorf("expected the failed fetch to be cached")
+	}
+	if _, ok := e.Get(server.URL + "/image.png"); ok {
+		t.Errorf("expected no metadata for the image")
+	}
+}
@@ -0,0 +1,255 @@
+// Package enrich fetches the pages stories link to and extracts their metadata, such as the OpenGraph title,
+// description and image, for showing summaries next to the stories.
+package enrich
+
+import (
+	"html"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// wordsPerMinute is how fast an average adult reads.
+const wordsPerMinute = 230
+
+// Metadata is what a page says about itself.
+type Metadata struct {
+	Title        string
+	Description  string
+	Image        string
+	CanonicalURL string
+	Words        int
+	ReadingTime  time.Duration
+}
+
+// Minutes returns the reading time in whole minutes, for templates.
+func (m Metadata) Minutes() int {
+	return int(m.ReadingTime / time.Minute)
+}
+
+// Parse extracts the metadata of an HTML page, preferring OpenGraph tags over their plain HTML equivalents. Relative
+// URLs are resolved against base. Parse is forgiving, it works on truncated and malformed pages.
+func Parse(r io.Reader, base *url.URL) (Metadata, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return Metadata{}, err
+	}
+
+	var m Metadata
+	var title, description, image, canonical, ogURL string
+	var text strings.Builder
+
+	s := string(data)
+	inTitle := false
+
+	for len(s) > 0 {
+		i := strings.IndexByte(s, '<')
+		if i < 0 {
+			i = len(s)
+		}
+
+		if inTitle {
+			title += s[:i]
+		} else {
+			text.WriteString(s[:i])
+			text.WriteByte(' ')
+		}
+		s = s[i:]
+		if s == "" {
+			break
+		}
+
+		if len(s) > 1 && !isTagStart(s[1]) {
+			// A < that doesn't start a tag is just text.
+			text.WriteByte('<')
+			s = s[1:]
+			continue
+		}
+
+		if strings.HasPrefix(s, "<!--") {
+			end := strings.Index(s, "-->")
+			if end < 0 {
+				break
+			}
+			s = s[end+3:]
+			continue
+		}
+
+		name, attrs, rest := parseTag(s)
+		s = rest
+
+		switch name {
+		case "title":
+			inTitle = title == ""
+		case "/title":
+			inTitle = false
+		case "script", "style", "noscript", "svg":
+			// Their contents aren't part of the article.
+			end := strings.Index(strings.ToLower(s), "</"+name)
+			if end < 0 {
+				s = ""
+			} else {
+				s = s[end:]
+			}
+		case "/head", "body":
+			// Only the text in the body is counted.
+			text.Reset()
+		case "meta":
+			key := strings.ToLower(attrs["property"])
+			if key == "" {
+				key = strings.ToLower(attrs["name"])
+			}
+			content := strings.TrimSpace(attrs["content"])
+
+			switch key {
+			case "og:title":
+				m.Title = content
+			case "og:description":
+				m.Description = content
+			case "og:image", "og:image:url", "og:image:secure_url":
+				if m.Image == "" {
+					m.Image = content
+				}
+			case "og:url":
+				ogURL = content
+			case "description", "twitter:description":
+				if description == "" {
+					description = content
+				}
+			case "twitter:image", "twitter:image:src":
+				if image == "" {
+					image = content
+				}
+			}
+		case "link":
+			for _, rel := range strings.Fields(strings.ToLower(attrs["rel"])) {
+				if rel == "canonical" {
+					canonical = strings.TrimSpace(attrs["href"])
+				}
+			}
+		}
+	}
+
+	if m.Title == "" {
+		m.Title = strings.Join(strings.Fields(html.UnescapeString(title)), " ")
+	}
+	if m.Description == "" {
+		m.Description = description
+	}
+	if m.Image == "" {
+		m.Image = image
+	}
+	if canonical == "" {
+		canonical = ogURL
+	}
+
+	m.Image = resolve(base, m.Image)
+	m.CanonicalURL = resolve(base, canonical)
+
+	m.Words = len(strings.Fields(html.UnescapeString(text.String())))
+	if m.Words > 0 {
+		minutes := (m.Words + wordsPerMinute - 1) / wordsPerMinute
+		m.ReadingTime = time.Duration(minutes) * time.Minute
+	}
+
+	return m, nil
+}
+
+func isTagStart(c byte) bool {
+	return c == '/' || c == '!' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// parseTag parses the tag at the start of s, returning its lower-cased name, prefixed with / for closing tags, its
+// attributes and what follows it.
+func parseTag(s string) (string, map[string]string, string) {
+	s = s[1:]
+
+	end := strings.IndexAny(s, " \t\r\n/>")
+	if end < 0 {
+		return "", nil, ""
+	}
+	if end == 0 && strings.HasPrefix(s, "/") {
+		end = 1 + strings.IndexAny(s[1:], " \t\r\n>")
+		if end == 0 {
+			return "", nil, ""
+		}
+	}
+
+	name := strings.ToLower(s[:end])
+	s = s[end:]
+	attrs := make(map[string]string)
+
+	for {
+		s = strings.TrimLeft(s, " \t\r\n/")
+		if s == "" {
+			return name, attrs, ""
+		}
+		if s[0] == '>' {
+			return name, attrs, s[1:]
+		}
+
+		end := strings.IndexAny(s, " \t\r\n=/>")
+		if end < 0 {
+			return name, attrs, ""
+		}
+
+		key := strings.ToLower(s[:end])
+		s = strings.TrimLeft(s[end:], " \t\r\n")
+
+		if !strings.HasPrefix(s, "=") {
+			if end == 0 {
+				s = s[1:]
+			}
+			attrs[key] = ""
+			continue
+		}
+
+		s = strings.TrimLeft(s[1:], " \t\r\n")
+		if s == "" {
+			return name, attrs, ""
+		}
+
+		var value string
+
+		switch quote := s[0]; quote {
+		case '"', '\'':
+			close := strings.IndexByte(s[1:], quote)
+			if close < 0 {
+				return name, attrs, ""
+			}
+			value, s = s[1:1+close], s[2+close:]
+		default:
+			end := strings.IndexAny(s, " \t\r\n>")
+			if end < 0 {
+				end = len(s)
+			}
+			value, s = s[:end], s[end:]
+		}
+
+		attrs[key] = html.UnescapeString(value)
+	}
+}
+
+// resolve makes ref absolute, leaving out anything that isn't an http(s) URL.
+func resolve(base *url.URL, ref string) string {
+	if ref == "" {
+		return ""
+	}
+
+	u, err := url.Parse(ref)
+	if err != nil {
+		return ""
+	}
+
+	if base != nil {
+		u = base.ResolveReference(u)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return ""
+	}
+
+	return u.String()
+}
@@ -0,0 +1,219 @@
+package history
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// interval is how often record adds snapshots.
+const interval = 10 * time.Minute
+
+// open opens the store at path, which is closed once the test is over.
+func open(t *testing.T, path string, retention time.Duration) *Store {
+	t.Helper()
+
+	s, err := Open(path, retention, interval)
+	if err != nil {
+		t.Fatalf("Open() received an error: %s", err.Error())
+	}
+
+	t.Cleanup(func() { s.Close() })
+
+	return s
+}
+
+// newStore opens an empty store in a temporary directory, returning the path of its file.
+func newStore(t *testing.T, retention time.Duration) (*Store, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "history.jsonl")
+
+	return open(t, path, retention), path
+}
+
+// record adds a snapshot every interval from start, each a list of story ids in rank order. Scores go up by 10 in
+// every snapshot.
+func record(t *testing.T, s *Store, lists ...[]int) {
+	for i, ids := range lists {
+		samples := make([]Sample, len(ids))
+
+		for rank, id := range ids {
+			samples[rank] = Sample{
+				ID:       id,
+				Title:    "Story " + string(rune('A'+id-1)),
+				Rank:     rank + 1,
+				Score:    (i + 1) * 10,
+				Comments: i,
+			}
+		}
+
+		if err := s.Add(start.Add(time.Duration(i)*interval), samples); err != nil {
+			t.Fatalf("s.Add() received an error: %s", err.Error())
+		}
+	}
+}
+
+func TestStore_History(t *testing.T) {
+	s, path := newStore(t, 0)
+
+	record(t, s, []int{1, 2, 3}, []int{2, 1, 3}, []int{2, 3})
+
+	want := []Point{
+		{Time: start, Rank: 1, Score: 10, Comments: 0},
+		{Time: start.Add(10 * time.Minute), Rank: 2, Score: 20, Comments: 1},
+	}
+
+	if got := s.History(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("s.History(1):\nwant %v\n got %v", want, got)
+	}
+
+	if got := s.Title(3); got != "Story C" {
+		t.Errorf("s.Title(3): want %s, got %s", "Story C", got)
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(data), `"Story A"`); n != 1 {
+		t.Errorf("expected the title to be written once, got %d times", n)
+	}
+
+	// Reopening the store loads the same snapshots, ignoring a line left half written.
+	s.Close()
+
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"time":"2020-06-01T13:00:00Z","stories":[{"id":`)
+	f.Close()
+
+	reopened := open(t, path, 0)
+
+	if got := reopened.History(1); !reflect.DeepEqual(got, want) {
+		t.Errorf("reopened.History(1):\nwant %v\n got %v", want, got)
+	}
+
+	if got := reopened.Title(1); got != "Story A" {
+		t.Errorf("reopened.Title(1): want %s, got %s", "Story A", got)
+	}
+}
+
+func TestStore_retention(t *testing.T) {
+	s, path := newStore(t, 15*time.Minute)
+
+	lists := make([][]int, 10)
+	for i := range lists {
+		lists[i] = []int{1, 2}
+	}
+
+	record(t, s, lists...)
+
+	// Snapshots from 75 to 90 minutes are kept.
+	if s.Len() != 2 {
+		t.Errorf("s.Len(): want %d, got %d", 2, s.Len())
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if n := strings.Count(string(data), "\n"); n > 2*s.Len()+1 {
+		t.Errorf("expected the file to be compacted, it has %d lines", n)
+	}
+
+	// Titles survive compaction.
+	s.Close()
+
+	reopened := open(t, path, 0)
+
+	if got := reopened.Title(2); got != "Story B" {
+		t.Errorf("reopened.Title(2): want %s, got %s", "Story B", got)
+	}
+}
+
+func TestStore_Risers(t *testing.T) {
+	s, _ := newStore(t, 0)
+
+	record(t, s,
+		[]int{1, 2, 3, 4},
+		[]int{1, 3, 2, 4},
+		[]int{4, 1, 3, 5},
+	)
+
+	want := []Rise{
+		{ID: 4, Title: "Story D", From: 4, To: 1, Gained: 3, Points: 20},
+		{ID: 5, Title: "Story E", From: 5, To: 4, New: true, Gained: 1},
+	}
+
+	if got := s.Risers(start, 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("s.Risers():\nwant %+v\n got %+v", want, got)
+	}
+
+	if got := s.Risers(start, 1); len(got) != 1 || got[0].ID != 4 {
+		t.Errorf("expected only the biggest riser, got %+v", got)
+	}
+
+	// Since the second snapshot 3 only rose from 2 to 3, which isn't a rise.
+	want = []Rise{
+		{ID: 4, Title: "Story D", From: 4, To: 1, Gained: 3, Points: 10},
+		{ID: 5, Title: "Story E", From: 5, To: 4, New: true, Gained: 1},
+	}
+
+	if got := s.Risers(start.Add(10*time.Minute), 10); !reflect.DeepEqual(got, want) {
+		t.Errorf("s.Risers() since the second snapshot:\nwant %+v\n got %+v", want, got)
+	}
+
+	if got := s.Risers(start.Add(20*time.Minute), 10); got != nil {
+		t.Errorf("expected no risers from a single snapshot, got %+v", got)
+	}
+}
+
+func TestStore_Peaks(t *testing.T) {
+	s, _ := newStore(t, 0)
+
+	record(t, s,
+		[]int{1, 2},
+		[]int{2, 1},
+		[]int{2, 3},
+		[]int{3, 2},
+	)
+
+	got := s.Peaks(start, start.Add(30*time.Minute))
+
+	want := []Peak{
+		{ID: 1, Title: "Story A", Rank: 1, At: start, Score: 20, Comments: 1, FirstSeen: start,
+			LastSeen: start.Add(10 * time.Minute), OnFrontPage: 20 * time.Minute},
+		{ID: 2, Title: "Story B", Rank: 1, At: start.Add(10 * time.Minute), Score: 30, Comments: 2, FirstSeen: start,
+			LastSeen: start.Add(20 * time.Minute), OnFrontPage: 20 * time.Minute},
+		{ID: 3, Title: "Story C", Rank: 2, At: start.Add(20 * time.Minute), Score: 30, Comments: 2,
+			FirstSeen: start.Add(20 * time.Minute), LastSeen: start.Add(20 * time.Minute)},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("s.Peaks():\nwant %+v\n got %+v", want, got)
+	}
+}
+
+func TestStore_PeaksDowntime(t *testing.T) {
+	s, _ := newStore(t, 0)
+
+	// The recorder was down for three hours after the first snapshot.
+	for _, at := range []time.Time{start, start.Add(3 * time.Hour), start.Add(3*time.Hour + interval)} {
+		if err := s.Add(at, []Sample{{ID: 1, Title: "Story A", Rank: 1}}); err != nil {
+			t.Fatalf("s.Add() received an error: %s", err.Error())
+		}
+	}
+
+	got := s.Peaks(start, start.Add(4*time.Hour))
+	if len(got) != 1 || got[0].OnFrontPage != 2*interval {
+		t.Errorf("expected %v on the front page, got %+v", 2*interval, got)
+	}
+}
@@ -0,0 +1,274 @@
+// Package history keeps snapshots of where stories ranked on HN, so how they rose and fell can be looked at later.
+package history
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Sample is where a single story was in a snapshot.
+type Sample struct {
+	ID       int    `json:"id"`
+	Title    string `json:"title,omitempty"`
+	Rank     int    `json:"rank"`
+	Score    int    `json:"score"`
+	Comments int    `json:"comments"`
+}
+
+// Snapshot is the list of ranked stories at a point in time.
+type Snapshot struct {
+	Time    time.Time `json:"time"`
+	Samples []Sample  `json:"stories"`
+}
+
+// Store is an append-only log of snapshots, one JSON document per line, which is kept in memory as well. Titles are
+// only written the first time a story appears in the file.
+type Store struct {
+	path      string
+	retention time.Duration
+	interval  time.Duration
+
+	mutex     sync.RWMutex
+	file      *os.File
+	snapshots []Snapshot
+	titles    map[int]string
+	// written are the stories whose titles are in the file.
+	written map[int]bool
+	// dropped is the number of snapshots in the file that are past the retention.
+	dropped int
+}
+
+// Open opens the store at path, creating it if it doesn't exist. Snapshots older than retention are dropped, a
+// retention of 0 keeps them forever. interval is how often snapshots are added, so longer gaps between them can be
+// told apart as time nothing was recorded.
+func Open(path string, retention, interval time.Duration) (*Store, error) {
+	s := &Store{
+		path:      path,
+		retention: retention,
+		interval:  interval,
+		titles:    make(map[int]string),
+		written:   make(map[int]bool),
+	}
+
+	if err := s.load(); err != nil {
+		return nil, err
+	}
+
+	s.prune(time.Now())
+
+	// Rewriting the file drops the expired snapshots from it as well.
+	if err := s.compact(); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// load reads the snapshots in the file. A partly written last line, left by a crash, is ignored.
+func (s *Store) load() error {
+	f, err := os.Open(s.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	for scanner.Scan() {
+		var snap Snapshot
+		if err := json.Unmarshal(scanner.Bytes(), &snap); err != nil {
+			continue
+		}
+
+		s.remember(&snap)
+		s.snapshots = append(s.snapshots, snap)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	sort.SliceStable(s.snapshots, func(i, j int) bool {
+		return s.snapshots[i].Time.Before(s.snapshots[j].Time)
+	})
+
+	return nil
+}
+
+// remember keeps the titles of the snapshot's stories and strips them from its samples, they are looked up by ID.
+func (s *Store) remember(snap *Snapshot) {
+	for i, sample := range snap.Samples {
+		if sample.Title != "" {
+			s.titles[sample.ID] = sample.Title
+		}
+
+		snap.Samples[i].Title = ""
+	}
+}
+
+// prune drops the snapshots that are past the retention.
+func (s *Store) prune(now time.Time) {
+	if s.retention <= 0 {
+		return
+	}
+
+	cutoff := now.Add(-s.retention)
+
+	n := sort.Search(len(s.snapshots), func(i int) bool {
+		return !s.snapshots[i].Time.Before(cutoff)
+	})
+	if n == 0 {
+		return
+	}
+
+	s.snapshots = append([]Snapshot(nil), s.snapshots[n:]...)
+	s.dropped += n
+}
+
+// compact rewrites the file with only the snapshots in memory, and reopens it for appending.
+func (s *Store) compact() error {
+	tmp, err := ioutil.TempFile(filepath.Dir(s.path), filepath.Base(s.path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	s.written = make(map[int]bool)
+	w := bufio.NewWriter(tmp)
+
+	for _, snap := range s.snapshots {
+		if err := s.write(w, snap); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	if s.file != nil {
+		s.file.Close()
+	}
+
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return err
+	}
+
+	s.file, err = os.OpenFile(s.path, os.O_WRONLY|os.O_APPEND, 0644)
+	s.dropped = 0
+
+	return err
+}
+
+// write writes a snapshot as a line, with the titles of the stories that are new to the file.
+func (s *Store) write(w *bufio.Writer, snap Snapshot) error {
+	line := Snapshot{Time: snap.Time, Samples: make([]Sample, len(snap.Samples))}
+
+	for i, sample := range snap.Samples {
+		line.Samples[i] = sample
+
+		if title := s.titles[sample.ID]; title != "" && !s.written[sample.ID] {
+			line.Samples[i].Title = title
+			s.written[sample.ID] = true
+		}
+	}
+
+	data, err := json.Marshal(line)
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+
+	return w.WriteByte('\n')
+}
+
+// Add records a snapshot taken at t. Snapshots must be added in order.
+func (s *Store) Add(t time.Time, samples []Sample) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snap := Snapshot{Time: t, Samples: make([]Sample, len(samples))}
+	copy(snap.Samples, samples)
+
+	s.remember(&snap)
+
+	w := bufio.NewWriter(s.file)
+	if err := s.write(w, snap); err != nil {
+		return err
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	s.snapshots = append(s.snapshots, snap)
+	s.prune(t)
+
+	// Rewrite the file once most of it has expired, so it doesn't grow forever.
+	if s.dropped > len(s.snapshots) {
+		return s.compact()
+	}
+
+	return nil
+}
+
+// Close closes the file.
+func (s *Store) Close() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.file.Close()
+}
+
+// Title returns the title of a story, if it was ever ranked.
+func (s *Store) Title(id int) string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return s.titles[id]
+}
+
+// Len returns the number of snapshots.
+func (s *Store) Len() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	return len(s.snapshots)
+}
+
+// since returns the snapshots taken at or after from. It must be called with the lock held.
+func (s *Store) since(from time.Time) []Snapshot {
+	i := sort.Search(len(s.snapshots), func(i int) bool {
+		return !s.snapshots[i].Time.Before(from)
+	})
+
+	return s.snapshots[i:]
+}
+
+// between returns the snapshots taken from from up to, but not including, to. It must be called with the lock held.
+func (s *Store) between(from, to time.Time) []Snapshot {
+	snapshots := s.since(from)
+	j := sort.Search(len(snapshots), func(i int) bool {
+		return !snapshots[i].Time.Before(to)
+	})
+
+	return snapshots[:j]
+}
@@ -0,0 +1,204 @@
+package history
+
+import (
+	"sort"
+	"time"
+)
+
+// FrontPage is the number of stories on HN's front page.
+const FrontPage = 30
+
+// Point is where a story was in a single snapshot.
+type Point struct {
+	Time     time.Time
+	Rank     int
+	Score    int
+	Comments int
+}
+
+// History returns every point at which the story was ranked, oldest first.
+func (s *Store) History(id int) []Point {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	var points []Point
+
+	for _, snap := range s.snapshots {
+		if sample, ok := find(snap, id); ok {
+			points = append(points, Point{
+				Time:     snap.Time,
+				Rank:     sample.Rank,
+				Score:    sample.Score,
+				Comments: sample.Comments,
+			})
+		}
+	}
+
+	return points
+}
+
+func find(snap Snapshot, id int) (Sample, bool) {
+	for _, sample := range snap.Samples {
+		if sample.ID == id {
+			return sample, true
+		}
+	}
+
+	return Sample{}, false
+}
+
+// Rise is how far a story climbed.
+type Rise struct {
+	ID    int
+	Title string
+	// From is the story's rank at the start. Stories that weren't ranked yet start just below the last one, and are
+	// marked New.
+	From   int
+	To     int
+	New    bool
+	Gained int
+	// Points is how much the story's score went up.
+	Points int
+}
+
+// Risers returns the n stories that climbed the most places since the given time, up to the latest snapshot. Stories
+// that dropped out of the list since are left out.
+func (s *Store) Risers(since time.Time, n int) []Rise {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshots := s.since(since)
+	if len(snapshots) < 2 {
+		return nil
+	}
+
+	first, last := snapshots[0], snapshots[len(snapshots)-1]
+
+	// The score a story had when it was first seen in the window.
+	startScores := make(map[int]int)
+
+	for _, snap := range snapshots {
+		for _, sample := range snap.Samples {
+			if _, ok := startScores[sample.ID]; !ok {
+				startScores[sample.ID] = sample.Score
+			}
+		}
+	}
+
+	var rises []Rise
+
+	for _, sample := range last.Samples {
+		rise := Rise{
+			ID:     sample.ID,
+			Title:  s.titles[sample.ID],
+			To:     sample.Rank,
+			Points: sample.Score - startScores[sample.ID],
+		}
+
+		if start, ok := find(first, sample.ID); ok {
+			rise.From = start.Rank
+		} else {
+			rise.From = len(first.Samples) + 1
+			rise.New = true
+		}
+
+		rise.Gained = rise.From - rise.To
+		if rise.Gained > 0 {
+			rises = append(rises, rise)
+		}
+	}
+
+	sort.SliceStable(rises, func(i, j int) bool {
+		if rises[i].Gained != rises[j].Gained {
+			return rises[i].Gained > rises[j].Gained
+		}
+
+		return rises[i].To < rises[j].To
+	})
+
+	if n > 0 && len(rises) > n {
+		rises = rises[:n]
+	}
+
+	return rises
+}
+
+// Peak is the best a story did in a period.
+type Peak struct {
+	ID    int
+	Title string
+	// Rank is the best rank the story reached, first at At.
+	Rank     int
+	At       time.Time
+	Score    int
+	Comments int
+	// FirstSeen and LastSeen are the first and last snapshots the story was ranked in.
+	FirstSeen time.Time
+	LastSeen  time.Time
+	// OnFrontPage is how long the story was in the top FrontPage stories, as far as was recorded.
+	OnFrontPage time.Duration
+}
+
+// Peaks returns the peak of every story ranked from from up to to, best first.
+func (s *Store) Peaks(from, to time.Time) []Peak {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	snapshots := s.between(from, to)
+	peaks := make(map[int]*Peak)
+
+	for i, snap := range snapshots {
+		for _, sample := range snap.Samples {
+			p, ok := peaks[sample.ID]
+			if !ok {
+				p = &Peak{
+					ID:        sample.ID,
+					Title:     s.titles[sample.ID],
+					Rank:      sample.Rank,
+					At:        snap.Time,
+					FirstSeen: snap.Time,
+				}
+				peaks[sample.ID] = p
+			}
+
+			if sample.Rank < p.Rank {
+				p.Rank = sample.Rank
+				p.At = snap.Time
+			}
+			if sample.Score > p.Score {
+				p.Score = sample.Score
+			}
+			if sample.Comments > p.Comments {
+				p.Comments = sample.Comments
+			}
+
+			p.LastSeen = snap.Time
+
+			// A story is counted as on the front page until the next snapshot, or for an interval if the next one is
+			// later than that because nothing was recorded in between.
+			if sample.Rank <= FrontPage && i+1 < len(snapshots) {
+				step := snapshots[i+1].Time.Sub(snap.Time)
+				if s.interval > 0 && step > s.interval {
+					step = s.interval
+				}
+
+				p.OnFrontPage += step
+			}
+		}
+	}
+
+	ret := make([]Peak, 0, len(peaks))
+	for _, p := range peaks {
+		ret = append(ret, *p)
+	}
+
+	sort.Slice(ret, func(i, j int) bool {
+		if ret[i].Rank != ret[j].Rank {
+			return ret[i].Rank < ret[j].Rank
+		}
+
+		return ret[i].At.Before(ret[j].At)
+	})
+
+	return ret
+}
@@ -0,0 +1,202 @@
+package hn
+
+import (
+	"container/list"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Cache stores items so the Client doesn't have to fetch them again. A Cache is shared by every request the Client
+// makes, so it must be safe for concurrent use.
+type Cache interface {
+	// Get returns the cached item and true, or false if the item isn't cached or has expired.
+	Get(id int) (Item, bool)
+	Set(item Item)
+	Delete(id int)
+}
+
+// WithCache makes GetItem, and everything built on it, check cache before asking the API for an item.
+func WithCache(cache Cache) Option {
+	return func(c *Client) {
+		c.cache = cache
+	}
+}
+
+// Invalidate removes every item the updates feed reports as changed from the Client's cache, so the next request for
+// them goes to the API. It returns the ids of the changed items.
+func (c *Client) Invalidate(ctx context.Context) ([]int, error) {
+	updates, err := c.UpdatesContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if c.cache != nil {
+		for _, id := range updates.Items {
+			c.cache.Delete(id)
+		}
+	}
+
+	return updates.Items, nil
+}
+
+type memoryEntry struct {
+	item    Item
+	expires time.Time
+}
+
+// MemoryCache is a Cache that keeps up to a fixed number of items in memory, evicting the least recently used item to
+// make room for new ones. Items also expire after a fixed time.
+type MemoryCache struct {
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	mutex sync.Mutex
+	order *list.List
+	items map[int]*list.Element
+}
+
+// NewMemoryCache returns a MemoryCache holding up to size items for ttl each.
+func NewMemoryCache(size int, ttl time.Duration) *MemoryCache {
+	return &MemoryCache{
+		size:  size,
+		ttl:   ttl,
+		now:   time.Now,
+		order: list.New(),
+		items: make(map[int]*list.Element),
+	}
+}
+
+func (mc *MemoryCache) Get(id int) (Item, bool) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	el, ok := mc.items[id]
+	if !ok {
+		return Item{}, false
+	}
+
+	entry := el.Value.(*memoryEntry)
+	if !mc.now().Before(entry.expires) {
+		mc.order.Remove(el)
+		delete(mc.items, id)
+		return Item{}, false
+	}
+
+	mc.order.MoveToFront(el)
+	return entry.item, true
+}
+
+func (mc *MemoryCache) Set(item Item) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	entry := &memoryEntry{item: item, expires: mc.now().Add(mc.ttl)}
+
+	if el, ok := mc.items[item.ID]; ok {
+		el.Value = entry
+		mc.order.MoveToFront(el)
+		return
+	}
+
+	mc.items[item.ID] = mc.order.PushFront(entry)
+
+	for mc.order.Len() > mc.size {
+		oldest := mc.order.Back()
+		mc.order.Remove(oldest)
+		delete(mc.items, oldest.Value.(*memoryEntry).item.ID)
+	}
+}
+
+func (mc *MemoryCache) Delete(id int) {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	if el, ok := mc.items[id]; ok {
+		mc.order.Remove(el)
+		delete(mc.items, id)
+	}
+}
+
+// Len returns how many items are cached, including ones that expired but haven't been looked up since.
+func (mc *MemoryCache) Len() int {
+	mc.mutex.Lock()
+	defer mc.mutex.Unlock()
+
+	return mc.order.Len()
+}
+
+type diskEntry struct {
+	Item   Item      `json:"item"`
+	Stored time.Time `json:"stored"`
+}
+
+// DiskCache is a Cache that keeps each item in its own JSON file in a directory, so it survives restarts. Items
+// expire after a fixed time.
+type DiskCache struct {
+	dir string
+	ttl time.Duration
+	now func() time.Time
+}
+
+// NewDiskCache returns a DiskCache storing items in dir for ttl each. dir is created if it doesn't exist.
+func NewDiskCache(dir string, ttl time.Duration) (*DiskCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+
+	return &DiskCache{dir: dir, ttl: ttl, now: time.Now}, nil
+}
+
+func (dc *DiskCache) path(id int) string {
+	return filepath.Join(dc.dir, strconv.Itoa(id)+".json")
+}
+
+func (dc *DiskCache) Get(id int) (Item, bool) {
+	data, err := ioutil.ReadFile(dc.path(id))
+	if err != nil {
+		return Item{}, false
+	}
+
+	var entry diskEntry
+	if err := json.Unmarshal(data, &entry); err != nil || dc.now().Sub(entry.Stored) >= dc.ttl {
+		dc.Delete(id)
+		return Item{}, false
+	}
+
+	return entry.Item, true
+}
+
+// Set stores the item, writing it to a temporary file first so readers never see half of it. Failures are ignored,
+// the item will simply be fetched again.
+func (dc *DiskCache) Set(item Item) {
+	data, err := json.Marshal(diskEntry{Item: item, Stored: dc.now()})
+	if err != nil {
+		return
+	}
+
+	tmp, err := ioutil.TempFile(dc.dir, ".item-*")
+	if err != nil {
+		return
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), dc.path(item.ID))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+	}
+}
+
+func (dc *DiskCache) Delete(id int) {
+	_ = os.Remove(dc.path(id))
+}
@@ -0,0 +1,138 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// clock is a fake time.Now that only moves when told to.
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func TestMemoryCache(t *testing.T) {
+	clk := &clock{now: time.Unix(1522599083, 0)}
+	mc := NewMemoryCache(2, time.Minute)
+	mc.now = clk.Now
+
+	mc.Set(Item{ID: 1})
+	mc.Set(Item{ID: 2})
+	if _, ok := mc.Get(1); !ok {
+		t.Fatalf("expected item 1 to be cached")
+	}
+
+	// 2 is now the least recently used item, so it makes room for 3.
+	mc.Set(Item{ID: 3})
+	if _, ok := mc.Get(2); ok {
+		t.Errorf("expected item 2 to be evicted")
+	}
+	if mc.Len() != 2 {
+		t.Errorf("mc.Len(): want %d, got %d", 2, mc.Len())
+	}
+
+	mc.Delete(3)
+	if _, ok := mc.Get(3); ok {
+		t.Errorf("expected item 3 to be deleted")
+	}
+
+	clk.now = clk.now.Add(time.Minute)
+	if _, ok := mc.Get(1); ok {
+		t.Errorf("expected item 1 to expire")
+	}
+}
+
+func TestDiskCache(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hn-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	clk := &clock{now: time.Unix(1522599083, 0)}
+
+	dc, err := NewDiskCache(dir, time.Minute)
+	if err != nil {
+		t.Fatalf("NewDiskCache() received an error: %s", err.Error())
+	}
+	dc.now = clk.Now
+	dc.Set(Item{ID: 1, Title: "Test Story Title"})
+
+	// A new cache over the same directory sees what the first one stored, like it would after a restart.
+	restarted, err := NewDiskCache(dir, time.Minute)
+	if err != nil {
+		t.Fatalf("NewDiskCache() received an error: %s", err.Error())
+	}
+	restarted.now = clk.Now
+
+	item, ok := restarted.Get(1)
+	if !ok {
+		t.Fatalf("expected item 1 to survive a restart")
+	}
+	if item.Title != "Test Story Title" {
+		t.Errorf("item.Title: want %s, got %s", "Test Story Title", item.Title)
+	}
+
+	clk.now = clk.now.Add(time.Minute)
+	if _, ok := restarted.Get(1); ok {
+		t.Errorf("expected item 1 to expire")
+	}
+	if _, err := os.Stat(restarted.path(1)); !os.IsNotExist(err) {
+		t.Errorf("expected the expired item's file to be removed, got %v", err)
+	}
+}
+
+func TestClient_GetItem_cached(t *testing.T) {
+	var calls int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/item/", func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		fmt.Fprint(w, "{\"id\":1,\"score\":34,\"type\":\"story\"}")
+	})
+	mux.HandleFunc("/updates.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"items\":[1,7],\"profiles\":[]}")
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	cache := NewMemoryCache(10, time.Hour)
+	cache.Set(Item{ID: 2})
+
+	c := NewClient(WithBaseURL(server.URL), WithCache(cache))
+	for i := 0; i < 3; i++ {
+		if _, err := c.GetItem(1); err != nil {
+			t.Fatalf("client.GetItem() received an error: %s", err.Error())
+		}
+	}
+	if calls != 1 {
+		t.Errorf("calls: want %d, got %d", 1, calls)
+	}
+
+	changed, err := c.Invalidate(context.Background())
+	if err != nil {
+		t.Fatalf("client.Invalidate() received an error: %s", err.Error())
+	}
+	if len(changed) != 2 {
+		t.Errorf("len(changed): want %d, got %d", 2, len(changed))
+	}
+	if _, ok := cache.Get(2); !ok {
+		t.Errorf("expected unchanged item 2 to stay cached")
+	}
+
+	if _, err := c.GetItem(1); err != nil {
+		t.Fatalf("client.GetItem() received an error: %s", err.Error())
+	}
+	if calls != 2 {
+		t.Errorf("calls after invalidating: want %d, got %d", 2, calls)
+	}
+}
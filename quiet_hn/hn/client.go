@@ -2,27 +2,242 @@
 package hn
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"sync"
+	"time"
 )
 
 const (
 	apiBase = "https://hacker-news.firebaseio.com/v0"
+
+	defaultBackoff = 100 * time.Millisecond
+
+	// maxBodySize is the largest response the client reads. The biggest the API sends are lists of ids, which are
+	// far smaller than this.
+	maxBodySize = 10 << 20
 )
 
 // Client is an API client used to interact with the Hacker News API
 type Client struct {
 	// unexported fields...
-	apiBase string
+	apiBase    string
+	httpClient *http.Client
+	userAgent  string
+	timeout    time.Duration
+	retries    int
+	backoff    time.Duration
+	cache      Cache
+
+	maxThreadSize int
+
+	defaults sync.Once
+}
+
+// Option configures a Client created with NewClient.
+type Option func(*Client)
+
+// WithHTTPClient makes the Client send its requests with hc instead of http.DefaultClient.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithBaseURL points the Client at a different copy of the API, which is mostly useful for testing.
+func WithBaseURL(url string) Option {
+	return func(c *Client) {
+		c.apiBase = url
+	}
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) Option {
+	return func(c *Client) {
+		c.userAgent = ua
+	}
+}
+
+// WithTimeout limits how long each attempt at a request may take.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = d
+	}
+}
+
+// WithRetries retries requests that fail because of the network, a 5xx or a 429 response up to n more times. The
+// wait between attempts starts at backoff and doubles after every attempt.
+func WithRetries(n int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = n
+		c.backoff = backoff
+	}
+}
+
+// NewClient returns a Client configured by opts. The zero value Client is still ready to use, NewClient is only
+// needed to change its defaults.
+func NewClient(opts ...Option) *Client {
+	c := &Client{}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	c.defaultify()
+
+	return c
 }
 
 // Making the Client zero value useful without forcing users to do something
-// like `NewClient()`
+// like `NewClient()`. The defaults are only filled in once, as a Client is used from many goroutines at a time.
 func (c *Client) defaultify() {
-	if c.apiBase == "" {
-		c.apiBase = apiBase
+	c.defaults.Do(func() {
+		if c.apiBase == "" {
+			c.apiBase = apiBase
+		}
+		if c.httpClient == nil {
+			c.httpClient = http.DefaultClient
+		}
+		if c.backoff == 0 {
+			c.backoff = defaultBackoff
+		}
+		if c.maxThreadSize == 0 {
+			c.maxThreadSize = defaultMaxThreadSize
+		}
+	})
+}
+
+// StatusError is returned when the API answers with a status code other than 2xx.
+type StatusError struct {
+	StatusCode int
+	URL        string
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("hn: %s returned %d %s", e.URL, e.StatusCode, http.StatusText(e.StatusCode))
+}
+
+// temporary reports whether trying the request again could succeed.
+func (e *StatusError) temporary() bool {
+	return e.StatusCode >= 500 || e.StatusCode == http.StatusTooManyRequests
+}
+
+// permanentError is an error that trying the request again won't fix, like a response that isn't valid JSON.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+func (e *permanentError) Unwrap() error {
+	return e.err
+}
+
+// retryable reports whether a failed attempt is worth trying again: network errors, server errors and rate limits
+// are, anything wrong with the request or the response itself isn't.
+func retryable(err error) bool {
+	var se *StatusError
+	if errors.As(err, &se) {
+		return se.temporary()
+	}
+
+	var pe *permanentError
+	return !errors.As(err, &pe)
+}
+
+// NullItemError is returned for items the API has no data for, which is what it does for deleted items.
+type NullItemError struct {
+	ID int
+}
+
+func (e *NullItemError) Error() string {
+	return fmt.Sprintf("hn: item %d is null", e.ID)
+}
+
+// get fetches path and decodes the JSON response into v, retrying failed attempts as configured. It returns true if
+// the response was null, in which case v is left untouched.
+func (c *Client) get(ctx context.Context, path string, v interface{}) (bool, error) {
+	c.defaultify()
+
+	var err error
+	var null bool
+
+	for attempt := 0; ; attempt++ {
+		null, err = c.attempt(ctx, path, v)
+		if err == nil || attempt >= c.retries || ctx.Err() != nil {
+			return null, err
+		}
+
+		if !retryable(err) {
+			return null, err
+		}
+
+		wait := time.NewTimer(c.backoff << attempt)
+
+		select {
+		case <-ctx.Done():
+			wait.Stop()
+			return null, ctx.Err()
+		case <-wait.C:
+		}
+	}
+}
+
+func (c *Client) attempt(ctx context.Context, path string, v interface{}) (bool, error) {
+	if c.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.timeout)
+		defer cancel()
+	}
+
+	url := c.apiBase + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, &permanentError{err}
+	}
+
+	req.Header.Set("Accept", "application/json")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return false, &StatusError{StatusCode: resp.StatusCode, URL: url}
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxBodySize+1))
+	if err != nil {
+		return false, err
+	}
+
+	if len(body) > maxBodySize {
+		return false, &permanentError{fmt.Errorf("hn: %s returned more than %d bytes", url, maxBodySize)}
+	}
+
+	if string(bytes.TrimSpace(body)) == "null" {
+		return true, nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return false, &permanentError{err}
 	}
+
+	return false, nil
 }
 
 // TopItems returns the ids of roughly 450 top items in decreasing order. These
@@ -32,41 +247,116 @@ func (c *Client) defaultify() {
 // TopItmes does not filter out job listings or anything else, as the type of
 // each item is unknown without further API calls.
 func (c *Client) TopItems() ([]int, error) {
-	c.defaultify()
-	resp, err := http.Get(fmt.Sprintf("%s/topstories.json", c.apiBase))
-	if err != nil {
-		return nil, err
-	}
-	defer resp.Body.Close()
+	return c.TopItemsContext(context.Background())
+}
+
+// TopItemsContext is TopItems with a context to cancel the request with.
+func (c *Client) TopItemsContext(ctx context.Context) ([]int, error) {
+	return c.ids(ctx, "/topstories.json")
+}
+
+// NewStories returns the ids of up to 500 of the newest stories.
+func (c *Client) NewStories() ([]int, error) {
+	return c.NewStoriesContext(context.Background())
+}
+
+// NewStoriesContext is NewStories with a context to cancel the request with.
+func (c *Client) NewStoriesContext(ctx context.Context) ([]int, error) {
+	return c.ids(ctx, "/newstories.json")
+}
+
+// BestStories returns the ids of up to 500 of the best stories.
+func (c *Client) BestStories() ([]int, error) {
+	return c.BestStoriesContext(context.Background())
+}
+
+// BestStoriesContext is BestStories with a context to cancel the request with.
+func (c *Client) BestStoriesContext(ctx context.Context) ([]int, error) {
+	return c.ids(ctx, "/beststories.json")
+}
+
+// AskStories returns the ids of up to 200 of the latest Ask HN stories.
+func (c *Client) AskStories() ([]int, error) {
+	return c.AskStoriesContext(context.Background())
+}
+
+// AskStoriesContext is AskStories with a context to cancel the request with.
+func (c *Client) AskStoriesContext(ctx context.Context) ([]int, error) {
+	return c.ids(ctx, "/askstories.json")
+}
+
+// ShowStories returns the ids of up to 200 of the latest Show HN stories.
+func (c *Client) ShowStories() ([]int, error) {
+	return c.ShowStoriesContext(context.Background())
+}
+
+// ShowStoriesContext is ShowStories with a context to cancel the request with.
+func (c *Client) ShowStoriesContext(ctx context.Context) ([]int, error) {
+	return c.ids(ctx, "/showstories.json")
+}
+
+// JobStories returns the ids of up to 200 of the latest job listings.
+func (c *Client) JobStories() ([]int, error) {
+	return c.JobStoriesContext(context.Background())
+}
+
+// JobStoriesContext is JobStories with a context to cancel the request with.
+func (c *Client) JobStoriesContext(ctx context.Context) ([]int, error) {
+	return c.ids(ctx, "/jobstories.json")
+}
+
+func (c *Client) ids(ctx context.Context, path string) ([]int, error) {
 	var ids []int
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&ids)
-	if err != nil {
+	if _, err := c.get(ctx, path, &ids); err != nil {
 		return nil, err
 	}
 	return ids, nil
 }
 
+// MaxItem returns the largest item id handed out so far. Every id below it belongs to an item too.
+func (c *Client) MaxItem() (int, error) {
+	return c.MaxItemContext(context.Background())
+}
+
+// MaxItemContext is MaxItem with a context to cancel the request with.
+func (c *Client) MaxItemContext(ctx context.Context) (int, error) {
+	var id int
+	_, err := c.get(ctx, "/maxitem.json", &id)
+	return id, err
+}
+
 // GetItem will return the Item defined by the provided ID.
 func (c *Client) GetItem(id int) (Item, error) {
-	c.defaultify()
+	return c.GetItemContext(context.Background(), id)
+}
+
+// GetItemContext is GetItem with a context to cancel the request with. Items the API returns null for, such as
+// deleted items, are reported with a *NullItemError.
+func (c *Client) GetItemContext(ctx context.Context, id int) (Item, error) {
+	if c.cache != nil {
+		if item, ok := c.cache.Get(id); ok {
+			return item, nil
+		}
+	}
+
 	var item Item
-	resp, err := http.Get(fmt.Sprintf("%s/item/%d.json", c.apiBase, id))
+	null, err := c.get(ctx, fmt.Sprintf("/item/%d.json", id), &item)
 	if err != nil {
 		return item, err
 	}
-	defer resp.Body.Close()
-	dec := json.NewDecoder(resp.Body)
-	err = dec.Decode(&item)
-	if err != nil {
-		return item, err
+	if null {
+		return item, &NullItemError{ID: id}
+	}
+
+	if c.cache != nil {
+		c.cache.Set(item)
 	}
 	return item, nil
 }
 
 // Item represents a single item returned by the HN API. This can have a type
-// of "story", "comment", or "job" (and probably more values), and one of the
-// URL or Text fields will be set, but not both.
+// of "story", "comment", "job", "poll" or "pollopt", and one of the URL or
+// Text fields will be set, but not both.
 //
 // For the purpose of this exercise, we only care about items where the
 // type is "story", and the URL is set.
@@ -83,4 +373,13 @@ type Item struct {
 	// Only one of these should exist
 	Text string `json:"text"`
 	URL  string `json:"url"`
+
+	// Parent is the comment or story a comment belongs to, and Poll is the
+	// poll a pollopt belongs to. Parts are the pollopts of a poll.
+	Parent int   `json:"parent"`
+	Poll   int   `json:"poll"`
+	Parts  []int `json:"parts"`
+
+	Deleted bool `json:"deleted"`
+	Dead    bool `json:"dead"`
 }
@@ -1,10 +1,16 @@
 package hn
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func setup() (string, func()) {
@@ -12,6 +18,30 @@ func setup() (string, func()) {
 	mux.HandleFunc("/topstories.json", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "[0,1,2,3,4]")
 	})
+	for _, feed := range []string{"new", "best", "ask", "show", "job"} {
+		mux.HandleFunc("/"+feed+"stories.json", func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, "[5,6,7]")
+		})
+	}
+	mux.HandleFunc("/maxitem.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "16735000")
+	})
+	mux.HandleFunc("/updates.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"items\":[16732999,16729637],\"profiles\":[\"test_user\"]}")
+	})
+	mux.HandleFunc("/user/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/user/test_user.json" {
+			fmt.Fprint(w, "null")
+			return
+		}
+		fmt.Fprint(w, "{\"about\":\"Testing things\",\"created\":1173923446,\"id\":\"test_user\",\"karma\":2937,\"submitted\":[1,2,3]}")
+	})
+	mux.HandleFunc("/item/126809.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"by\":\"test_user\",\"descendants\":54,\"id\":126809,\"kids\":[126822],\"parts\":[126810,126811],\"score\":46,\"time\":1204403652,\"title\":\"Poll: What would happen if News.YC had explicit support for polls?\",\"type\":\"poll\"}")
+	})
+	mux.HandleFunc("/item/126810.json", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "{\"by\":\"test_user\",\"id\":126810,\"poll\":126809,\"score\":335,\"text\":\"Yes, ban them\",\"time\":1207886576,\"type\":\"pollopt\"}")
+	})
 	mux.HandleFunc("/item/", func(w http.ResponseWriter, r *http.Request) {
 		fmt.Fprint(w, "{\"by\":\"test_user\",\"descendants\":10,\"id\":1,\"kids\":[16732999,16729637,16729517,16729595],\"score\":34,\"time\":1522599083,\"title\":\"Test Story Title\",\"type\":\"story\",\"url\":\"https://www.test-story.com\"}")
 	})
@@ -45,6 +75,29 @@ func TestClient_defaultify(t *testing.T) {
 	}
 }
 
+func TestClient_concurrentDefaults(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	// The zero value Client fills in its defaults on first use, which must be safe from many goroutines.
+	c := Client{apiBase: baseURL}
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if _, err := c.TopItems(); err != nil {
+				t.Errorf("client.TopItems() received an error: %s", err.Error())
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
 func TestClient_GetItem(t *testing.T) {
 	baseURL, teardown := setup()
 	defer teardown()
@@ -62,3 +115,252 @@ func TestClient_GetItem(t *testing.T) {
 		t.Errorf("item.By: want %s, got %s", "test_user", item.By)
 	}
 }
+
+func TestClient_feeds(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	c := NewClient(WithBaseURL(baseURL))
+
+	tests := []struct {
+		name string
+		feed func() ([]int, error)
+	}{
+		{name: "new", feed: c.NewStories},
+		{name: "best", feed: c.BestStories},
+		{name: "ask", feed: c.AskStories},
+		{name: "show", feed: c.ShowStories},
+		{name: "job", feed: c.JobStories},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ids, err := tt.feed()
+			if err != nil {
+				t.Fatalf("received an error: %s", err.Error())
+			}
+			if len(ids) != 3 {
+				t.Errorf("len(ids): want %d, got %d", 3, len(ids))
+			}
+		})
+	}
+}
+
+func TestClient_MaxItem(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	c := NewClient(WithBaseURL(baseURL))
+	id, err := c.MaxItem()
+	if err != nil {
+		t.Fatalf("client.MaxItem() received an error: %s", err.Error())
+	}
+	if id != 16735000 {
+		t.Errorf("id: want %d, got %d", 16735000, id)
+	}
+}
+
+func TestClient_GetItem_poll(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	c := NewClient(WithBaseURL(baseURL))
+	poll, err := c.GetItem(126809)
+	if err != nil {
+		t.Fatalf("client.GetItem() received an error: %s", err.Error())
+	}
+	if poll.Type != "poll" || len(poll.Parts) != 2 {
+		t.Errorf("poll: want a poll with 2 parts, got %q with %d", poll.Type, len(poll.Parts))
+	}
+
+	opt, err := c.GetItem(poll.Parts[0])
+	if err != nil {
+		t.Fatalf("client.GetItem() received an error: %s", err.Error())
+	}
+	if opt.Poll != poll.ID {
+		t.Errorf("opt.Poll: want %d, got %d", poll.ID, opt.Poll)
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	hc := &http.Client{}
+	c := NewClient(
+		WithHTTPClient(hc),
+		WithBaseURL("http://example.com"),
+		WithUserAgent("quiet_hn"),
+		WithTimeout(time.Second),
+		WithRetries(3, time.Millisecond),
+	)
+	if c.httpClient != hc {
+		t.Errorf("c.httpClient: want %p, got %p", hc, c.httpClient)
+	}
+	if c.apiBase != "http://example.com" {
+		t.Errorf("c.apiBase: want %s, got %s", "http://example.com", c.apiBase)
+	}
+	if c.userAgent != "quiet_hn" {
+		t.Errorf("c.userAgent: want %s, got %s", "quiet_hn", c.userAgent)
+	}
+	if c.timeout != time.Second {
+		t.Errorf("c.timeout: want %v, got %v", time.Second, c.timeout)
+	}
+	if c.retries != 3 || c.backoff != time.Millisecond {
+		t.Errorf("retries: want 3 every %v, got %d every %v", time.Millisecond, c.retries, c.backoff)
+	}
+}
+
+func TestClient_UserAgent(t *testing.T) {
+	var got string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("User-Agent")
+		fmt.Fprint(w, "[]")
+	}))
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL), WithUserAgent("quiet_hn/1.0"))
+	if _, err := c.TopItems(); err != nil {
+		t.Fatalf("client.TopItems() received an error: %s", err.Error())
+	}
+	if got != "quiet_hn/1.0" {
+		t.Errorf("User-Agent: want %s, got %s", "quiet_hn/1.0", got)
+	}
+}
+
+func TestClient_StatusError(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL), WithRetries(3, time.Millisecond))
+	_, err := c.GetItem(1)
+
+	var se *StatusError
+	if !errors.As(err, &se) {
+		t.Fatalf("expected a *StatusError, got %v", err)
+	}
+	if se.StatusCode != http.StatusNotFound {
+		t.Errorf("se.StatusCode: want %d, got %d", http.StatusNotFound, se.StatusCode)
+	}
+	if calls != 1 {
+		t.Errorf("calls: want %d, got %d", 1, calls)
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	tests := []struct {
+		name      string
+		fails     int32
+		retries   int
+		wantCalls int32
+		wantErr   bool
+	}{
+		{name: "succeeds after retrying", fails: 2, retries: 2, wantCalls: 3},
+		{name: "gives up", fails: 3, retries: 2, wantCalls: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&calls, 1) <= tt.fails {
+					http.Error(w, "unavailable", http.StatusServiceUnavailable)
+					return
+				}
+				fmt.Fprint(w, "[1,2,3]")
+			}))
+			defer server.Close()
+
+			c := NewClient(WithBaseURL(server.URL), WithRetries(tt.retries, time.Millisecond))
+			ids, err := c.TopItems()
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err: want error %v, got %v", tt.wantErr, err)
+			}
+			if !tt.wantErr && len(ids) != 3 {
+				t.Errorf("len(ids): want %d, got %d", 3, len(ids))
+			}
+			if calls != tt.wantCalls {
+				t.Errorf("calls: want %d, got %d", tt.wantCalls, calls)
+			}
+		})
+	}
+}
+
+func TestClient_invalidResponse(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+	}{
+		{name: "invalid JSON", body: "[1,2"},
+		{name: "wrong type", body: `{"id": 1}`},
+		{name: "too big", body: "[" + strings.Repeat(" ", maxBodySize) + "]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&calls, 1)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			c := NewClient(WithBaseURL(server.URL), WithRetries(3, time.Millisecond))
+			if _, err := c.TopItems(); err == nil {
+				t.Fatalf("expected an error")
+			}
+			if calls != 1 {
+				t.Errorf("calls: want %d, got %d", 1, calls)
+			}
+		})
+	}
+}
+
+func TestClient_NullItem(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "null")
+	}))
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL))
+	_, err := c.GetItem(42)
+
+	var ne *NullItemError
+	if !errors.As(err, &ne) {
+		t.Fatalf("expected a *NullItemError, got %v", err)
+	}
+	if ne.ID != 42 {
+		t.Errorf("ne.ID: want %d, got %d", 42, ne.ID)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	done := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(done)
+
+	c := NewClient(WithBaseURL(server.URL), WithTimeout(20*time.Millisecond))
+	if _, err := c.TopItems(); err == nil {
+		t.Errorf("expected a timeout error, got nil")
+	}
+}
+
+func TestClient_GetItemContext_cancelled(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := NewClient(WithBaseURL(baseURL), WithRetries(3, time.Millisecond))
+	if _, err := c.GetItemContext(ctx, 1); !errors.Is(err, context.Canceled) {
+		t.Errorf("err: want %v, got %v", context.Canceled, err)
+	}
+}
@@ -0,0 +1,406 @@
+// Package hntest provides a fake Hacker News API for tests and offline development. It serves fixtures from a
+// directory or a generated dataset, including comments, users, deleted and dead items and the updates feed, and can
+// add latency and errors to its responses.
+package hntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The lists the API serves, without their .json extension.
+var lists = []string{"topstories", "newstories", "beststories", "askstories", "showstories", "jobstories"}
+
+// Dataset is everything a fake API serves.
+type Dataset struct {
+	Items map[int]hn.Item
+	Users map[string]hn.User
+	// Lists are the story lists by name, such as "topstories".
+	Lists   map[string][]int
+	Updates hn.Updates
+}
+
+// NewDataset returns a Dataset with the provided items and users, filling the story lists from the items.
+func NewDataset(items []hn.Item, users []hn.User) *Dataset {
+	ds := &Dataset{
+		Items: make(map[int]hn.Item),
+		Users: make(map[string]hn.User),
+		Lists: make(map[string][]int),
+	}
+
+	for _, item := range items {
+		ds.Items[item.ID] = item
+	}
+	for _, user := range users {
+		ds.Users[user.ID] = user
+	}
+
+	ds.fill()
+
+	return ds
+}
+
+// MaxItem returns the largest item id in the dataset.
+func (ds *Dataset) MaxItem() int {
+	max := 0
+	for id := range ds.Items {
+		if id > max {
+			max = id
+		}
+	}
+	return max
+}
+
+// fill derives the story lists that weren't provided from the items, roughly the way HN ranks them.
+func (ds *Dataset) fill() {
+	var stories []hn.Item
+	for _, item := range ds.Items {
+		if (item.Type == "story" || item.Type == "job" || item.Type == "poll") && !item.Deleted && !item.Dead {
+			stories = append(stories, item)
+		}
+	}
+
+	ranked := func(name string, keep func(hn.Item) bool, less func(a, b hn.Item) bool) {
+		if _, ok := ds.Lists[name]; ok {
+			return
+		}
+
+		var matching []hn.Item
+		for _, story := range stories {
+			if keep(story) {
+				matching = append(matching, story)
+			}
+		}
+
+		sort.Slice(matching, func(i, j int) bool {
+			if less(matching[i], matching[j]) {
+				return true
+			}
+			if less(matching[j], matching[i]) {
+				return false
+			}
+			return matching[i].ID > matching[j].ID
+		})
+
+		ids := make([]int, 0, len(matching))
+		for _, story := range matching {
+			ids = append(ids, story.ID)
+		}
+		ds.Lists[name] = ids
+	}
+
+	notJob := func(item hn.Item) bool { return item.Type != "job" }
+	newest := func(a, b hn.Item) bool { return a.Time > b.Time }
+	best := func(a, b hn.Item) bool { return a.Score > b.Score }
+	// HN's ranking is roughly score divided by age, with a gravity of 1.8.
+	hot := func(a, b hn.Item) bool {
+		now := time.Now().Unix()
+		rank := func(item hn.Item) float64 {
+			hours := float64(now-int64(item.Time))/3600 + 2
+			return float64(item.Score-1) / math.Pow(hours, 1.8)
+		}
+		return rank(a) > rank(b)
+	}
+
+	ranked("topstories", func(hn.Item) bool { return true }, hot)
+	ranked("newstories", notJob, newest)
+	ranked("beststories", notJob, best)
+	ranked("askstories", func(item hn.Item) bool { return strings.HasPrefix(item.Title, "Ask HN") }, newest)
+	ranked("showstories", func(item hn.Item) bool { return strings.HasPrefix(item.Title, "Show HN") }, newest)
+	ranked("jobstories", func(item hn.Item) bool { return item.Type == "job" }, newest)
+}
+
+// capitalize upper-cases the first letter of s.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// LoadDir reads a fixture directory. Items are read from items/<id>.json and users from users/<id>.json. The story
+// lists, like topstories.json, and updates.json are read from the top of the directory if they exist, and are filled
+// from the items otherwise.
+func LoadDir(dir string) (*Dataset, error) {
+	var items []hn.Item
+	var users []hn.User
+
+	err := readAll(filepath.Join(dir, "items"), func(data []byte) error {
+		var item hn.Item
+		if err := json.Unmarshal(data, &item); err != nil {
+			return err
+		}
+		items = append(items, item)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = readAll(filepath.Join(dir, "users"), func(data []byte) error {
+		var user hn.User
+		if err := json.Unmarshal(data, &user); err != nil {
+			return err
+		}
+		users = append(users, user)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	ds := &Dataset{
+		Items: make(map[int]hn.Item),
+		Users: make(map[string]hn.User),
+		Lists: make(map[string][]int),
+	}
+
+	for _, name := range lists {
+		data, err := ioutil.ReadFile(filepath.Join(dir, name+".json"))
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var ids []int
+		if err := json.Unmarshal(data, &ids); err != nil {
+			return nil, fmt.Errorf("%s.json: %v", name, err)
+		}
+		ds.Lists[name] = ids
+	}
+
+	if data, err := ioutil.ReadFile(filepath.Join(dir, "updates.json")); err == nil {
+		if err := json.Unmarshal(data, &ds.Updates); err != nil {
+			return nil, fmt.Errorf("updates.json: %v", err)
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	for _, item := range items {
+		ds.Items[item.ID] = item
+	}
+	for _, user := range users {
+		ds.Users[user.ID] = user
+	}
+
+	ds.fill()
+
+	return ds, nil
+}
+
+// readAll calls fn with the contents of every JSON file in dir. A missing dir has no files.
+func readAll(dir string, fn func([]byte) error) error {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != ".json" {
+			continue
+		}
+
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			return err
+		}
+
+		if err := fn(data); err != nil {
+			return fmt.Errorf("%s: %v", file.Name(), err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	names = []string{
+		"pg", "dang", "tptacek", "patio11", "jacquesm", "rayiner", "ingve", "tosh", "walterbell", "danso",
+		"pjmlp", "JumpCrisscross", "ChuckMcM", "kragen", "userbinator", "Animats", "nostrademons", "DonHopkins",
+		"simonw", "mikeash", "cperciva", "gruseom", "sctb", "luu", "todsacerdoti", "rbanffy", "bookofjoe",
+	}
+	subjects = []string{
+		"a SQLite extension", "the Linux scheduler", "a tiny Lisp", "Go's garbage collector", "CRDTs", "the 6502",
+		"a text editor", "TCP congestion control", "a ray tracer", "Postgres indexes", "WebAssembly", "Rust's borrow checker",
+		"a mechanical keyboard", "the Apollo guidance computer", "an open source CAD tool", "spreadsheets",
+	}
+	formats = []string{
+		"How we rewrote %s in a weekend", "%s, explained", "Understanding %s (2019)", "I built %s from scratch",
+		"The hidden complexity of %s", "Why %s is slower than you think", "A visual guide to %s",
+		"Lessons from ten years of %s", "%s is all you need",
+	}
+	hosts = []string{
+		"github.com", "arstechnica.com", "nytimes.com", "blog.example.com", "lwn.net", "medium.com",
+		"theverge.com", "go.dev", "jvns.ca", "danluu.com", "arxiv.org", "en.wikipedia.org",
+	}
+	remarks = []string{
+		"I've been using this for years and it's great.",
+		"This reminds me of <i>The Mythical Man-Month</i>.",
+		"Does anyone know how this compares to the <a href=\"https:&#x2F;&#x2F;example.com&#x2F;other\" rel=\"nofollow\">other one</a>?",
+		"The benchmark in the article doesn't measure what it claims to.<p>Try it with a cold cache.",
+		"Previous discussion: <a href=\"https:&#x2F;&#x2F;news.ycombinator.com&#x2F;item?id=1\">https:&#x2F;&#x2F;news.ycombinator.com&#x2F;item?id=1</a>",
+		"We did exactly this at my last job. It worked until it didn&#x27;t.",
+		"<pre><code>  for i := range xs {\n      go f(i)\n  }\n</code></pre>\nThis is the bug.",
+		"Fascinating. I had no idea.",
+		"The title is a bit misleading &gt; the article is mostly about the history.",
+	}
+)
+
+// Generate returns a realistic dataset of the provided number of stories, with comment threads, users, jobs, polls,
+// Ask and Show HN posts, and a few deleted and dead items. The same seed always generates the same dataset, apart from
+// the times, which are relative to now.
+func Generate(seed int64, stories int) *Dataset {
+	r := rand.New(rand.NewSource(seed))
+	now := time.Now().Unix()
+
+	var items []hn.Item
+	nextID := 1000000
+
+	newID := func() int {
+		nextID += 1 + r.Intn(5)
+		return nextID
+	}
+	author := func() string {
+		return names[r.Intn(len(names))]
+	}
+
+	// comments adds up to depth levels of replies to parent, returning the ids of its kids and how many
+	// descendants it has.
+	var comments func(parent hn.Item, depth int) ([]int, int)
+	comments = func(parent hn.Item, depth int) ([]int, int) {
+		if depth <= 0 {
+			return nil, 0
+		}
+
+		var kids []int
+		total := 0
+
+		for i, n := 0, r.Intn(4+depth); i < n; i++ {
+			c := hn.Item{
+				ID:     newID(),
+				By:     author(),
+				Parent: parent.ID,
+				Time:   parent.Time + 60 + r.Intn(3600),
+				Type:   "comment",
+				Text:   remarks[r.Intn(len(remarks))],
+			}
+
+			switch r.Intn(25) {
+			case 0:
+				c = hn.Item{ID: c.ID, Deleted: true, Parent: c.Parent, Time: c.Time, Type: "comment"}
+			case 1:
+				c.Dead = true
+				c.Text = "[flagged]"
+			}
+
+			var descendants int
+			c.Kids, descendants = comments(c, depth-1-r.Intn(2))
+
+			items = append(items, c)
+			kids = append(kids, c.ID)
+			total += 1 + descendants
+		}
+
+		return kids, total
+	}
+
+	for i := 0; i < stories; i++ {
+		story := hn.Item{
+			ID:    newID(),
+			By:    author(),
+			Score: 1 + int(r.ExpFloat64()*80),
+			Time:  int(now) - r.Intn(24*3600),
+			Type:  "story",
+		}
+
+		title := fmt.Sprintf(formats[r.Intn(len(formats))], subjects[r.Intn(len(subjects))])
+
+		switch kind := r.Intn(20); {
+		case kind == 0:
+			story.Type = "job"
+			story.Title = fmt.Sprintf("%s (YC S%02d) is hiring engineers to work on %s", capitalize(names[r.Intn(len(names))]), 10+r.Intn(14), subjects[r.Intn(len(subjects))])
+			story.URL = "https://" + hosts[r.Intn(len(hosts))] + "/jobs/" + strconv.Itoa(story.ID)
+			story.Score = 1
+		case kind == 1:
+			story.Title = "Ask HN: What's your experience with " + subjects[r.Intn(len(subjects))] + "?"
+			story.Text = "I'm evaluating it for work and would love to hear from people who have used it in production."
+		case kind == 2:
+			story.Title = "Show HN: " + capitalize(title)
+			story.URL = "https://github.com/" + author() + "/" + strconv.Itoa(story.ID)
+		case kind == 3:
+			story.Type = "poll"
+			story.Title = "Poll: Which do you prefer, " + subjects[r.Intn(len(subjects))] + " or " + subjects[r.Intn(len(subjects))] + "?"
+			for _, option := range []string{"The first", "The second", "Neither"} {
+				opt := hn.Item{ID: newID(), By: story.By, Poll: story.ID, Score: r.Intn(200), Text: option, Time: story.Time, Type: "pollopt"}
+				items = append(items, opt)
+				story.Parts = append(story.Parts, opt.ID)
+			}
+		case kind == 4:
+			story.Title = capitalize(title)
+			story.URL = "https://" + hosts[r.Intn(len(hosts))] + "/" + strconv.Itoa(story.ID)
+			story.Dead = true
+		default:
+			story.Title = capitalize(title)
+			story.URL = "https://" + hosts[r.Intn(len(hosts))] + "/" + strconv.Itoa(story.ID)
+		}
+
+		if story.Type != "job" {
+			story.Kids, story.Descendants = comments(story, 1+r.Intn(4))
+		}
+
+		items = append(items, story)
+	}
+
+	var users []hn.User
+	submitted := make(map[string][]int)
+
+	for _, item := range items {
+		if item.By != "" {
+			submitted[item.By] = append(submitted[item.By], item.ID)
+		}
+	}
+
+	for i, name := range names {
+		ids := submitted[name]
+		sort.Sort(sort.Reverse(sort.IntSlice(ids)))
+
+		users = append(users, hn.User{
+			ID:        name,
+			Created:   int(now) - (i+1)*86400*200,
+			Karma:     100 + r.Intn(50000),
+			About:     "Hello, I'm " + name + ".",
+			Submitted: ids,
+		})
+	}
+
+	ds := NewDataset(items, users)
+
+	for _, name := range names[:3] {
+		ds.Updates.Profiles = append(ds.Updates.Profiles, name)
+	}
+	for _, id := range ds.Lists["topstories"] {
+		if len(ds.Updates.Items) == 10 {
+			break
+		}
+		ds.Updates.Items = append(ds.Updates.Items, id)
+	}
+
+	return ds
+}
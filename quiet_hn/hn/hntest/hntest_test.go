@@ -0,0 +1,197 @@
+package hntest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"testing"
+	"time"
+)
+
+func TestLoadDir(t *testing.T) {
+	ds, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatalf("LoadDir() received an error: %s", err.Error())
+	}
+
+	server, _ := NewServer(ds, Options{})
+	defer server.Close()
+
+	c := hn.NewClient(hn.WithBaseURL(server.URL + "/v0"))
+
+	ids, err := c.TopItems()
+	if err != nil {
+		t.Fatalf("client.TopItems() received an error: %s", err.Error())
+	}
+	if len(ids) != 4 || ids[0] != 8863 {
+		t.Errorf("ids: want the 4 ids in topstories.json, got %v", ids)
+	}
+
+	// The lists missing from the directory are filled from the items.
+	jobs, err := c.JobStories()
+	if err != nil {
+		t.Fatalf("client.JobStories() received an error: %s", err.Error())
+	}
+	if len(jobs) != 1 || jobs[0] != 192327 {
+		t.Errorf("jobs: want [192327], got %v", jobs)
+	}
+
+	ask, err := c.AskStories()
+	if err != nil {
+		t.Fatalf("client.AskStories() received an error: %s", err.Error())
+	}
+	if len(ask) != 1 || ask[0] != 121003 {
+		t.Errorf("ask: want [121003], got %v", ask)
+	}
+
+	deleted, err := c.GetItem(8917)
+	if err != nil {
+		t.Fatalf("client.GetItem() received an error: %s", err.Error())
+	}
+	if !deleted.Deleted {
+		t.Errorf("expected item 8917 to be deleted")
+	}
+
+	var ne *hn.NullItemError
+	if _, err := c.GetItem(1); !errors.As(err, &ne) {
+		t.Errorf("expected a *NullItemError for a missing item, got %v", err)
+	}
+
+	user, err := c.GetUser("pg")
+	if err != nil {
+		t.Fatalf("client.GetUser() received an error: %s", err.Error())
+	}
+	if user.Karma != 155111 {
+		t.Errorf("user.Karma: want %d, got %d", 155111, user.Karma)
+	}
+
+	updates, err := c.Updates()
+	if err != nil {
+		t.Fatalf("client.Updates() received an error: %s", err.Error())
+	}
+	if len(updates.Items) != 2 {
+		t.Errorf("len(updates.Items): want %d, got %d", 2, len(updates.Items))
+	}
+
+	max, err := c.MaxItem()
+	if err != nil {
+		t.Fatalf("client.MaxItem() received an error: %s", err.Error())
+	}
+	if max != 192327 {
+		t.Errorf("max: want %d, got %d", 192327, max)
+	}
+
+	thread, err := c.GetThread(context.Background(), 8863, 10)
+	if err != nil {
+		t.Fatalf("client.GetThread() received an error: %s", err.Error())
+	}
+	if len(thread.Replies) != 2 || len(thread.Replies[0].Replies) != 1 {
+		t.Errorf("thread: want 2 replies with 1 reply to the first, got %d", len(thread.Replies))
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	ds := Generate(1, 200)
+
+	if got := len(Generate(1, 200).Items); got != len(ds.Items) {
+		t.Errorf("expected the same seed to generate the same dataset, got %d and %d items", len(ds.Items), got)
+	}
+
+	counts := make(map[string]int)
+	for _, item := range ds.Items {
+		counts[item.Type]++
+		if item.Deleted {
+			counts["deleted"]++
+		}
+		if item.Dead {
+			counts["dead"]++
+		}
+	}
+
+	for _, kind := range []string{"story", "comment", "job", "poll", "pollopt", "deleted", "dead"} {
+		if counts[kind] == 0 {
+			t.Errorf("expected some %s items, got none", kind)
+		}
+	}
+
+	for _, name := range lists {
+		if len(ds.Lists[name]) == 0 {
+			t.Errorf("expected %s to have stories", name)
+		}
+	}
+
+	for _, id := range ds.Lists["topstories"] {
+		if item := ds.Items[id]; item.Dead || item.Deleted {
+			t.Errorf("expected topstories to leave out dead and deleted items, got %d", id)
+		}
+	}
+}
+
+func TestHandler_errors(t *testing.T) {
+	server, h := NewServer(Generate(1, 10), Options{ErrorRate: 1})
+	defer server.Close()
+
+	c := hn.NewClient(hn.WithBaseURL(server.URL), hn.WithRetries(2, time.Millisecond))
+
+	var se *hn.StatusError
+	if _, err := c.TopItems(); !errors.As(err, &se) || se.StatusCode != 503 {
+		t.Errorf("expected a 503 *StatusError, got %v", err)
+	}
+	if h.Requests() != 3 {
+		t.Errorf("h.Requests(): want %d, got %d", 3, h.Requests())
+	}
+}
+
+func TestHandler_latency(t *testing.T) {
+	server, _ := NewServer(Generate(1, 10), Options{Latency: 50 * time.Millisecond})
+	defer server.Close()
+
+	c := hn.NewClient(hn.WithBaseURL(server.URL), hn.WithTimeout(10*time.Millisecond))
+	if _, err := c.TopItems(); err == nil {
+		t.Errorf("expected the request to time out")
+	}
+}
+
+func TestHandler_watch(t *testing.T) {
+	ds, err := LoadDir("testdata")
+	if err != nil {
+		t.Fatalf("LoadDir() received an error: %s", err.Error())
+	}
+
+	server, h := NewServer(ds, Options{})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	c := hn.NewClient(hn.WithBaseURL(server.URL))
+	events, err := c.Watch(ctx, "/item/8863.json")
+	if err != nil {
+		t.Fatalf("client.Watch() received an error: %s", err.Error())
+	}
+
+	<-events
+
+	story := ds.Items[8863]
+	story.Score = 500
+	h.SetItem(story)
+
+	select {
+	case event := <-events:
+		var item hn.Item
+		if err := json.Unmarshal(event.Data, &item); err != nil || item.Score != 500 {
+			t.Errorf("expected a put with a score of 500, got %s", event.Data)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected an event after SetItem")
+	}
+
+	updates, err := c.Updates()
+	if err != nil {
+		t.Fatalf("client.Updates() received an error: %s", err.Error())
+	}
+	if updates.Items[0] != 8863 {
+		t.Errorf("updates.Items[0]: want %d, got %d", 8863, updates.Items[0])
+	}
+}
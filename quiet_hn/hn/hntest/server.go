@@ -0,0 +1,260 @@
+package hntest
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options add the misbehaviour of a real network to a Handler.
+type Options struct {
+	// Latency is added to every response, plus a random amount of up to Jitter.
+	Latency time.Duration
+	Jitter  time.Duration
+	// ErrorRate is the fraction of requests, from 0 to 1, answered with ErrorStatus instead. ErrorStatus defaults to
+	// 503 Service Unavailable.
+	ErrorRate   float64
+	ErrorStatus int
+	// Seed seeds the jitter and the errors.
+	Seed int64
+}
+
+// Handler serves a Dataset the way the HN API does, with or without the /v0 prefix. Requests that accept
+// text/event-stream are streamed like the real API streams them: a put with the current data, followed by a put
+// every time SetItem or SetList changes it.
+type Handler struct {
+	opts Options
+
+	mutex    sync.Mutex
+	ds       *Dataset
+	rand     *rand.Rand
+	requests int
+	watchers map[string]map[chan []byte]struct{}
+}
+
+// NewHandler returns a Handler serving ds.
+func NewHandler(ds *Dataset, opts Options) *Handler {
+	if opts.ErrorStatus == 0 {
+		opts.ErrorStatus = http.StatusServiceUnavailable
+	}
+
+	return &Handler{
+		opts:     opts,
+		ds:       ds,
+		rand:     rand.New(rand.NewSource(opts.Seed)),
+		watchers: make(map[string]map[chan []byte]struct{}),
+	}
+}
+
+// NewServer starts a server for ds, to be closed by the caller. Point an hn.Client at it with hn.WithBaseURL(s.URL).
+func NewServer(ds *Dataset, opts Options) (*httptest.Server, *Handler) {
+	h := NewHandler(ds, opts)
+	return httptest.NewServer(h), h
+}
+
+// Requests returns how many requests the Handler has answered, including failed ones.
+func (h *Handler) Requests() int {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	return h.requests
+}
+
+// Item returns the item with the provided id.
+func (h *Handler) Item(id int) (hn.Item, bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	item, ok := h.ds.Items[id]
+	return item, ok
+}
+
+// SetItem adds or replaces an item, adds it to the updates feed and tells anyone watching it.
+func (h *Handler) SetItem(item hn.Item) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.ds.Items[item.ID] = item
+
+	updates := &h.ds.Updates
+	updates.Items = append([]int{item.ID}, remove(updates.Items, item.ID)...)
+
+	h.notify(fmt.Sprintf("/item/%d.json", item.ID), item)
+	h.notify("/updates.json", *updates)
+}
+
+// SetList replaces one of the story lists, such as "topstories", and tells anyone watching it.
+func (h *Handler) SetList(name string, ids []int) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	h.ds.Lists[name] = ids
+	h.notify("/"+name+".json", ids)
+}
+
+func remove(ids []int, id int) []int {
+	kept := ids[:0:0]
+	for _, existing := range ids {
+		if existing != id {
+			kept = append(kept, existing)
+		}
+	}
+	return kept
+}
+
+// notify sends a put of v to everyone watching path. The caller must hold h.mutex.
+func (h *Handler) notify(path string, v interface{}) {
+	event, err := putEvent(v)
+	if err != nil {
+		return
+	}
+
+	for ch := range h.watchers[path] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+func putEvent(v interface{}) ([]byte, error) {
+	data, err := json.Marshal(map[string]interface{}{"path": "/", "data": v})
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(fmt.Sprintf("event: put\ndata: %s\n\n", data)), nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v0")
+
+	h.mutex.Lock()
+	h.requests++
+	delay := h.opts.Latency
+	if h.opts.Jitter > 0 {
+		delay += time.Duration(h.rand.Int63n(int64(h.opts.Jitter)))
+	}
+	fail := h.opts.ErrorRate > 0 && h.rand.Float64() < h.opts.ErrorRate
+	data, found := h.lookup(path)
+	h.mutex.Unlock()
+
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	if fail {
+		http.Error(w, http.StatusText(h.opts.ErrorStatus), h.opts.ErrorStatus)
+		return
+	}
+
+	if !found {
+		http.Error(w, "Not found", http.StatusNotFound)
+		return
+	}
+
+	if r.Header.Get("Accept") == "text/event-stream" {
+		h.stream(w, r, path, data)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	_ = json.NewEncoder(w).Encode(data)
+}
+
+// lookup returns what the API serves at path. Unknown items and users are null, like they are on the real API. The
+// caller must hold h.mutex.
+func (h *Handler) lookup(path string) (interface{}, bool) {
+	if !strings.HasSuffix(path, ".json") {
+		return nil, false
+	}
+
+	path = strings.TrimSuffix(path, ".json")
+
+	switch {
+	case strings.HasPrefix(path, "/item/"):
+		id, err := strconv.Atoi(strings.TrimPrefix(path, "/item/"))
+		if err != nil {
+			return nil, false
+		}
+		if item, ok := h.ds.Items[id]; ok {
+			return item, true
+		}
+		return nil, true
+	case strings.HasPrefix(path, "/user/"):
+		if user, ok := h.ds.Users[strings.TrimPrefix(path, "/user/")]; ok {
+			return user, true
+		}
+		return nil, true
+	case path == "/maxitem":
+		return h.ds.MaxItem(), true
+	case path == "/updates":
+		return h.ds.Updates, true
+	}
+
+	ids, ok := h.ds.Lists[strings.TrimPrefix(path, "/")]
+	if ok && ids == nil {
+		ids = []int{}
+	}
+	return ids, ok
+}
+
+// stream sends data as a put event, followed by every change to it, until the client goes away.
+func (h *Handler) stream(w http.ResponseWriter, r *http.Request, path string, data interface{}) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan []byte, 16)
+
+	h.mutex.Lock()
+	if h.watchers[path] == nil {
+		h.watchers[path] = make(map[chan []byte]struct{})
+	}
+	h.watchers[path][ch] = struct{}{}
+	h.mutex.Unlock()
+
+	defer func() {
+		h.mutex.Lock()
+		delete(h.watchers[path], ch)
+		h.mutex.Unlock()
+	}()
+
+	event, err := putEvent(data)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Write(event)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(30 * time.Second)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case event := <-ch:
+			w.Write(event)
+		case <-keepAlive.C:
+			fmt.Fprint(w, "event: keep-alive\ndata: null\n\n")
+		}
+		flusher.Flush()
+	}
+}
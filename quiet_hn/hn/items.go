@@ -0,0 +1,76 @@
+package hn
+
+import (
+	"context"
+	"sync"
+)
+
+const defaultWorkers = 10
+
+// BatchOptions configures how GetItems fetches its items.
+type BatchOptions struct {
+	// Workers is the most items fetched at the same time. Defaults to 10.
+	Workers int
+	// FailFast stops fetching the remaining items as soon as one of them fails.
+	FailFast bool
+}
+
+// Result is the outcome of fetching a single item with GetItems.
+type Result struct {
+	Item Item
+	Err  error
+}
+
+// GetItems fetches the items with the provided ids using a bounded pool of workers. The results are in the same order
+// as ids, and an item that could not be fetched has its error set instead. Once ctx is cancelled no more items are
+// requested, and the items that were never fetched report the context's error.
+func (c *Client) GetItems(ctx context.Context, ids []int, opts BatchOptions) []Result {
+	// The workers share c, so it has to be ready before they start.
+	c.defaultify()
+
+	if opts.Workers <= 0 {
+		opts.Workers = defaultWorkers
+	}
+	if opts.Workers > len(ids) {
+		opts.Workers = len(ids)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]Result, len(ids))
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for w := 0; w < opts.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				item, err := c.GetItemContext(ctx, ids[i])
+				results[i] = Result{Item: item, Err: err}
+				if err != nil && opts.FailFast {
+					cancel()
+				}
+			}
+		}()
+	}
+
+	next := 0
+dispatch:
+	for ; next < len(ids); next++ {
+		select {
+		case jobs <- next:
+		case <-ctx.Done():
+			break dispatch
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	for ; next < len(ids); next++ {
+		results[next].Err = ctx.Err()
+	}
+
+	return results
+}
@@ -0,0 +1,114 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// itemServer serves an item for every id, waiting a little longer for lower ids so they finish last. Ids listed in
+// nulls are served as null. It also records the most requests that were in flight at once.
+func itemServer(nulls ...int) (*httptest.Server, *int32) {
+	var inFlight, maxInFlight int32
+	var mu sync.Mutex
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		defer atomic.AddInt32(&inFlight, -1)
+
+		mu.Lock()
+		if n > maxInFlight {
+			maxInFlight = n
+		}
+		mu.Unlock()
+
+		id, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/item/"), ".json"))
+		time.Sleep(time.Duration(20-id%20) * time.Millisecond)
+
+		for _, null := range nulls {
+			if id == null {
+				fmt.Fprint(w, "null")
+				return
+			}
+		}
+		fmt.Fprintf(w, "{\"id\":%d,\"type\":\"story\"}", id)
+	}))
+
+	return server, &maxInFlight
+}
+
+func TestClient_GetItems(t *testing.T) {
+	server, maxInFlight := itemServer(3)
+	defer server.Close()
+
+	ids := []int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+
+	c := NewClient(WithBaseURL(server.URL))
+	results := c.GetItems(context.Background(), ids, BatchOptions{Workers: 4})
+
+	if len(results) != len(ids) {
+		t.Fatalf("len(results): want %d, got %d", len(ids), len(results))
+	}
+	for i, r := range results {
+		if ids[i] == 3 {
+			var ne *NullItemError
+			if !errors.As(r.Err, &ne) {
+				t.Errorf("results[%d].Err: expected a *NullItemError, got %v", i, r.Err)
+			}
+			continue
+		}
+		if r.Err != nil {
+			t.Errorf("results[%d].Err: want nil, got %v", i, r.Err)
+		}
+		if r.Item.ID != ids[i] {
+			t.Errorf("results[%d].Item.ID: want %d, got %d", i, ids[i], r.Item.ID)
+		}
+	}
+	if *maxInFlight > 4 {
+		t.Errorf("max requests in flight: want at most %d, got %d", 4, *maxInFlight)
+	}
+}
+
+func TestClient_GetItems_cancelled(t *testing.T) {
+	server, _ := itemServer()
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	c := NewClient(WithBaseURL(server.URL))
+	for i, r := range c.GetItems(ctx, []int{1, 2, 3}, BatchOptions{}) {
+		if !errors.Is(r.Err, context.Canceled) {
+			t.Errorf("results[%d].Err: want %v, got %v", i, context.Canceled, r.Err)
+		}
+	}
+}
+
+func TestClient_GetItems_failFast(t *testing.T) {
+	server, _ := itemServer(19)
+	defer server.Close()
+
+	ids := make([]int, 100)
+	for i := range ids {
+		ids[i] = i + 19
+	}
+
+	c := NewClient(WithBaseURL(server.URL))
+	results := c.GetItems(context.Background(), ids, BatchOptions{Workers: 1, FailFast: true})
+
+	var ne *NullItemError
+	if !errors.As(results[0].Err, &ne) {
+		t.Fatalf("results[0].Err: expected a *NullItemError, got %v", results[0].Err)
+	}
+	if last := results[len(results)-1]; !errors.Is(last.Err, context.Canceled) {
+		t.Errorf("last result: want %v, got %v", context.Canceled, last.Err)
+	}
+}
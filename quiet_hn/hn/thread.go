@@ -0,0 +1,81 @@
+package hn
+
+import (
+	"context"
+)
+
+const defaultMaxThreadSize = 500
+
+// WithMaxThreadSize limits how many items GetThread fetches for a single thread, including the root item.
+func WithMaxThreadSize(n int) Option {
+	return func(c *Client) {
+		c.maxThreadSize = n
+	}
+}
+
+// Thread is an item along with the replies to it, in the order HN ranks them.
+type Thread struct {
+	Item
+	Replies []*Thread
+	// More is how many replies weren't fetched because of the depth or size limits.
+	More int
+}
+
+// GetThread fetches the item with the provided id and up to depth levels of replies below it, a level at a time with
+// each level fetched concurrently. Replies that can't be fetched, such as deleted ones the API returns null for, are
+// left out. Once the Client's maximum thread size is reached the remaining replies are only counted.
+func (c *Client) GetThread(ctx context.Context, id, depth int) (*Thread, error) {
+	c.defaultify()
+
+	item, err := c.GetItemContext(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &Thread{Item: item}
+	budget := c.maxThreadSize - 1
+	level := []*Thread{root}
+
+	for d := 0; d < depth && len(level) > 0; d++ {
+		var ids []int
+		var parents []*Thread
+
+		for _, parent := range level {
+			for _, kid := range parent.Kids {
+				if len(ids) >= budget {
+					parent.More++
+					continue
+				}
+
+				ids = append(ids, kid)
+				parents = append(parents, parent)
+			}
+		}
+
+		budget -= len(ids)
+
+		var next []*Thread
+
+		for i, r := range c.GetItems(ctx, ids, BatchOptions{}) {
+			if r.Err != nil {
+				continue
+			}
+
+			reply := &Thread{Item: r.Item}
+			parents[i].Replies = append(parents[i].Replies, reply)
+			next = append(next, reply)
+		}
+
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		level = next
+	}
+
+	for _, t := range level {
+		t.More += len(t.Kids)
+	}
+
+	return root, nil
+}
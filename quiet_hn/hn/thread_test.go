@@ -0,0 +1,92 @@
+package hn
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// threadServer serves a story, 1, with this thread of comments below it:
+//
+//	1
+//	├── 2
+//	│   ├── 4
+//	│   │   └── 6
+//	│   └── 5 (deleted)
+//	└── 3
+func threadServer() *httptest.Server {
+	kids := map[int][]int{1: {2, 3}, 2: {4, 5}, 4: {6}}
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(strings.TrimSuffix(strings.TrimPrefix(r.URL.Path, "/item/"), ".json"))
+		if id < 1 || id > 6 || id == 5 {
+			w.Write([]byte("null"))
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(Item{ID: id, Kids: kids[id], Type: "comment"})
+	}))
+}
+
+// shape describes a thread as nested ids, like "1(2(4),3)", with the number of replies left out after a +.
+func shape(t *Thread) string {
+	s := strconv.Itoa(t.ID)
+	if len(t.Replies) > 0 {
+		var replies []string
+		for _, reply := range t.Replies {
+			replies = append(replies, shape(reply))
+		}
+		s += "(" + strings.Join(replies, ",") + ")"
+	}
+	if t.More > 0 {
+		s += "+" + strconv.Itoa(t.More)
+	}
+	return s
+}
+
+func TestClient_GetThread(t *testing.T) {
+	server := threadServer()
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		depth   int
+		maxSize int
+		want    string
+	}{
+		// The deleted comment, 5, is left out without being counted.
+		{name: "whole thread", depth: 10, want: "1(2(4(6)),3)"},
+		{name: "no replies", depth: 0, want: "1+2"},
+		{name: "one level", depth: 1, want: "1(2+2,3)"},
+		{name: "two levels", depth: 2, want: "1(2(4+1),3)"},
+		{name: "size limit", depth: 10, maxSize: 4, want: "1(2(4+1)+1,3)"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewClient(WithBaseURL(server.URL), WithMaxThreadSize(tt.maxSize))
+			thread, err := c.GetThread(context.Background(), 1, tt.depth)
+			if err != nil {
+				t.Fatalf("client.GetThread() received an error: %s", err.Error())
+			}
+
+			if got := shape(thread); got != tt.want {
+				t.Errorf("thread: want %s, got %s", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestClient_GetThread_null(t *testing.T) {
+	server := threadServer()
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL))
+	if _, err := c.GetThread(context.Background(), 5, 10); err == nil {
+		t.Errorf("expected an error for a deleted root, got nil")
+	}
+}
@@ -0,0 +1,62 @@
+package hn
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// User is a Hacker News user. Only users with public activity are available through the API.
+type User struct {
+	ID        string `json:"id"`
+	Created   int    `json:"created"`
+	Karma     int    `json:"karma"`
+	About     string `json:"about"`
+	Submitted []int  `json:"submitted"`
+}
+
+// NullUserError is returned for users the API has no data for.
+type NullUserError struct {
+	ID string
+}
+
+func (e *NullUserError) Error() string {
+	return fmt.Sprintf("hn: user %q is null", e.ID)
+}
+
+// GetUser returns the user with the provided case-sensitive id.
+func (c *Client) GetUser(id string) (User, error) {
+	return c.GetUserContext(context.Background(), id)
+}
+
+// GetUserContext is GetUser with a context to cancel the request with. Unknown users are reported with a
+// *NullUserError.
+func (c *Client) GetUserContext(ctx context.Context, id string) (User, error) {
+	var user User
+	null, err := c.get(ctx, "/user/"+url.PathEscape(id)+".json", &user)
+	if err != nil {
+		return user, err
+	}
+	if null {
+		return user, &NullUserError{ID: id}
+	}
+	return user, nil
+}
+
+// Updates are the items and profiles that changed recently.
+type Updates struct {
+	Items    []int    `json:"items"`
+	Profiles []string `json:"profiles"`
+}
+
+// Updates returns the items and profiles that changed recently.
+func (c *Client) Updates() (Updates, error) {
+	return c.UpdatesContext(context.Background())
+}
+
+// UpdatesContext is Updates with a context to cancel the request with.
+func (c *Client) UpdatesContext(ctx context.Context) (Updates, error) {
+	var updates Updates
+	_, err := c.get(ctx, "/updates.json", &updates)
+	return updates, err
+}
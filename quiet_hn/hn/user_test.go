@@ -0,0 +1,47 @@
+package hn
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestClient_GetUser(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	c := NewClient(WithBaseURL(baseURL))
+	user, err := c.GetUser("test_user")
+	if err != nil {
+		t.Fatalf("client.GetUser() received an error: %s", err.Error())
+	}
+	if user.Karma != 2937 {
+		t.Errorf("user.Karma: want %d, got %d", 2937, user.Karma)
+	}
+	if len(user.Submitted) != 3 {
+		t.Errorf("len(user.Submitted): want %d, got %d", 3, len(user.Submitted))
+	}
+
+	_, err = c.GetUser("nobody")
+
+	var ne *NullUserError
+	if !errors.As(err, &ne) {
+		t.Errorf("expected a *NullUserError, got %v", err)
+	}
+}
+
+func TestClient_Updates(t *testing.T) {
+	baseURL, teardown := setup()
+	defer teardown()
+
+	c := NewClient(WithBaseURL(baseURL))
+	updates, err := c.Updates()
+	if err != nil {
+		t.Fatalf("client.Updates() received an error: %s", err.Error())
+	}
+	if len(updates.Items) != 2 {
+		t.Errorf("len(updates.Items): want %d, got %d", 2, len(updates.Items))
+	}
+	if len(updates.Profiles) != 1 || updates.Profiles[0] != "test_user" {
+		t.Errorf("updates.Profiles: want [test_user], got %v", updates.Profiles)
+	}
+}
@@ -0,0 +1,144 @@
+package hn
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Event is a change pushed by the streaming API. A "put" event replaces the data at Path, which is relative to the
+// watched path, and a "patch" event updates the children of Path listed in Data.
+type Event struct {
+	Type string
+	Path string
+	Data json.RawMessage
+}
+
+// Watch subscribes to the changes of path, such as "/topstories.json" or "/item/8863.json", using the server-sent
+// events the API offers. The first event is always a put with the current data. If the stream drops, Watch reconnects
+// after the Client's backoff. The channel is closed once ctx is done or the API cancels the subscription.
+//
+// Watch returns an error if the first connection fails. The Client's timeout does not apply to the stream, but any
+// timeout on the *http.Client does, so use an *http.Client without one.
+func (c *Client) Watch(ctx context.Context, path string) (<-chan Event, error) {
+	c.defaultify()
+
+	resp, err := c.stream(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make(chan Event)
+
+	go func() {
+		defer close(events)
+
+		for attempt := 0; ; {
+			if resp != nil {
+				cancelled := readEvents(ctx, resp, events)
+				resp.Body.Close()
+				if cancelled {
+					return
+				}
+				attempt = 0
+			}
+
+			wait := time.NewTimer(c.backoff << attempt)
+			select {
+			case <-ctx.Done():
+				wait.Stop()
+				return
+			case <-wait.C:
+			}
+
+			if attempt < 6 {
+				attempt++
+			}
+			resp, _ = c.stream(ctx, path)
+		}
+	}()
+
+	return events, nil
+}
+
+func (c *Client) stream(ctx context.Context, path string) (*http.Response, error) {
+	url := c.apiBase + path
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", "text/event-stream")
+	if c.userAgent != "" {
+		req.Header.Set("User-Agent", c.userAgent)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		resp.Body.Close()
+		return nil, &StatusError{StatusCode: resp.StatusCode, URL: url}
+	}
+
+	return resp, nil
+}
+
+// readEvents sends the events in resp to events until the stream ends. It returns true if the watch is over, because
+// ctx is done or the API cancelled it, rather than because the connection dropped.
+func readEvents(ctx context.Context, resp *http.Response, events chan<- Event) bool {
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+
+	var name string
+	var data []string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		if line != "" {
+			field, value := line, ""
+			if i := strings.Index(line, ":"); i >= 0 {
+				field, value = line[:i], strings.TrimPrefix(line[i+1:], " ")
+			}
+
+			switch field {
+			case "event":
+				name = value
+			case "data":
+				data = append(data, value)
+			}
+			continue
+		}
+
+		event, body := name, strings.Join(data, "\n")
+		name, data = "", nil
+
+		switch event {
+		case "put", "patch":
+			var payload struct {
+				Path string          `json:"path"`
+				Data json.RawMessage `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(body), &payload); err != nil {
+				continue
+			}
+
+			select {
+			case events <- Event{Type: event, Path: payload.Path, Data: payload.Data}:
+			case <-ctx.Done():
+				return true
+			}
+		case "cancel", "auth_revoked":
+			return true
+		}
+	}
+
+	return ctx.Err() != nil
+}
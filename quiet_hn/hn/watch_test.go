@@ -0,0 +1,149 @@
+package hn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// sseServer streams the events written by stream to every connection, numbering the connections from 1.
+func sseServer(t *testing.T, stream func(w http.ResponseWriter, conn int32)) *httptest.Server {
+	var conns int32
+
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if accept := r.Header.Get("Accept"); accept != "text/event-stream" {
+			t.Errorf("Accept: want %s, got %s", "text/event-stream", accept)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		stream(w, atomic.AddInt32(&conns, 1))
+		w.(http.Flusher).Flush()
+	}))
+}
+
+func next(t *testing.T, events <-chan Event) Event {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatalf("expected an event, the channel was closed")
+		}
+		return event
+	case <-time.After(time.Second):
+		t.Fatalf("expected an event, got nothing")
+	}
+
+	return Event{}
+}
+
+func closed(t *testing.T, events <-chan Event) {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if ok {
+			t.Fatalf("expected the channel to be closed, got %+v", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("expected the channel to be closed")
+	}
+}
+
+func TestClient_Watch(t *testing.T) {
+	server := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		fmt.Fprint(w, "event: put\ndata: {\"path\":\"/\",\"data\":[1,2,3]}\n\n")
+		fmt.Fprint(w, "event: keep-alive\ndata: null\n\n")
+		fmt.Fprint(w, ": a comment\nevent: patch\ndata: {\"path\":\"/\",\ndata: \"data\":{\"0\":4}}\n\n")
+		fmt.Fprint(w, "event: cancel\ndata: null\n\n")
+	})
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL))
+	events, err := c.Watch(context.Background(), "/topstories.json")
+	if err != nil {
+		t.Fatalf("client.Watch() received an error: %s", err.Error())
+	}
+
+	put := next(t, events)
+	if put.Type != "put" || put.Path != "/" || string(put.Data) != "[1,2,3]" {
+		t.Errorf("put: want {put / [1,2,3]}, got {%s %s %s}", put.Type, put.Path, put.Data)
+	}
+
+	patch := next(t, events)
+	if patch.Type != "patch" || string(patch.Data) != "{\"0\":4}" {
+		t.Errorf("patch: want {patch / {\"0\":4}}, got {%s %s %s}", patch.Type, patch.Path, patch.Data)
+	}
+
+	closed(t, events)
+}
+
+func TestClient_Watch_reconnects(t *testing.T) {
+	server := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		fmt.Fprintf(w, "event: put\ndata: {\"path\":\"/\",\"data\":%d}\n\n", conn)
+	})
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := NewClient(WithBaseURL(server.URL), WithRetries(0, time.Millisecond))
+	events, err := c.Watch(ctx, "/maxitem.json")
+	if err != nil {
+		t.Fatalf("client.Watch() received an error: %s", err.Error())
+	}
+
+	for _, want := range []string{"1", "2", "3"} {
+		if event := next(t, events); string(event.Data) != want {
+			t.Errorf("event.Data: want %s, got %s", want, event.Data)
+		}
+	}
+
+	cancel()
+
+	// Drain whatever was sent before the cancellation was noticed.
+	for range events {
+	}
+}
+
+func TestClient_Watch_statusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "permission denied", http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	c := NewClient(WithBaseURL(server.URL))
+	_, err := c.Watch(context.Background(), "/topstories.json")
+
+	var se *StatusError
+	if !errors.As(err, &se) || se.StatusCode != http.StatusUnauthorized {
+		t.Errorf("expected a 401 *StatusError, got %v", err)
+	}
+}
+
+func TestClient_Watch_cancelled(t *testing.T) {
+	done := make(chan struct{})
+	server := sseServer(t, func(w http.ResponseWriter, conn int32) {
+		fmt.Fprint(w, "event: put\ndata: {\"path\":\"/\",\"data\":null}\n\n")
+		w.(http.Flusher).Flush()
+		<-done
+	})
+	defer server.Close()
+	defer close(done)
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	c := NewClient(WithBaseURL(server.URL))
+	events, err := c.Watch(ctx, "/item/1.json")
+	if err != nil {
+		t.Fatalf("client.Watch() received an error: %s", err.Error())
+	}
+
+	next(t, events)
+	cancel()
+	closed(t, events)
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type itemData struct {
+	Story *hn.Thread
+	Host  string
+	Time  time.Duration
+}
+
+// itemFuncs are the template functions item.html uses to render comments.
+var itemFuncs = template.FuncMap{
+	"sanitize": func(text string) template.HTML {
+		return template.HTML(sanitize(text))
+	},
+	"ago": ago,
+}
+
+// ago describes how long ago a unix time was, the way HN does.
+func ago(unix int) string {
+	d := time.Since(time.Unix(int64(unix), 0))
+
+	switch {
+	case d < time.Minute:
+		return "just now"
+	case d < time.Hour:
+		return plural(int(d/time.Minute), "minute") + " ago"
+	case d < 24*time.Hour:
+		return plural(int(d/time.Hour), "hour") + " ago"
+	default:
+		return plural(int(d/(24*time.Hour)), "day") + " ago"
+	}
+}
+
+func plural(n int, unit string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, unit)
+	}
+
+	return fmt.Sprintf("%d %ss", n, unit)
+}
+
+// itemHandler renders /item/{id} with its threaded comments, fetching up to depth levels of replies.
+func itemHandler(client *hn.Client, depth int, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/item/"))
+		if err != nil || id <= 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		thread, err := client.GetThread(ctx, id, depth)
+		if err != nil {
+			var ne *hn.NullItemError
+			if errors.As(err, &ne) {
+				http.NotFound(w, r)
+				return
+			}
+
+			http.Error(w, "Failed to load the item", http.StatusBadGateway)
+			return
+		}
+
+		data := itemData{
+			Story: thread,
+			Host:  parseHNItem(thread.Item).Host,
+			Time:  time.Now().Sub(start),
+		}
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// liveUpdate is a change to a single story pushed to browsers.
+type liveUpdate struct {
+	ID          int `json:"id"`
+	Rank        int `json:"rank,omitempty"`
+	Score       int `json:"score,omitempty"`
+	Descendants int `json:"descendants,omitempty"`
+}
+
+// live follows the top stories through the streaming API and pushes their rank and score changes to browsers with
+// server-sent events.
+type live struct {
+	ctx     context.Context
+	client  *hn.Client
+	cache   hn.Cache
+	tracked int
+
+	mutex   sync.Mutex
+	ids     []int
+	ranks   map[int]int
+	clients map[chan []byte]struct{}
+}
+
+// newLive returns a live that reports changes for the first tracked top stories until ctx is done. Changed items are
+// dropped from cache before they are fetched again.
+func newLive(ctx context.Context, client *hn.Client, cache hn.Cache, tracked int) *live {
+	return &live{
+		ctx:     ctx,
+		client:  client,
+		cache:   cache,
+		tracked: tracked,
+		ranks:   make(map[int]int),
+		clients: make(map[chan []byte]struct{}),
+	}
+}
+
+// run follows the top stories and the updates feed until l.ctx is done.
+func (l *live) run() {
+	go l.watch(l.ctx, "/topstories.json", l.topStories)
+	l.watch(l.ctx, "/updates.json", func(event hn.Event) {
+		l.updates(l.ctx, event)
+	})
+}
+
+// watch passes the events of path to handle, subscribing again a minute after the stream can't be resumed.
+func (l *live) watch(ctx context.Context, path string, handle func(hn.Event)) {
+	for {
+		events, err := l.client.Watch(ctx, path)
+		if err != nil {
+			log.Printf("failed to watch %s: %v", path, err)
+		} else {
+			for event := range events {
+				handle(event)
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Minute):
+		}
+	}
+}
+
+// topStories applies a change to the top stories list and broadcasts the stories whose rank changed.
+func (l *live) topStories(event hn.Event) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	switch {
+	case event.Path == "/" && event.Type == "put":
+		var ids []int
+		if err := json.Unmarshal(event.Data, &ids); err != nil {
+			return
+		}
+		l.ids = ids
+	case event.Path == "/" && event.Type == "patch":
+		var changes map[string]int
+		if err := json.Unmarshal(event.Data, &changes); err != nil {
+			return
+		}
+		for index, id := range changes {
+			l.set(index, id)
+		}
+	case event.Type == "put":
+		var id int
+		if err := json.Unmarshal(event.Data, &id); err != nil {
+			return
+		}
+		l.set(strings.TrimPrefix(event.Path, "/"), id)
+	}
+
+	ranks := make(map[int]int)
+	var changed []liveUpdate
+
+	for i, id := range l.ids {
+		if i >= l.tracked {
+			break
+		}
+
+		ranks[id] = i + 1
+		if l.ranks[id] != i+1 {
+			changed = append(changed, liveUpdate{ID: id, Rank: i + 1})
+		}
+	}
+
+	l.ranks = ranks
+
+	if len(changed) > 0 {
+		l.broadcast("rank", changed)
+	}
+}
+
+// set puts id at the position of the top stories list named by index.
+func (l *live) set(index string, id int) {
+	i, err := strconv.Atoi(index)
+	if err != nil || i < 0 {
+		return
+	}
+
+	for len(l.ids) <= i {
+		l.ids = append(l.ids, 0)
+	}
+
+	l.ids[i] = id
+}
+
+// updates refetches the tracked stories the updates feed reports as changed and broadcasts their scores.
+func (l *live) updates(ctx context.Context, event hn.Event) {
+	var updates hn.Updates
+
+	switch event.Path {
+	case "/":
+		if err := json.Unmarshal(event.Data, &updates); err != nil {
+			return
+		}
+	case "/items":
+		if err := json.Unmarshal(event.Data, &updates.Items); err != nil {
+			return
+		}
+	default:
+		return
+	}
+
+	var ids []int
+
+	l.mutex.Lock()
+	for _, id := range updates.Items {
+		l.cache.Delete(id)
+		if _, ok := l.ranks[id]; ok {
+			ids = append(ids, id)
+		}
+	}
+	l.mutex.Unlock()
+
+	if len(ids) == 0 {
+		return
+	}
+
+	var changed []liveUpdate
+
+	for _, r := range l.client.GetItems(ctx, ids, hn.BatchOptions{}) {
+		if r.Err != nil {
+			continue
+		}
+
+		changed = append(changed, liveUpdate{ID: r.Item.ID, Score: r.Item.Score, Descendants: r.Item.Descendants})
+	}
+
+	if len(changed) > 0 {
+		l.mutex.Lock()
+		l.broadcast("score", changed)
+		l.mutex.Unlock()
+	}
+}
+
+// broadcast sends an event to every browser. Browsers that fall behind miss it rather than holding up the others. The
+// caller must hold l.mutex.
+func (l *live) broadcast(event string, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "event: %s\ndata: %s\n\n", event, data)
+
+	for ch := range l.clients {
+		select {
+		case ch <- msg.Bytes():
+		default:
+		}
+	}
+}
+
+// ServeHTTP streams the live updates to a browser until it disconnects or l.ctx is done.
+func (l *live) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	ch := make(chan []byte, 16)
+
+	l.mutex.Lock()
+	l.clients[ch] = struct{}{}
+	l.mutex.Unlock()
+
+	defer func() {
+		l.mutex.Lock()
+		delete(l.clients, ch)
+		l.mutex.Unlock()
+	}()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-l.ctx.Done():
+			return
+		case msg := <-ch:
+			if _, err := w.Write(msg); err != nil {
+				return
+			}
+			flusher.Flush()
+		}
+	}
+}
@@ -1,46 +1,77 @@
 package main
 
 import (
-	"errors"
+	"context"
 	"flag"
 	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/enrich"
+	"github.com/jwambugu/gophercises/quiet_hn/history"
 	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"github.com/jwambugu/gophercises/quiet_hn/prefs"
+	"github.com/jwambugu/gophercises/quiet_hn/search"
 	"html/template"
 	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
 	"path/filepath"
 	"runtime"
-	"sort"
 	"strings"
-	"sync"
+	"syscall"
 	"time"
 )
 
-var (
-	cache           []item
-	cacheExpiration time.Time
-	cacheMutex      sync.Mutex
-)
-
 // item is the same as the hn.Item, but adds the Host field
 type item struct {
 	hn.Item
 	Host string
+	// Highlight is the name of the rule that highlights the story, if any.
+	Highlight string
+	// Meta is what the linked page says about itself, once it has been fetched.
+	Meta *enrich.Metadata
+	// Read and Saved are whether the reader visited and saved the story.
+	Read  bool
+	Saved bool
+}
+
+// filtered is a story hidden by a rule.
+type filtered struct {
+	item
+	Rule string
+}
+
+// feed is one of the HN story lists quiet_hn can serve.
+type feed struct {
+	Name string
+	Path string
+	ids  func(*hn.Client, context.Context) ([]int, error)
+	keep func(item) bool
 }
 
-type storyCache struct {
-	numStories int
-	cache      []item
-	useA       bool
-	expiration time.Time
-	duration   time.Duration
-	mutext     sync.Mutex
+var feeds = []feed{
+	{Name: "top", Path: "/", ids: (*hn.Client).TopItemsContext, keep: isStoryLink},
+	{Name: "new", Path: "/new", ids: (*hn.Client).NewStoriesContext, keep: isStoryLink},
+	{Name: "best", Path: "/best", ids: (*hn.Client).BestStoriesContext, keep: isStoryLink},
+	{Name: "ask", Path: "/ask", ids: (*hn.Client).AskStoriesContext, keep: isStory},
+	{Name: "show", Path: "/show", ids: (*hn.Client).ShowStoriesContext, keep: isStory},
+	{Name: "jobs", Path: "/jobs", ids: (*hn.Client).JobStoriesContext, keep: isJob},
 }
 
 type templateData struct {
+	Feed    feed
+	Feeds   []feed
 	Stories []item
-	Time    time.Duration
+	// Start is the rank of the first story on the page.
+	Start int
+	Prev  string
+	Next  string
+	// Personal is true when readers have their own preferences, Back is the page to return to after saving a story
+	// and Filtered is the number of stories their filters hid.
+	Personal bool
+	Back     string
+	Filtered int
+	Time     time.Duration
 }
 
 func getAbsolutePath() string {
@@ -53,6 +84,23 @@ func isStoryLink(item item) bool {
 	return item.Type == "story" && item.URL != ""
 }
 
+func isStory(item item) bool {
+	return item.Type == "story"
+}
+
+func isJob(item item) bool {
+	return item.Type == "job"
+}
+
+// Link returns the URL the item points to, or its discussion page if it is a text post.
+func (i item) Link() string {
+	if i.URL != "" {
+		return i.URL
+	}
+
+	return fmt.Sprintf("/item/%d", i.ID)
+}
+
 func parseHNItem(hnItem hn.Item) item {
 	ret := item{
 		Item: hnItem,
@@ -66,141 +114,157 @@ func parseHNItem(hnItem hn.Item) item {
 	return ret
 }
 
-func getStories(ids []int) []item {
-	type result struct {
-		index int
-		item  item
-		err   error
-	}
-
-	resultsCh := make(chan result)
-	defer close(resultsCh)
-
-	for i := 0; i < len(ids); i++ {
-		go func(index, id int) {
-			var client hn.Client
-
-			hnItem, err := client.GetItem(id)
-			if err != nil {
-				resultsCh <- result{
-					index: index,
-					err:   err,
-				}
-			}
-
-			resultsCh <- result{
-				index: index,
-				item:  parseHNItem(hnItem),
-			}
-		}(i, ids[i])
-	}
-
-	var results []result
-
-	for i := 0; i < len(ids); i++ {
-		results = append(results, <-resultsCh)
-	}
+// getStories fetches the items with the provided ids and keeps the ones that belong in the feed, in their original
+// order. Items that could not be fetched are skipped, and stories hidden by the rules are returned separately.
+func getStories(ctx context.Context, client *hn.Client, f feed, rules *ruleSet, ids []int, batch hn.BatchOptions) ([]item, []filtered) {
+	var stories []item
+	var hidden []filtered
 
-	// Sort the items by index asc
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].index < results[j].index
-	})
+	now := time.Now()
 
-	var stories []item
+	for _, r := range client.GetItems(ctx, ids, batch) {
+		if r.Err != nil {
+			continue
+		}
 
-	for _, r := range results {
-		if r.err != nil {
+		story := parseHNItem(r.Item)
+		if !f.keep(story) {
 			continue
 		}
 
-		if isStoryLink(r.item) {
-			stories = append(stories, r.item)
+		story, rule := rules.apply(story, now)
+		if rule != "" {
+			hidden = append(hidden, filtered{item: story, Rule: rule})
+			continue
 		}
+
+		stories = append(stories, story)
 	}
 
-	return stories
+	return stories, hidden
 }
 
-func getTopStories(numStories int) ([]item, error) {
-	var client hn.Client
-
-	ids, err := client.TopItems()
+func getTopStories(ctx context.Context, client *hn.Client, f feed, rules *ruleSet, numStories int, batch hn.BatchOptions) ([]item, []filtered, error) {
+	ids, err := f.ids(client, ctx)
 	if err != nil {
-		return nil, errors.New("failed to load top stories")
+		return nil, nil, fmt.Errorf("failed to load %s stories", f.Name)
 	}
 
 	var stories []item
+	var hidden []filtered
 	currentPosition := 0
 
-	for len(stories) < numStories {
+	// Smaller feeds, like jobs, can run out of ids before numStories are found.
+	for len(stories) < numStories && currentPosition < len(ids) && ctx.Err() == nil {
 		remainingStories := (numStories - len(stories)) * 5 / 4
+		if remainingStories < 1 {
+			remainingStories = 1
+		}
 
-		stories = append(stories, getStories(ids[currentPosition:currentPosition+remainingStories])...)
-		currentPosition += remainingStories
-	}
-
-	return stories[:numStories], nil
-}
+		end := currentPosition + remainingStories
+		if end > len(ids) {
+			end = len(ids)
+		}
 
-func (sc *storyCache) stories() ([]item, error) {
-	sc.mutext.Lock()
-	defer sc.mutext.Unlock()
+		more, moreHidden := getStories(ctx, client, f, rules, ids[currentPosition:end], batch)
+		stories = append(stories, more...)
+		hidden = append(hidden, moreHidden...)
+		currentPosition = end
+	}
 
-	if time.Now().Sub(sc.expiration) < 0 {
-		return sc.cache, nil
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
 	}
 
-	stories, err := getTopStories(sc.numStories)
-	if err != nil {
-		return nil, err
+	if len(stories) > numStories {
+		stories = stories[:numStories]
 	}
 
-	sc.expiration = time.Now().Add(5 * time.Minute)
-	sc.cache = stories
+	return stories, hidden, nil
+}
 
-	return sc.cache, nil
+// invalidate drops the items that changed on HN from the client's cache every interval, so story refreshes only
+// refetch those.
+func invalidate(client *hn.Client, interval time.Duration) {
+	for range time.Tick(interval) {
+		if _, err := client.Invalidate(context.Background()); err != nil {
+			log.Printf("failed to invalidate updated items: %v", err)
+		}
+	}
 }
 
-func handler(numStories int, tpl *template.Template) http.HandlerFunc {
-	sc := &storyCache{
-		numStories: numStories,
-		duration:   5 * time.Minute,
+// withMeta returns a copy of stories with the metadata of their pages attached. Pages that haven't been fetched yet
+// are queued and left without, the handler never waits for them.
+func withMeta(stories []item, enricher *enrich.Enricher) []item {
+	if enricher == nil {
+		return stories
 	}
 
-	go func() {
-		ticker := time.NewTicker(4 * time.Minute)
-		for {
-			temp := &storyCache{
-				numStories: numStories,
-				duration:   sc.duration * 2,
-			}
+	ret := make([]item, len(stories))
 
-			_, _ = temp.stories()
+	for i, story := range stories {
+		ret[i] = story
 
-			sc.mutext.Lock()
-			sc.cache = temp.cache
-			sc.expiration = temp.expiration
-			sc.mutext.Unlock()
+		if story.URL == "" {
+			continue
+		}
 
-			//fmt.Printf("[*] cache has %d stories == %+v\n", len(sc.cache), sc.duration)
-			<-ticker.C
+		if meta, ok := enricher.Get(story.URL); ok {
+			ret[i].Meta = &meta
 		}
-	}()
+	}
 
+	return ret
+}
+
+func handler(caches map[string]*pages, f feed, u *users, enricher *enrich.Enricher, tpl *template.Template) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
 
-		stories, err := sc.stories()
+		p := caches[f.Name]
+		reader := u.get(r)
+
+		// The front page shows the feed the reader picked.
+		if f.Path == "/" && reader.Feed != "" && caches[reader.Feed] != nil {
+			p = caches[reader.Feed]
+		}
+
+		sc, key, ok := p.pageFor(w, r)
+		if !ok {
+			return
+		}
+
+		stories, _, err := sc.stories()
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 
 		data := templateData{
-			Stories: stories,
-			Time:    time.Now().Sub(start),
+			Feed:     p.feed,
+			Feeds:    feeds,
+			Start:    (key.page-1)*key.n + 1,
+			Personal: u != nil,
+			Back:     r.URL.RequestURI(),
+		}
+
+		personal := stories
+		if u != nil {
+			personal, data.Filtered = personalize(stories, reader)
 		}
 
+		data.Stories = withMeta(personal, enricher)
+
+		if key.page > 1 {
+			data.Prev = pageLink(p.feed, pageKey{page: key.page - 1, n: key.n}, p.front.n)
+		}
+
+		if len(stories) == key.n && key.page*key.n < maxRank {
+			data.Next = pageLink(p.feed, pageKey{page: key.page + 1, n: key.n}, p.front.n)
+		}
+
+		data.Time = time.Now().Sub(start)
+
 		if err := tpl.Execute(w, data); err != nil {
 			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
 			return
@@ -210,18 +274,283 @@ func handler(numStories int, tpl *template.Template) http.HandlerFunc {
 
 func main() {
 	// parse flags
-	var port, numStories int
+	var port, numStories, retries, workers, cacheSize int
+	var timeout, cacheTTL time.Duration
+	var cacheDir string
+	var liveUpdates bool
+	var liveStories int
+	var threadDepth, threadSize int
+	var rulesPath string
+	var ttl, refresh time.Duration
+	var apiBase string
+	var enrichStories bool
+	var enrichTimeout time.Duration
+	var historyPath string
+	var historyInterval, historyRetention time.Duration
+	var historyStories int
+	var prefsDir string
+	var indexPath string
+	var digestPath string
+	var digestNow bool
+	var trustProxy bool
 
 	flag.IntVar(&port, "port", 3000, "the port to start the web server on")
+	flag.StringVar(&apiBase, "api", "https://hacker-news.firebaseio.com/v0", "the HN API to use, such as a local cmd/hn-mock")
 	flag.IntVar(&numStories, "num_stories", 30, "the number of top stories to display")
+	flag.DurationVar(&ttl, "ttl", 5*time.Minute, "how long stories are served before they are refreshed on the next request")
+	flag.DurationVar(&refresh, "refresh", 4*time.Minute, "how often stories are refreshed in the background")
+	flag.DurationVar(&timeout, "timeout", 10*time.Second, "how long a single request to the HN API may take")
+	flag.IntVar(&retries, "retries", 2, "how many times to retry a failed request to the HN API")
+	flag.IntVar(&workers, "workers", 10, "the most stories to fetch from the HN API at the same time")
+	flag.IntVar(&cacheSize, "cache_size", 1000, "the number of items to keep in the in-memory item cache")
+	flag.DurationVar(&cacheTTL, "cache_ttl", 30*time.Minute, "how long an unchanged item stays cached")
+	flag.StringVar(&cacheDir, "cache_dir", "", "keep the item cache in this directory instead of in memory")
+	flag.BoolVar(&liveUpdates, "live", true, "push rank and score changes to browsers as they happen on HN")
+	flag.IntVar(&liveStories, "live_stories", 100, "the number of top stories to push live changes for")
+	flag.IntVar(&threadDepth, "thread_depth", 8, "the number of levels of comments to show on an item page")
+	flag.IntVar(&threadSize, "thread_size", 500, "the most comments to show on an item page")
+	flag.StringVar(&rulesPath, "rules", "", "a JSON file of rules to hide or highlight stories with, see rules.example.json")
+	flag.BoolVar(&enrichStories, "enrich", false, "fetch the stories' pages in the background to show their summaries")
+	flag.DurationVar(&enrichTimeout, "enrich_timeout", 5*time.Second, "how long fetching a story's page may take")
+	flag.StringVar(&historyPath, "history", "", "record the top stories' ranks in this file to show how they trend")
+	flag.DurationVar(&historyInterval, "history_interval", 5*time.Minute, "how often the top stories' ranks are recorded")
+	flag.DurationVar(&historyRetention, "history_retention", 30*24*time.Hour, "how long recorded ranks are kept, 0 keeps them forever")
+	flag.IntVar(&historyStories, "history_stories", 100, "the number of top stories to record the ranks of")
+	flag.StringVar(&prefsDir, "prefs_dir", "", "keep each reader's filters, read and saved stories and feed in this directory")
+	flag.StringVar(&indexPath, "index", "", "index every fetched item for /search, and keep the index in this file")
+	flag.StringVar(&digestPath, "digest", "", "send digests of the top stories as set up in this file, see digest.example.json")
+	flag.BoolVar(&digestNow, "digest_now", false, "send a digest as soon as the server starts, as well as on schedule")
+	flag.BoolVar(&trustProxy, "trust_proxy", false, "use the scheme in the X-Forwarded-Proto header of a reverse proxy for links in feeds")
 	flag.Parse()
 
+	var rules *ruleSet
+
+	if rulesPath != "" {
+		var err error
+		if rules, err = loadRules(rulesPath); err != nil {
+			log.Fatalf("failed to load %s: %v", rulesPath, err)
+		}
+	}
+
+	var cache hn.Cache = hn.NewMemoryCache(cacheSize, cacheTTL)
+
+	if cacheDir != "" {
+		diskCache, err := hn.NewDiskCache(cacheDir, cacheTTL)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		cache = diskCache
+	}
+
+	var index *search.Index
+
+	if indexPath != "" {
+		var err error
+		if index, err = search.Load(indexPath); err != nil {
+			log.Fatalf("failed to load %s: %v", indexPath, err)
+		}
+
+		cache = indexingCache{Cache: cache, index: index}
+	}
+
+	client := hn.NewClient(
+		hn.WithBaseURL(apiBase),
+		hn.WithUserAgent("quiet_hn (+https://gophercises.com/exercises/quiet_hn)"),
+		hn.WithTimeout(timeout),
+		hn.WithRetries(retries, 200*time.Millisecond),
+		hn.WithCache(cache),
+		hn.WithMaxThreadSize(threadSize),
+	)
+
+	go invalidate(client, time.Minute)
+
 	indexPage := fmt.Sprintf("%s/index.html", getAbsolutePath())
 	tpl := template.Must(template.ParseFiles(indexPage))
 
-	http.HandleFunc("/", handler(numStories, tpl))
+	itemPage := fmt.Sprintf("%s/item.html", getAbsolutePath())
+	itemTpl := template.Must(template.New("item.html").Funcs(itemFuncs).ParseFiles(itemPage))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var enricher *enrich.Enricher
+
+	if enrichStories {
+		enricher = enrich.NewEnricher(&enrich.Fetcher{
+			MaxBytes:  512 * 1024,
+			Timeout:   enrichTimeout,
+			UserAgent: "quiet_hn (+https://gophercises.com/exercises/quiet_hn)",
+		}, enrich.Options{})
+	}
+
+	var u *users
+
+	if prefsDir != "" {
+		prefsStore, err := prefs.NewStore(prefsDir)
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		key, err := prefs.LoadKey(filepath.Join(prefsDir, "cookie.key"))
+		if err != nil {
+			log.Fatalf("failed to load the cookie key: %v", err)
+		}
+
+		u = &users{store: prefsStore, signer: prefs.NewSigner(key)}
+	}
+
+	caches := make(map[string]*pages)
+
+	for _, f := range feeds {
+		p := newPages(f, storyCacheOptions{
+			client:     client,
+			batch:      hn.BatchOptions{Workers: workers},
+			rules:      rules,
+			numStories: numStories,
+			ttl:        ttl,
+			refresh:    refresh,
+		})
+		caches[f.Name] = p
+
+		if rules != nil {
+			rules.onReload = append(rules.onReload, p.expire)
+		}
+	}
+
+	for _, f := range feeds {
+		http.HandleFunc(f.Path, handler(caches, f, u, enricher, tpl))
+	}
+
+	if u != nil {
+		savedPage := fmt.Sprintf("%s/saved.html", getAbsolutePath())
+		savedTpl := template.Must(template.ParseFiles(savedPage))
+
+		prefsPage := fmt.Sprintf("%s/prefs.html", getAbsolutePath())
+		prefsTpl := template.Must(template.ParseFiles(prefsPage))
+
+		http.HandleFunc("/read/", readHandler(client, u))
+		http.HandleFunc("/save/", saveHandler(u))
+		http.HandleFunc("/saved", savedHandler(client, u, hn.BatchOptions{Workers: workers}, savedTpl))
+		http.HandleFunc("/prefs", prefsHandler(u, prefsTpl))
+	}
+
+	if rules != nil {
+		go rules.watch(2 * time.Second)
+	}
+
+	filteredPage := fmt.Sprintf("%s/filtered.html", getAbsolutePath())
+	filteredTpl := template.Must(template.ParseFiles(filteredPage))
+
+	http.HandleFunc("/filtered", filteredHandler(caches, rules, filteredTpl))
+
+	http.HandleFunc("/api/stories", feedHandler(caches, jsonFormat, trustProxy))
+	http.HandleFunc("/rss", feedHandler(caches, rssFormat, trustProxy))
+	http.HandleFunc("/atom", feedHandler(caches, atomFormat, trustProxy))
+
+	http.HandleFunc("/item/", itemHandler(client, threadDepth, itemTpl))
+
+	var store *history.Store
+	recorded := make(chan struct{})
+
+	if historyPath != "" {
+		var err error
+		if store, err = history.Open(historyPath, historyRetention, historyInterval); err != nil {
+			log.Fatalf("failed to open %s: %v", historyPath, err)
+		}
+
+		go func() {
+			defer close(recorded)
+			record(ctx, client, store, historyStories, historyInterval, hn.BatchOptions{Workers: workers})
+		}()
+	}
+
+	if digestPath != "" {
+		job, err := loadDigest(digestPath)
+		if err != nil {
+			log.Fatalf("failed to load %s: %v", digestPath, err)
+		}
+
+		job.Source = digestSource(client, rules, store, hn.BatchOptions{Workers: workers})
+
+		if digestNow {
+			go func() {
+				if err := job.Run(ctx, time.Now()); err != nil {
+					log.Printf("failed to send the %s digest: %v", job.Name, err)
+				}
+			}()
+		}
+
+		go job.Start(ctx)
+	}
+
+	if index != nil {
+		go saveIndex(ctx, index, indexPath, time.Minute)
+	}
+
+	searchPage := fmt.Sprintf("%s/search.html", getAbsolutePath())
+	searchTpl := template.Must(template.ParseFiles(searchPage))
+
+	http.HandleFunc("/search", searchHandler(index, searchTpl))
+
+	trendsPage := fmt.Sprintf("%s/trends.html", getAbsolutePath())
+	trendsTpl := template.Must(template.New("trends.html").Funcs(trendFuncs).ParseFiles(trendsPage))
+
+	historyPage := fmt.Sprintf("%s/history.html", getAbsolutePath())
+	historyTpl := template.Must(template.ParseFiles(historyPage))
+
+	http.HandleFunc("/trends", trendsHandler(store, trendsTpl))
+	http.HandleFunc("/history/", historyHandler(store, historyTpl))
+
+	if liveUpdates {
+		l := newLive(ctx, client, cache, liveStories)
+		go l.run()
+
+		http.Handle("/live", l)
+	}
+
+	srv := &http.Server{Addr: fmt.Sprintf(":%d", port)}
+
+	// Stop streaming live updates first, those connections never finish on their own.
+	srv.RegisterOnShutdown(cancel)
+
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+		<-signals
+
+		log.Printf("Shutting down")
+
+		shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancelShutdown()
+
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("failed to shut down gracefully: %v", err)
+		}
+	}()
 
 	// Start the server
 	log.Printf("Server running on port :%d", port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", port), nil))
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+
+	for _, p := range caches {
+		p.Close()
+	}
+
+	if enricher != nil {
+		enricher.Close()
+	}
+
+	if store != nil {
+		<-recorded
+		store.Close()
+	}
+
+	if index != nil {
+		if err := index.Save(indexPath); err != nil {
+			log.Printf("failed to save the search index: %v", err)
+		}
+	}
 }
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// format is a machine readable rendering of a feed's stories.
+type format struct {
+	contentType string
+	// write renders the stories, using base to make links absolute.
+	write func(w io.Writer, f feed, stories []item, updated time.Time, base string) error
+}
+
+var (
+	jsonFormat = format{contentType: "application/json; charset=utf-8", write: writeJSON}
+	rssFormat  = format{contentType: "application/rss+xml; charset=utf-8", write: writeRSS}
+	atomFormat = format{contentType: "application/atom+xml; charset=utf-8", write: writeAtom}
+)
+
+// feedHandler serves the cached stories of the feed named by the feed query parameter, top by default, in the
+// provided format. The page and n parameters work like they do for the HTML pages. Conditional requests are answered
+// with 304 when the stories haven't changed. The scheme in X-Forwarded-Proto is only used for links when trustProxy is
+// set, as any client can send it.
+func feedHandler(caches map[string]*pages, fm format, trustProxy bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("feed")
+		if name == "" {
+			name = "top"
+		}
+
+		p, ok := caches[name]
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		sc, _, ok := p.pageFor(w, r)
+		if !ok {
+			return
+		}
+
+		stories, updated, err := sc.stories()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		base := baseURL(r, trustProxy)
+
+		var body bytes.Buffer
+		if err := fm.write(&body, sc.feed, stories, updated, base); err != nil {
+			http.Error(w, "Failed to render the feed", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", fm.contentType)
+		w.Header().Set("ETag", etag(fm, sc.feed, stories, base))
+		w.Header().Set("Cache-Control", "public, max-age=60")
+
+		// ServeContent takes care of If-None-Match, If-Modified-Since and HEAD requests.
+		http.ServeContent(w, r, "", updated, bytes.NewReader(body.Bytes()))
+	}
+}
+
+// etag identifies what a feed shows of its stories. The time they were fetched at is left out, so the ETag only
+// changes when a refresh changed the stories. That makes it a weak ETag, as the bodies it stands for differ in their
+// updated time.
+func etag(fm format, f feed, stories []item, base string) string {
+	h := sha1.New()
+	fmt.Fprintf(h, "%s\n%s\n%s\n", fm.contentType, f.Name, base)
+
+	for _, story := range stories {
+		fmt.Fprintf(h, "%d\n%s\n%s\n%s\n%d\n%d\n%d\n", story.ID, story.Title, story.Link(), story.By, story.Score,
+			story.Descendants, story.Time)
+	}
+
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:8]) + `"`
+}
+
+// baseURL returns the scheme and host the request was made to. With trustProxy set, the scheme a reverse proxy
+// received the request with is taken from X-Forwarded-Proto.
+func baseURL(r *http.Request, trustProxy bool) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	if proto := r.Header.Get("X-Forwarded-Proto"); trustProxy && (proto == "http" || proto == "https") {
+		scheme = proto
+	}
+
+	return scheme + "://" + r.Host
+}
+
+// absolute makes a link returned by item.Link absolute.
+func absolute(link, base string) string {
+	if strings.HasPrefix(link, "/") {
+		return base + link
+	}
+
+	return link
+}
+
+func discussion(id int) string {
+	return fmt.Sprintf("https://news.ycombinator.com/item?id=%d", id)
+}
+
+type jsonStory struct {
+	ID          int       `json:"id"`
+	Title       string    `json:"title"`
+	URL         string    `json:"url"`
+	Host        string    `json:"host,omitempty"`
+	By          string    `json:"by"`
+	Score       int       `json:"score"`
+	Descendants int       `json:"descendants"`
+	Time        time.Time `json:"time"`
+	Discussion  string    `json:"discussion"`
+}
+
+func writeJSON(w io.Writer, f feed, stories []item, updated time.Time, base string) error {
+	data := struct {
+		Feed    string      `json:"feed"`
+		Updated time.Time   `json:"updated"`
+		Stories []jsonStory `json:"stories"`
+	}{
+		Feed:    f.Name,
+		Updated: updated.UTC(),
+		Stories: make([]jsonStory, len(stories)),
+	}
+
+	for i, story := range stories {
+		data.Stories[i] = jsonStory{
+			ID:          story.ID,
+			Title:       story.Title,
+			URL:         absolute(story.Link(), base),
+			Host:        story.Host,
+			By:          story.By,
+			Score:       story.Score,
+			Descendants: story.Descendants,
+			Time:        time.Unix(int64(story.Time), 0).UTC(),
+			Discussion:  discussion(story.ID),
+		}
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(data)
+}
+
+type rssItem struct {
+	Title    string `xml:"title"`
+	Link     string `xml:"link"`
+	Comments string `xml:"comments"`
+	GUID     string `xml:"guid"`
+	PubDate  string `xml:"pubDate"`
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+func writeRSS(w io.Writer, f feed, stories []item, updated time.Time, base string) error {
+	doc := rss{Version: "2.0"}
+	doc.Channel.Title = "Quiet Hacker News: " + f.Name
+	doc.Channel.Link = base + f.Path
+	doc.Channel.Description = fmt.Sprintf("The %s stories on Hacker News, without the noise.", f.Name)
+	doc.Channel.LastBuildDate = updated.UTC().Format(time.RFC1123Z)
+
+	for _, story := range stories {
+		doc.Channel.Items = append(doc.Channel.Items, rssItem{
+			Title:    story.Title,
+			Link:     absolute(story.Link(), base),
+			Comments: discussion(story.ID),
+			GUID:     discussion(story.ID),
+			PubDate:  time.Unix(int64(story.Time), 0).UTC().Format(time.RFC1123Z),
+		})
+	}
+
+	return writeXML(w, doc)
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+}
+
+type atomEntry struct {
+	Title   string     `xml:"title"`
+	ID      string     `xml:"id"`
+	Links   []atomLink `xml:"link"`
+	Updated string     `xml:"updated"`
+	Author  struct {
+		Name string `xml:"name"`
+	} `xml:"author"`
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Links   []atomLink  `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+func writeAtom(w io.Writer, f feed, stories []item, updated time.Time, base string) error {
+	doc := atom{
+		Title: "Quiet Hacker News: " + f.Name,
+		ID:    base + f.Path,
+		Links: []atomLink{
+			{Href: base + "/atom?feed=" + f.Name, Rel: "self"},
+			{Href: base + f.Path},
+		},
+		Updated: updated.UTC().Format(time.RFC3339),
+	}
+
+	for _, story := range stories {
+		entry := atomEntry{
+			Title: story.Title,
+			ID:    discussion(story.ID),
+			Links: []atomLink{
+				{Href: absolute(story.Link(), base)},
+				{Href: discussion(story.ID), Rel: "replies"},
+			},
+			Updated: time.Unix(int64(story.Time), 0).UTC().Format(time.RFC3339),
+		}
+		entry.Author.Name = story.By
+
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return writeXML(w, doc)
+}
+
+func writeXML(w io.Writer, v interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+
+	return enc.Encode(v)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"github.com/jwambugu/gophercises/quiet_hn/hn/hntest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// serveFeed serves a request for a feed, setting the headers provided.
+func serveFeed(h http.HandlerFunc, target string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, target, nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+
+	w := httptest.NewRecorder()
+	h(w, r)
+
+	return w
+}
+
+func TestFeedHandler_conditional(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	p := newPages(feeds[0], testCacheOptions(api.client()))
+	defer p.Close()
+
+	h := feedHandler(map[string]*pages{"top": p}, jsonFormat, false)
+
+	w := serveFeed(h, "/api/stories", nil)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status: want %d, got %d", http.StatusOK, w.Code)
+	}
+
+	etag, modified := w.Header().Get("ETag"), w.Header().Get("Last-Modified")
+	if etag == "" || modified == "" {
+		t.Fatalf("expected an ETag and a Last-Modified header, got %q and %q", etag, modified)
+	}
+
+	tests := []struct {
+		name   string
+		header map[string]string
+		want   int
+	}{
+		{name: "matching ETag", header: map[string]string{"If-None-Match": etag}, want: http.StatusNotModified},
+		{name: "other ETag", header: map[string]string{"If-None-Match": `W/"0000000000000000"`}, want: http.StatusOK},
+		{name: "not modified since", header: map[string]string{"If-Modified-Since": modified}, want: http.StatusNotModified},
+		{name: "modified since", header: map[string]string{"If-Modified-Since": "Mon, 01 Jan 2001 00:00:00 GMT"}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := serveFeed(h, "/api/stories", tt.header).Code; got != tt.want {
+				t.Errorf("status: want %d, got %d", tt.want, got)
+			}
+		})
+	}
+
+	// A refresh that changed nothing keeps the ETag, even though the stories were fetched again.
+	if sc := p.first(); sc.wait(sc.refresh()) != nil {
+		t.Fatalf("expected the refresh to succeed")
+	}
+
+	w = serveFeed(h, "/api/stories", map[string]string{"If-None-Match": etag})
+	if w.Code != http.StatusNotModified {
+		t.Errorf("status after a refresh: want %d, got %d", http.StatusNotModified, w.Code)
+	}
+
+	// Other pages and formats of the same stories are different representations.
+	if got := serveFeed(h, "/api/stories?n=2", nil).Header().Get("ETag"); got == etag {
+		t.Errorf("expected another page to have another ETag")
+	}
+	rss := feedHandler(map[string]*pages{"top": p}, rssFormat, false)
+	if got := serveFeed(rss, "/rss", nil).Header().Get("ETag"); got == etag {
+		t.Errorf("expected another format to have another ETag")
+	}
+}
+
+func TestFeedHandler_forwardedProto(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	p := newPages(feeds[0], testCacheOptions(api.client()))
+	defer p.Close()
+
+	caches := map[string]*pages{"top": p}
+
+	tests := []struct {
+		name       string
+		trustProxy bool
+		proto      string
+		want       string
+	}{
+		{name: "untrusted", proto: "https", want: "<link>http://example.com/</link>"},
+		{name: "trusted", trustProxy: true, proto: "https", want: "<link>https://example.com/</link>"},
+		{name: "trusted but invalid", trustProxy: true, proto: "javascript", want: "<link>http://example.com/</link>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := feedHandler(caches, rssFormat, tt.trustProxy)
+
+			body := serveFeed(h, "/rss", map[string]string{"X-Forwarded-Proto": tt.proto}).Body.String()
+			if !strings.Contains(body, tt.want) {
+				t.Errorf("expected the feed to link to %s, got %s", tt.want, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,190 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+)
+
+const (
+	// maxRank is as deep as HN's story lists go.
+	maxRank = 500
+	// maxPageSize is the most stories a page may show.
+	maxPageSize = 100
+	// maxPageCaches is how many pages of each feed are cached besides the front page.
+	maxPageCaches = 32
+)
+
+// pageKey identifies a page of a feed: which page it is and how many stories it shows.
+type pageKey struct {
+	page int
+	n    int
+}
+
+// pages caches every page of a feed separately. The front page is refreshed in the background, the rest are cached
+// as they are asked for and only refreshed when they are asked for again after going stale.
+type pages struct {
+	feed  feed
+	opts  storyCacheOptions
+	front pageKey
+
+	mutex  sync.Mutex
+	caches map[pageKey]*storyCache
+	order  []pageKey
+}
+
+// newPages returns the page caches of a feed, with pages of opts.numStories stories by default.
+func newPages(f feed, opts storyCacheOptions) *pages {
+	p := &pages{
+		feed:   f,
+		opts:   opts,
+		front:  pageKey{page: 1, n: opts.numStories},
+		caches: make(map[pageKey]*storyCache),
+	}
+
+	p.caches[p.front] = newStoryCache(f, opts)
+
+	return p
+}
+
+// first returns the cache of the front page.
+func (p *pages) first() *storyCache {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.caches[p.front]
+}
+
+// get returns the cache of a page, making room for it by evicting the least recently used page cache if there are
+// too many.
+func (p *pages) get(key pageKey) *storyCache {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if sc, ok := p.caches[key]; ok {
+		p.touch(key)
+		return sc
+	}
+
+	if len(p.order) >= maxPageCaches {
+		oldest := p.order[0]
+		p.order = p.order[1:]
+
+		p.caches[oldest].evict()
+		delete(p.caches, oldest)
+	}
+
+	opts := p.opts
+	opts.page = key.page
+	opts.numStories = key.n
+	opts.refresh = 0
+
+	sc := newStoryCache(p.feed, opts)
+	p.caches[key] = sc
+	p.order = append(p.order, key)
+
+	return sc
+}
+
+// touch moves key to the back of the eviction order. The front page isn't in the order, it is never evicted. The
+// caller must hold p.mutex.
+func (p *pages) touch(key pageKey) {
+	for i, k := range p.order {
+		if k == key {
+			p.order = append(p.order[:i], p.order[i+1:]...)
+			p.order = append(p.order, key)
+			return
+		}
+	}
+}
+
+// each calls fn with every page cache.
+func (p *pages) each(fn func(*storyCache)) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for _, sc := range p.caches {
+		fn(sc)
+	}
+}
+
+// expire expires every cached page.
+func (p *pages) expire() {
+	p.each((*storyCache).expire)
+}
+
+// Close stops every page cache.
+func (p *pages) Close() {
+	p.each((*storyCache).Close)
+}
+
+// parsePage reads the page and n query parameters, which default to the front page of defaultN stories.
+func parsePage(q url.Values, defaultN int) (pageKey, error) {
+	key := pageKey{page: 1, n: defaultN}
+
+	if s := q.Get("n"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 1 || n > maxPageSize {
+			return key, errors.New("n must be a number from 1 to " + strconv.Itoa(maxPageSize))
+		}
+
+		key.n = n
+	}
+
+	if s := q.Get("page"); s != "" {
+		page, err := strconv.Atoi(s)
+		if err != nil || page < 1 {
+			return key, errors.New("page must be a positive number")
+		}
+
+		// Every page past maxRank is empty, and checking it here stops the offset below from overflowing.
+		if page > maxRank {
+			return key, errNoSuchPage
+		}
+
+		key.page = page
+	}
+
+	if (key.page-1)*key.n >= maxRank {
+		return key, errNoSuchPage
+	}
+
+	return key, nil
+}
+
+var errNoSuchPage = errors.New("there are no stories that far down")
+
+// pageFor returns the cache of the page asked for by the request, or writes an error if the page doesn't exist.
+func (p *pages) pageFor(w http.ResponseWriter, r *http.Request) (*storyCache, pageKey, bool) {
+	key, err := parsePage(r.URL.Query(), p.front.n)
+
+	switch err {
+	case nil:
+		return p.get(key), key, true
+	case errNoSuchPage:
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	}
+
+	return nil, key, false
+}
+
+// pageLink returns the link to another page of a feed, leaving n out if it's the default.
+func pageLink(f feed, key pageKey, defaultN int) string {
+	q := url.Values{}
+	if key.page > 1 {
+		q.Set("page", strconv.Itoa(key.page))
+	}
+	if key.n != defaultN {
+		q.Set("n", strconv.Itoa(key.n))
+	}
+
+	if len(q) == 0 {
+		return f.Path
+	}
+
+	return f.Path + "?" + q.Encode()
+}
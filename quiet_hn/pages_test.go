@@ -0,0 +1,34 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParsePage(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    pageKey
+		wantErr error
+	}{
+		{name: "defaults", query: "", want: pageKey{page: 1, n: 30}},
+		{name: "page and n", query: "page=3&n=10", want: pageKey{page: 3, n: 10}},
+		{name: "past the last story", query: "page=18", wantErr: errNoSuchPage},
+		{name: "page that would overflow", query: "page=4611686018427387904&n=4", wantErr: errNoSuchPage},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, _ := url.ParseQuery(tt.query)
+
+			got, err := parsePage(q, 30)
+			if err != tt.wantErr {
+				t.Fatalf("parsePage(%q): want error %v, got %v", tt.query, tt.wantErr, err)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("parsePage(%q): want %+v, got %+v", tt.query, tt.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package prefs
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+)
+
+// NewID returns a new random reader id.
+func NewID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(b), nil
+}
+
+// Signer signs cookie values, so readers can't pick someone else's id.
+type Signer struct {
+	key []byte
+}
+
+// NewSigner returns a Signer using key, which should be at least 32 random bytes.
+func NewSigner(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+func (s *Signer) mac(value string) string {
+	h := hmac.New(sha256.New, s.key)
+	h.Write([]byte(value))
+
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Sign returns the value followed by its signature.
+func (s *Signer) Sign(value string) string {
+	return value + "." + s.mac(value)
+}
+
+// Verify returns the value of a signed string, or false if the signature doesn't match.
+func (s *Signer) Verify(signed string) (string, bool) {
+	i := strings.LastIndex(signed, ".")
+	if i < 0 {
+		return "", false
+	}
+
+	value, sig := signed[:i], signed[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(s.mac(value))) {
+		return "", false
+	}
+
+	return value, true
+}
+
+// LoadKey reads the signing key in path, creating a random one the first time so cookies stay valid across restarts.
+func LoadKey(path string) ([]byte, error) {
+	key, err := ioutil.ReadFile(path)
+	if err == nil && len(key) >= 32 {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+
+	if err := ioutil.WriteFile(path, key, 0600); err != nil {
+		return nil, err
+	}
+
+	return key, nil
+}
@@ -0,0 +1,103 @@
+// Package prefs keeps each reader's preferences on the server, identified by a signed cookie.
+package prefs
+
+import (
+	"sort"
+	"time"
+)
+
+// MaxRead is the most stories remembered as read, the ones read longest ago are forgotten first.
+const MaxRead = 1000
+
+// Filters hide the stories a reader isn't interested in. A story is hidden if it matches any of them.
+type Filters struct {
+	// Domains hide stories from these hosts or any of their subdomains.
+	Domains []string `json:"domains,omitempty"`
+	// Keywords hide stories whose titles contain any of them, ignoring case.
+	Keywords []string `json:"keywords,omitempty"`
+	// MinScore hides stories with fewer points.
+	MinScore int `json:"min_score,omitempty"`
+}
+
+// Empty reports whether the filters don't hide anything.
+func (f Filters) Empty() bool {
+	return len(f.Domains) == 0 && len(f.Keywords) == 0 && f.MinScore <= 0
+}
+
+// Prefs are a single reader's preferences.
+type Prefs struct {
+	// Feed is the feed shown on the front page, if it isn't the top stories.
+	Feed    string    `json:"feed,omitempty"`
+	Filters Filters   `json:"filters"`
+	Updated time.Time `json:"updated"`
+
+	// Read and Saved are when stories were read and saved.
+	Read  map[int]time.Time `json:"read,omitempty"`
+	Saved map[int]time.Time `json:"saved,omitempty"`
+}
+
+// MarkRead remembers that the story was read at t.
+func (p *Prefs) MarkRead(id int, t time.Time) {
+	if p.Read == nil {
+		p.Read = make(map[int]time.Time)
+	}
+
+	p.Read[id] = t
+
+	if len(p.Read) > MaxRead {
+		for _, id := range byTime(p.Read)[MaxRead:] {
+			delete(p.Read, id)
+		}
+	}
+}
+
+// IsRead reports whether the story was read.
+func (p Prefs) IsRead(id int) bool {
+	_, ok := p.Read[id]
+	return ok
+}
+
+// Save saves the story at t, or unsaves it if it was already saved. It reports whether the story is saved.
+func (p *Prefs) Save(id int, t time.Time) bool {
+	if p.IsSaved(id) {
+		delete(p.Saved, id)
+		return false
+	}
+
+	if p.Saved == nil {
+		p.Saved = make(map[int]time.Time)
+	}
+
+	p.Saved[id] = t
+	return true
+}
+
+// IsSaved reports whether the story is saved.
+func (p Prefs) IsSaved(id int) bool {
+	_, ok := p.Saved[id]
+	return ok
+}
+
+// SavedIDs returns the saved stories, the most recently saved first.
+func (p Prefs) SavedIDs() []int {
+	return byTime(p.Saved)
+}
+
+// byTime returns the ids in times, the latest first.
+func byTime(times map[int]time.Time) []int {
+	ids := make([]int, 0, len(times))
+	for id := range times {
+		ids = append(ids, id)
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		ti, tj := times[ids[i]], times[ids[j]]
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+
+		return ids[i] > ids[j]
+	})
+
+	return ids
+}
@@ -0,0 +1,189 @@
+package prefs
+
+import (
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+var start = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+func TestPrefs_MarkRead(t *testing.T) {
+	var p Prefs
+
+	for i := 0; i < MaxRead+10; i++ {
+		p.MarkRead(i, start.Add(time.Duration(i)*time.Second))
+	}
+
+	if len(p.Read) != MaxRead {
+		t.Errorf("len(p.Read): want %d, got %d", MaxRead, len(p.Read))
+	}
+
+	if p.IsRead(9) {
+		t.Errorf("expected the oldest stories to be forgotten")
+	}
+
+	if !p.IsRead(10) || !p.IsRead(MaxRead+9) {
+		t.Errorf("expected the latest stories to be remembered")
+	}
+}
+
+func TestPrefs_Save(t *testing.T) {
+	var p Prefs
+
+	p.Save(1, start)
+	p.Save(2, start.Add(time.Minute))
+	p.Save(3, start.Add(2*time.Minute))
+
+	if saved := p.Save(2, start.Add(3*time.Minute)); saved {
+		t.Errorf("expected saving a saved story to unsave it")
+	}
+
+	if got, want := p.SavedIDs(), []int{3, 1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("p.SavedIDs(): want %v, got %v", want, got)
+	}
+}
+
+// newStore returns a Store in a temporary directory whose clock is stopped at start.
+func newStore(t *testing.T) *Store {
+	t.Helper()
+
+	s, err := NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewStore() received an error: %s", err.Error())
+	}
+	s.now = func() time.Time { return start }
+
+	return s
+}
+
+func TestStore(t *testing.T) {
+	s := newStore(t)
+
+	id, err := NewID()
+	if err != nil {
+		t.Fatalf("NewID() received an error: %s", err.Error())
+	}
+
+	p, err := s.Get(id)
+	if err != nil {
+		t.Fatalf("s.Get() received an error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(p, Prefs{}) {
+		t.Errorf("expected a new reader to have no preferences, got %+v", p)
+	}
+
+	_, err = s.Update(id, func(p *Prefs) {
+		p.Feed = "best"
+		p.Filters.Domains = []string{"example.com"}
+		p.Save(42, start)
+	})
+	if err != nil {
+		t.Fatalf("s.Update() received an error: %s", err.Error())
+	}
+
+	p, err = s.Get(id)
+	if err != nil {
+		t.Fatalf("s.Get() received an error: %s", err.Error())
+	}
+
+	want := Prefs{
+		Feed:    "best",
+		Filters: Filters{Domains: []string{"example.com"}},
+		Updated: start,
+		Saved:   map[int]time.Time{42: start},
+	}
+	if !reflect.DeepEqual(p, want) {
+		t.Errorf("s.Get():\nwant %+v\n got %+v", want, p)
+	}
+
+	if err := s.Delete(id); err != nil {
+		t.Fatalf("s.Delete() received an error: %s", err.Error())
+	}
+
+	if p, _ := s.Get(id); !reflect.DeepEqual(p, Prefs{}) {
+		t.Errorf("expected a deleted reader to have no preferences, got %+v", p)
+	}
+}
+
+func TestStore_concurrentUpdates(t *testing.T) {
+	s := newStore(t)
+
+	id, _ := NewID()
+
+	var wg sync.WaitGroup
+
+	for i := 1; i <= 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			s.Update(id, func(p *Prefs) { p.MarkRead(i, start) })
+		}(i)
+	}
+
+	wg.Wait()
+
+	if p, _ := s.Get(id); len(p.Read) != 20 {
+		t.Errorf("len(p.Read): want %d, got %d", 20, len(p.Read))
+	}
+}
+
+func TestStore_badID(t *testing.T) {
+	s := newStore(t)
+
+	for _, id := range []string{"", "../../etc/passwd", "ABCDEF0123456789ABCDEF0123456789"} {
+		if _, err := s.Get(id); err != ErrBadID {
+			t.Errorf("s.Get(%q): want %v, got %v", id, ErrBadID, err)
+		}
+	}
+}
+
+func TestSigner(t *testing.T) {
+	s := NewSigner([]byte("0123456789abcdef0123456789abcdef"))
+
+	signed := s.Sign("reader")
+
+	tests := []struct {
+		name   string
+		signed string
+		value  string
+		ok     bool
+	}{
+		{name: "signed", signed: signed, value: "reader", ok: true},
+		{name: "changed value", signed: "header" + signed[len("reader"):]},
+		{name: "changed signature", signed: signed[:len(signed)-1] + "A"},
+		{name: "unsigned", signed: "reader"},
+		{name: "other key", signed: NewSigner([]byte("another key, just as long as it is")).Sign("reader")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, ok := s.Verify(tt.signed)
+			if value != tt.value || ok != tt.ok {
+				t.Errorf("s.Verify(%q): want %q, %v, got %q, %v", tt.signed, tt.value, tt.ok, value, ok)
+			}
+		})
+	}
+}
+
+func TestLoadKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "key")
+
+	key, err := LoadKey(path)
+	if err != nil {
+		t.Fatalf("LoadKey() received an error: %s", err.Error())
+	}
+	if len(key) != 32 {
+		t.Errorf("len(key): want %d, got %d", 32, len(key))
+	}
+
+	again, err := LoadKey(path)
+	if err != nil {
+		t.Fatalf("LoadKey() received an error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(key, again) {
+		t.Errorf("expected the same key to be loaded again")
+	}
+}
@@ -0,0 +1,124 @@
+package prefs
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+	"time"
+)
+
+// ErrBadID is returned for ids that weren't made by NewID.
+var ErrBadID = errors.New("prefs: malformed id")
+
+var validID = regexp.MustCompile(`^[0-9a-f]{32}$`)
+
+// Store keeps each reader's preferences in its own JSON file in a directory.
+type Store struct {
+	dir string
+	now func() time.Time
+
+	// mutex serialises updates, so two requests from the same reader can't undo each other's changes.
+	mutex sync.Mutex
+}
+
+// NewStore returns a Store keeping preferences in dir. dir is created if it doesn't exist.
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &Store{dir: dir, now: time.Now}, nil
+}
+
+func (s *Store) path(id string) (string, error) {
+	if !validID.MatchString(id) {
+		return "", ErrBadID
+	}
+
+	return filepath.Join(s.dir, id+".json"), nil
+}
+
+// Get returns the reader's preferences. Readers without any have the zero Prefs.
+func (s *Store) Get(id string) (Prefs, error) {
+	path, err := s.path(id)
+	if err != nil {
+		return Prefs{}, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return Prefs{}, nil
+	}
+	if err != nil {
+		return Prefs{}, err
+	}
+
+	var p Prefs
+	if err := json.Unmarshal(data, &p); err != nil {
+		return Prefs{}, err
+	}
+
+	return p, nil
+}
+
+// Update changes the reader's preferences with fn and stores them, returning the new preferences.
+func (s *Store) Update(id string, fn func(*Prefs)) (Prefs, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	p, err := s.Get(id)
+	if err != nil {
+		return Prefs{}, err
+	}
+
+	fn(&p)
+	p.Updated = s.now()
+
+	data, err := json.Marshal(p)
+	if err != nil {
+		return Prefs{}, err
+	}
+
+	path, _ := s.path(id)
+
+	// Written to a temporary file first, so a reader never sees half of it.
+	tmp, err := ioutil.TempFile(s.dir, ".prefs-*")
+	if err != nil {
+		return Prefs{}, err
+	}
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return Prefs{}, err
+	}
+
+	return p, nil
+}
+
+// Delete forgets the reader.
+func (s *Store) Delete(id string) error {
+	path, err := s.path(id)
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
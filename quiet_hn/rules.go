@@ -0,0 +1,325 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	actionHide      = "hide"
+	actionHighlight = "highlight"
+)
+
+// duration is a time.Duration that is written as a string like "90m" in the rules file.
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+
+	*d = duration(parsed)
+	return nil
+}
+
+// rule hides or highlights the stories matching every condition it sets. See rules.example.json.
+type rule struct {
+	Name   string `json:"name"`
+	Action string `json:"action"`
+
+	// Domains match the story's host or any of its subdomains.
+	Domains []string `json:"domains"`
+	// Keywords match titles containing any of them, ignoring case.
+	Keywords []string `json:"keywords"`
+	Regex    string   `json:"regex"`
+	Authors  []string `json:"authors"`
+
+	MinScore    *int      `json:"min_score"`
+	MaxScore    *int      `json:"max_score"`
+	MinComments *int      `json:"min_comments"`
+	MaxComments *int      `json:"max_comments"`
+	MinAge      *duration `json:"min_age"`
+	MaxAge      *duration `json:"max_age"`
+
+	regex *regexp.Regexp
+}
+
+// matches reports whether the story meets every condition of the rule.
+func (r *rule) matches(story item, now time.Time) bool {
+	if len(r.Domains) > 0 && !matchesDomain(story.Host, r.Domains) {
+		return false
+	}
+
+	if len(r.Keywords) > 0 {
+		title := strings.ToLower(story.Title)
+		found := false
+
+		for _, keyword := range r.Keywords {
+			if strings.Contains(title, strings.ToLower(keyword)) {
+				found = true
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	if r.regex != nil && !r.regex.MatchString(story.Title) {
+		return false
+	}
+
+	if len(r.Authors) > 0 && !contains(r.Authors, story.By) {
+		return false
+	}
+
+	age := now.Sub(time.Unix(int64(story.Time), 0))
+
+	switch {
+	case r.MinScore != nil && story.Score < *r.MinScore,
+		r.MaxScore != nil && story.Score > *r.MaxScore,
+		r.MinComments != nil && story.Descendants < *r.MinComments,
+		r.MaxComments != nil && story.Descendants > *r.MaxComments,
+		r.MinAge != nil && age < time.Duration(*r.MinAge),
+		r.MaxAge != nil && age > time.Duration(*r.MaxAge):
+		return false
+	}
+
+	return true
+}
+
+func matchesDomain(host string, domains []string) bool {
+	host = strings.ToLower(host)
+
+	for _, domain := range domains {
+		domain = strings.TrimPrefix(strings.ToLower(domain), "www.")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseRules reads the rules in a rules file and checks them.
+func parseRules(data []byte) ([]*rule, error) {
+	var file struct {
+		Rules []*rule `json:"rules"`
+	}
+
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	for i, r := range file.Rules {
+		if r == nil {
+			return nil, fmt.Errorf("rule %d is empty", i+1)
+		}
+
+		if r.Name == "" {
+			r.Name = fmt.Sprintf("rule %d", i+1)
+		}
+
+		if r.Action != actionHide && r.Action != actionHighlight {
+			return nil, fmt.Errorf("%s: action must be %q or %q, got %q", r.Name, actionHide, actionHighlight, r.Action)
+		}
+
+		if r.Regex != "" {
+			regex, err := regexp.Compile(r.Regex)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %v", r.Name, err)
+			}
+
+			r.regex = regex
+		}
+	}
+
+	return file.Rules, nil
+}
+
+// ruleSet is the rules loaded from a rules file, which are reloaded whenever the file changes. A nil *ruleSet has no
+// rules.
+type ruleSet struct {
+	path     string
+	mutex    sync.RWMutex
+	rules    []*rule
+	modified time.Time
+	loaded   time.Time
+	err      error
+	onReload []func()
+}
+
+// loadRules reads the rules file at path.
+func loadRules(path string) (*ruleSet, error) {
+	rs := &ruleSet{path: path}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := rs.load(info.ModTime()); err != nil {
+		return nil, err
+	}
+
+	return rs, nil
+}
+
+func (rs *ruleSet) load(modified time.Time) error {
+	data, err := ioutil.ReadFile(rs.path)
+	if err == nil {
+		var rules []*rule
+		if rules, err = parseRules(data); err == nil {
+			rs.mutex.Lock()
+			rs.rules = rules
+			rs.loaded = time.Now()
+			rs.mutex.Unlock()
+		}
+	}
+
+	rs.mutex.Lock()
+	rs.modified = modified
+	rs.err = err
+	rs.mutex.Unlock()
+
+	return err
+}
+
+// watch reloads the rules every time the file is modified, checking every interval.
+func (rs *ruleSet) watch(interval time.Duration) {
+	for range time.Tick(interval) {
+		rs.reload()
+	}
+}
+
+// reload reloads the rules if the file was modified since they were loaded, and reports whether it did. A file with
+// mistakes in it is reported and ignored, and the rules loaded before stay in use.
+func (rs *ruleSet) reload() bool {
+	info, err := os.Stat(rs.path)
+	if err != nil {
+		return false
+	}
+
+	rs.mutex.RLock()
+	changed := !info.ModTime().Equal(rs.modified)
+	rs.mutex.RUnlock()
+
+	if !changed {
+		return false
+	}
+
+	if err := rs.load(info.ModTime()); err != nil {
+		log.Printf("failed to reload %s: %v", rs.path, err)
+		return false
+	}
+
+	log.Printf("reloaded %s", rs.path)
+
+	for _, fn := range rs.onReload {
+		fn()
+	}
+
+	return true
+}
+
+// apply runs the rules against a story. It returns the story, highlighted if a highlight rule matched, and the name
+// of the rule that hides it, if any.
+func (rs *ruleSet) apply(story item, now time.Time) (item, string) {
+	if rs == nil {
+		return story, ""
+	}
+
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	for _, r := range rs.rules {
+		if !r.matches(story, now) {
+			continue
+		}
+
+		if r.Action == actionHide {
+			return story, r.Name
+		}
+
+		if story.Highlight == "" {
+			story.Highlight = r.Name
+		}
+	}
+
+	return story, ""
+}
+
+type ruleStatus struct {
+	Path   string
+	Rules  []*rule
+	Loaded time.Time
+	Err    error
+}
+
+func (rs *ruleSet) status() ruleStatus {
+	if rs == nil {
+		return ruleStatus{}
+	}
+
+	rs.mutex.RLock()
+	defer rs.mutex.RUnlock()
+
+	return ruleStatus{Path: rs.path, Rules: rs.rules, Loaded: rs.loaded, Err: rs.err}
+}
+
+type filteredFeed struct {
+	Feed   feed
+	Hidden []filtered
+}
+
+type filteredData struct {
+	Rules ruleStatus
+	Feeds []filteredFeed
+}
+
+// filteredHandler renders a debug page listing the rules in use and the stories each feed hid from its front page.
+func filteredHandler(caches map[string]*pages, rules *ruleSet, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := filteredData{
+			Rules: rules.status(),
+		}
+
+		for _, f := range feeds {
+			data.Feeds = append(data.Feeds, filteredFeed{
+				Feed:   f,
+				Hidden: caches[f.Name].first().filtered(),
+			})
+		}
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
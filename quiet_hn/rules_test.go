@@ -0,0 +1,207 @@
+package main
+
+import (
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var ruleNow = time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+
+// testStory returns a story posted age before ruleNow.
+func testStory(title, host, by string, score, comments int, age time.Duration) item {
+	return item{
+		Item: hn.Item{
+			Title:       title,
+			By:          by,
+			Score:       score,
+			Descendants: comments,
+			Time:        int(ruleNow.Add(-age).Unix()),
+		},
+		Host: host,
+	}
+}
+
+// mustParseRules parses a rules file, failing the test if it is invalid.
+func mustParseRules(t *testing.T, data string) []*rule {
+	t.Helper()
+
+	rules, err := parseRules([]byte(data))
+	if err != nil {
+		t.Fatalf("parseRules() received an error: %s", err)
+	}
+
+	return rules
+}
+
+func TestRule_matches(t *testing.T) {
+	gopher := testStory("Go 1.15 is released", "blog.golang.org", "rsc", 500, 120, 2*time.Hour)
+
+	tests := []struct {
+		name string
+		// rule is the conditions of a hide rule.
+		rule  string
+		story item
+		want  bool
+	}{
+		{name: "no conditions", rule: ``, story: gopher, want: true},
+		{name: "domain", rule: `"domains": ["blog.golang.org"]`, story: gopher, want: true},
+		{name: "subdomain", rule: `"domains": ["golang.org"]`, story: gopher, want: true},
+		{name: "domain case and www", rule: `"domains": ["www.GOLANG.org"]`, story: gopher, want: true},
+		{name: "host case", rule: `"domains": ["golang.org"]`, story: testStory("Go", "Blog.Golang.org", "rsc", 1, 0, 0), want: true},
+		{name: "other domain", rule: `"domains": ["example.com"]`, story: gopher, want: false},
+		{name: "domain suffix isn't a subdomain", rule: `"domains": ["lang.org"]`, story: gopher, want: false},
+		{name: "keyword", rule: `"keywords": ["nft", "RELEASED"]`, story: gopher, want: true},
+		{name: "no keyword", rule: `"keywords": ["rust"]`, story: gopher, want: false},
+		{name: "regex", rule: `"regex": "^Go \\d"`, story: gopher, want: true},
+		{name: "no regex match", rule: `"regex": "^go \\d"`, story: gopher, want: false},
+		{name: "author", rule: `"authors": ["pg", "rsc"]`, story: gopher, want: true},
+		{name: "other author", rule: `"authors": ["pg"]`, story: gopher, want: false},
+		{name: "min score", rule: `"min_score": 500`, story: gopher, want: true},
+		{name: "below min score", rule: `"min_score": 501`, story: gopher, want: false},
+		{name: "max score", rule: `"max_score": 499`, story: gopher, want: false},
+		{name: "min comments", rule: `"min_comments": 121`, story: gopher, want: false},
+		{name: "max comments", rule: `"max_comments": 120`, story: gopher, want: true},
+		{name: "min age", rule: `"min_age": "3h"`, story: gopher, want: false},
+		{name: "max age", rule: `"max_age": "3h"`, story: gopher, want: true},
+		{name: "every condition must match", rule: `"domains": ["golang.org"], "authors": ["pg"]`, story: gopher, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := `{"rules": [{"action": "hide"`
+			if tt.rule != "" {
+				data += ", " + tt.rule
+			}
+
+			rules := mustParseRules(t, data+"}]}")
+
+			if got := rules[0].matches(tt.story, ruleNow); got != tt.want {
+				t.Errorf("{%s}.matches(): want %v, got %v", tt.rule, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParseRules(t *testing.T) {
+	rules := mustParseRules(t, `{"rules": [{"action": "hide"}, {"name": "go", "action": "highlight"}]}`)
+	if rules[0].Name != "rule 1" || rules[1].Name != "go" {
+		t.Errorf("names: want %q and %q, got %q and %q", "rule 1", "go", rules[0].Name, rules[1].Name)
+	}
+
+	for _, data := range []string{
+		`{"rules": [{"action": "delete"}]}`,
+		`{"rules": [{"action": "hide", "regex": "("}]}`,
+		`{"rules": [{"action": "hide", "min_age": "soon"}]}`,
+		`{"rules": [{"action": "hide", "min_score": "10"}]}`,
+		`{"rules": [null]}`,
+		`{"rules": `,
+	} {
+		if _, err := parseRules([]byte(data)); err == nil {
+			t.Errorf("parseRules(%s): want an error", data)
+		}
+	}
+}
+
+func TestRuleSet_apply(t *testing.T) {
+	rs := &ruleSet{rules: mustParseRules(t, `{"rules": [
+		{"name": "go", "action": "highlight", "keywords": ["go"]},
+		{"name": "big", "action": "highlight", "min_score": 100},
+		{"name": "no rust", "action": "hide", "keywords": ["rust"]}
+	]}`)}
+
+	tests := []struct {
+		name          string
+		story         item
+		wantHighlight string
+		wantHidden    string
+	}{
+		{name: "first highlight wins", story: testStory("Go 1.15", "", "", 500, 0, 0), wantHighlight: "go"},
+		{name: "second highlight", story: testStory("Zig 0.6", "", "", 500, 0, 0), wantHighlight: "big"},
+		{name: "hide", story: testStory("Rust 1.44", "", "", 5, 0, 0), wantHidden: "no rust"},
+		{name: "hide after a highlight", story: testStory("Go vs Rust", "", "", 5, 0, 0), wantHidden: "no rust"},
+		{name: "no match", story: testStory("Zig 0.6", "", "", 5, 0, 0)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, hidden := rs.apply(tt.story, ruleNow)
+			if hidden != tt.wantHidden {
+				t.Errorf("hidden by: want %q, got %q", tt.wantHidden, hidden)
+			}
+			if hidden == "" && got.Highlight != tt.wantHighlight {
+				t.Errorf("highlight: want %q, got %q", tt.wantHighlight, got.Highlight)
+			}
+		})
+	}
+
+	var none *ruleSet
+	if _, hidden := none.apply(testStory("Rust", "", "", 0, 0, 0), ruleNow); hidden != "" {
+		t.Errorf("expected a nil rule set to hide nothing, got %q", hidden)
+	}
+}
+
+func TestRuleSet_reload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rules.json")
+
+	// write replaces the rules file, moving its modification time on so the change is seen.
+	modified := time.Now()
+	write := func(data string) {
+		t.Helper()
+
+		if err := ioutil.WriteFile(path, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		modified = modified.Add(time.Second)
+		if err := os.Chtimes(path, modified, modified); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(`{"rules": [{"name": "no rust", "action": "hide", "keywords": ["rust"]}]}`)
+
+	rs, err := loadRules(path)
+	if err != nil {
+		t.Fatalf("loadRules() received an error: %s", err)
+	}
+
+	reloads := 0
+	rs.onReload = append(rs.onReload, func() { reloads++ })
+
+	if rs.reload() {
+		t.Errorf("expected an unchanged file not to be reloaded")
+	}
+
+	write(`{"rules": [{"name": "no go", "action": "hide", "keywords": ["go"]}]}`)
+
+	if !rs.reload() || reloads != 1 {
+		t.Fatalf("expected the changed file to be reloaded once, got %d reloads", reloads)
+	}
+	if _, hidden := rs.apply(testStory("Go 1.15", "", "", 0, 0, 0), ruleNow); hidden != "no go" {
+		t.Errorf("expected the new rules to be used, got %q", hidden)
+	}
+
+	// A broken file is reported, and the rules from before stay in use.
+	write(`{"rules": [{"action": "delete"}]}`)
+
+	if rs.reload() || reloads != 1 {
+		t.Errorf("expected a broken file not to be reloaded, got %d reloads", reloads)
+	}
+	if rs.status().Err == nil {
+		t.Errorf("expected the error to be reported")
+	}
+	if _, hidden := rs.apply(testStory("Go 1.15", "", "", 0, 0, 0), ruleNow); hidden != "no go" {
+		t.Errorf("expected the rules from before to stay in use, got %q", hidden)
+	}
+
+	// Fixing the file clears the error.
+	write(`{"rules": []}`)
+
+	if !rs.reload() || rs.status().Err != nil {
+		t.Errorf("expected the fixed file to be reloaded, got %v", rs.status().Err)
+	}
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+// allowedTags are the tags HN uses in comment text. Everything else is dropped.
+var allowedTags = map[string]bool{
+	"a": true, "b": true, "code": true, "em": true, "i": true, "pre": true, "strong": true,
+}
+
+// rawText are the tags whose contents aren't text to show.
+var rawText = map[string]bool{"script": true, "style": true}
+
+var hrefPattern = regexp.MustCompile(`(?i)\bhref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s>]+))`)
+
+// sanitize rebuilds the HTML in HN comment text from an allow list, so only plain formatting and http(s) links make it
+// to the page. Tags are always balanced in the result, and text is escaped again.
+func sanitize(text string) string {
+	var b strings.Builder
+	var open []string
+
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '<')
+		if start < 0 {
+			start = len(text)
+		}
+
+		b.WriteString(html.EscapeString(html.UnescapeString(text[:start])))
+		text = text[start:]
+		if text == "" {
+			break
+		}
+
+		// A < that can't start a tag, like in "a < b", is just text.
+		if len(text) < 2 || !isTagStart(text[1]) {
+			b.WriteString("&lt;")
+			text = text[1:]
+			continue
+		}
+
+		end := strings.IndexByte(text, '>')
+		if end < 0 {
+			// A lone < is just text.
+			b.WriteString(html.EscapeString(html.UnescapeString(text)))
+			break
+		}
+
+		tag := text[1:end]
+		text = text[end+1:]
+
+		closing := strings.HasPrefix(tag, "/")
+		name := strings.ToLower(strings.TrimPrefix(tag, "/"))
+		if i := strings.IndexAny(name, " \t\n/"); i >= 0 {
+			name = name[:i]
+		}
+
+		switch {
+		case rawText[name] && !closing:
+			// The contents of scripts and styles are dropped with them, up to the closing tag.
+			if i := strings.Index(strings.ToLower(text), "</"+name); i >= 0 {
+				text = text[i:]
+			} else {
+				text = ""
+			}
+		case name == "p" && !closing:
+			b.WriteString("<p>")
+		case !allowedTags[name]:
+			// Dropped.
+		case closing:
+			for i := len(open) - 1; i >= 0; i-- {
+				if open[i] != name {
+					continue
+				}
+				for len(open) > i {
+					fmt.Fprintf(&b, "</%s>", open[len(open)-1])
+					open = open[:len(open)-1]
+				}
+				break
+			}
+		case name == "a":
+			b.WriteString(anchor(tag))
+			open = append(open, name)
+		default:
+			fmt.Fprintf(&b, "<%s>", name)
+			open = append(open, name)
+		}
+	}
+
+	for i := len(open) - 1; i >= 0; i-- {
+		fmt.Fprintf(&b, "</%s>", open[i])
+	}
+
+	return b.String()
+}
+
+// isTagStart reports whether c can follow a < that starts a tag.
+func isTagStart(c byte) bool {
+	return c == '/' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+// anchor rebuilds an <a> tag with only its href, and only if it points to an http(s) URL.
+func anchor(tag string) string {
+	m := hrefPattern.FindStringSubmatch(tag)
+	if m == nil {
+		return "<a>"
+	}
+
+	href := strings.TrimSpace(html.UnescapeString(m[1] + m[2] + m[3]))
+	lower := strings.ToLower(href)
+	if !strings.HasPrefix(lower, "http://") && !strings.HasPrefix(lower, "https://") {
+		return "<a>"
+	}
+
+	return fmt.Sprintf(`<a href="%s" rel="nofollow noopener">`, html.EscapeString(href))
+}
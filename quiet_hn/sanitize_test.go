@@ -0,0 +1,43 @@
+package main
+
+import "testing"
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "formatting", text: `<p>Some <i>italic</i> and <code>code</code>`, want: `<p>Some <i>italic</i> and <code>code</code>`},
+		{name: "script", text: `<script>alert(1)</script>hi`, want: `hi`},
+		{name: "script with src", text: `<SCRIPT src="https://evil.com/x.js"></SCRIPT>hi`, want: `hi`},
+		{name: "unclosed script", text: `hi<script>alert(1)`, want: `hi`},
+		{name: "style", text: `<style>body { display: none }</style>hi`, want: `hi`},
+		{name: "other tags", text: `<img src=x onerror=alert(1)><iframe src="https://evil.com"></iframe>hi`, want: `hi`},
+		{name: "on attributes", text: `<i onmouseover="alert(1)">hi</i>`, want: `<i>hi</i>`},
+		{name: "on attributes on links", text: `<a href="https://example.com/" onclick="alert(1)">hi</a>`, want: `<a href="https://example.com/" rel="nofollow noopener">hi</a>`},
+		{name: "unquoted href", text: `<a href=https://example.com/>hi</a>`, want: `<a href="https://example.com/" rel="nofollow noopener">hi</a>`},
+		{name: "quotes in href", text: `<a href='https://example.com/?q="x"'>hi</a>`, want: `<a href="https://example.com/?q=&#34;x&#34;" rel="nofollow noopener">hi</a>`},
+		{name: "javascript href", text: `<a href="javascript:alert(1)">hi</a>`, want: `<a>hi</a>`},
+		{name: "mixed case javascript href", text: `<a href="JaVaScRiPt:alert(1)">hi</a>`, want: `<a>hi</a>`},
+		{name: "javascript href with spaces", text: `<a href="  javascript:alert(1)">hi</a>`, want: `<a>hi</a>`},
+		{name: "entity encoded javascript href", text: `<a href="&#106;avascript:alert(1)">hi</a>`, want: `<a>hi</a>`},
+		{name: "data href", text: `<a href="data:text/html;base64,PHNjcmlwdD4=">hi</a>`, want: `<a>hi</a>`},
+		{name: "unclosed tags", text: `<i>unclosed <b>tags`, want: `<i>unclosed <b>tags</b></i>`},
+		{name: "misnested tags", text: `<b><i>mis</b>nested</i>`, want: `<b><i>mis</i></b>nested`},
+		{name: "stray closing tag", text: `</i>hi`, want: `hi`},
+		{name: "unterminated tag", text: `hi <a href="https://example.com/"`, want: `hi &lt;a href=&#34;https://example.com/&#34;`},
+		{name: "less than", text: `a < b and c > d`, want: `a &lt; b and c &gt; d`},
+		{name: "entity encoded tags", text: `&lt;script&gt;alert(1)&lt;/script&gt;`, want: `&lt;script&gt;alert(1)&lt;/script&gt;`},
+		{name: "double encoded tags", text: `&amp;lt;script&amp;gt;`, want: `&amp;lt;script&amp;gt;`},
+		{name: "entities", text: `it&#x27;s &quot;quoted&quot;`, want: `it&#39;s &#34;quoted&#34;`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitize(tt.text); got != tt.want {
+				t.Errorf("sanitize(%q): want %q, got %q", tt.text, tt.want, got)
+			}
+		})
+	}
+}
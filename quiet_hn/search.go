@@ -0,0 +1,204 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"github.com/jwambugu/gophercises/quiet_hn/search"
+	"html/template"
+	"log"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// searchPageSize is the number of hits on a page of search results.
+const searchPageSize = 20
+
+// maxSearchPage is the last page that can be asked for, so the offset of a page can't overflow.
+const maxSearchPage = math.MaxInt32 / searchPageSize
+
+// indexingCache indexes every item the client fetches on its way into the cache, so everything quiet_hn has shown
+// can be searched.
+type indexingCache struct {
+	hn.Cache
+	index *search.Index
+}
+
+func (c indexingCache) Set(item hn.Item) {
+	if !item.Deleted && !item.Dead {
+		c.index.Add(toDoc(item))
+	}
+
+	c.Cache.Set(item)
+}
+
+func toDoc(item hn.Item) search.Doc {
+	return search.Doc{
+		ID:     item.ID,
+		Type:   item.Type,
+		Parent: item.Parent,
+		Title:  item.Title,
+		URL:    item.URL,
+		Text:   item.Text,
+		By:     item.By,
+		Host:   parseHNItem(item).Host,
+		Score:  item.Score,
+		Time:   time.Unix(int64(item.Time), 0),
+	}
+}
+
+// saveIndex saves the index to path every interval until ctx is cancelled.
+func saveIndex(ctx context.Context, index *search.Index, path string, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := index.Save(path); err != nil {
+				log.Printf("failed to save the search index: %v", err)
+			}
+		}
+	}
+}
+
+type searchHit struct {
+	search.Hit
+	Link       string
+	StoryTitle string
+}
+
+type searchData struct {
+	Enabled  bool
+	Query    string
+	After    string
+	Before   string
+	MinScore string
+	Type     string
+	Total    int
+	Hits     []searchHit
+	Prev     string
+	Next     string
+	Err      string
+	Time     time.Duration
+}
+
+// searchHandler renders /search?q= with the indexed items matching the query, optionally only the ones of a type,
+// posted after and before the given dates or with a min_score.
+func searchHandler(index *search.Index, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		q := r.URL.Query()
+
+		data := searchData{
+			Enabled:  index != nil,
+			Query:    q.Get("q"),
+			After:    q.Get("after"),
+			Before:   q.Get("before"),
+			MinScore: q.Get("min_score"),
+			Type:     q.Get("type"),
+		}
+
+		query, page, err := parseSearch(q)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			data.Err = err.Error()
+		}
+
+		if index != nil && err == nil && strings.TrimSpace(data.Query) != "" {
+			results := index.Search(query)
+			data.Total = results.Total
+
+			for _, hit := range results.Hits {
+				sh := searchHit{Hit: hit, Link: parseHNItem(hn.Item{ID: hit.Doc.ID, URL: hit.Doc.URL}).Link()}
+
+				if hit.Doc.Story != hit.Doc.ID {
+					if story, ok := index.Doc(hit.Doc.Story); ok {
+						sh.StoryTitle = story.Title
+					}
+				}
+
+				data.Hits = append(data.Hits, sh)
+			}
+
+			if page > 1 {
+				data.Prev = searchLink(q, page-1)
+			}
+			if page*searchPageSize < results.Total {
+				data.Next = searchLink(q, page+1)
+			}
+		}
+
+		data.Time = time.Now().Sub(start)
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// parseSearch reads the search form into a query, and the page of results asked for.
+func parseSearch(q url.Values) (search.Query, int, error) {
+	query := search.Query{
+		Text:  q.Get("q"),
+		Type:  q.Get("type"),
+		Limit: searchPageSize,
+	}
+
+	var err error
+
+	if s := q.Get("after"); s != "" {
+		if query.After, err = time.Parse("2006-01-02", s); err != nil {
+			return query, 1, errors.New("after must be a date like 2006-01-02")
+		}
+	}
+
+	if s := q.Get("before"); s != "" {
+		if query.Before, err = time.Parse("2006-01-02", s); err != nil {
+			return query, 1, errors.New("before must be a date like 2006-01-02")
+		}
+
+		// Before includes the day itself.
+		query.Before = query.Before.AddDate(0, 0, 1)
+	}
+
+	if s := q.Get("min_score"); s != "" {
+		if query.MinScore, err = strconv.Atoi(s); err != nil || query.MinScore < 0 {
+			return query, 1, errors.New("min_score must be a positive number")
+		}
+	}
+
+	page := 1
+	if s := q.Get("page"); s != "" {
+		if page, err = strconv.Atoi(s); err != nil || page < 1 || page > maxSearchPage {
+			return query, 1, fmt.Errorf("page must be a number from 1 to %d", maxSearchPage)
+		}
+	}
+
+	query.Offset = (page - 1) * searchPageSize
+
+	return query, page, nil
+}
+
+func searchLink(q url.Values, page int) string {
+	next := url.Values{}
+	for k, v := range q {
+		next[k] = v
+	}
+
+	next.Del("page")
+	if page > 1 {
+		next.Set("page", strconv.Itoa(page))
+	}
+
+	return "/search?" + next.Encode()
+}
@@ -0,0 +1,244 @@
+// Package search is an in-memory inverted index over HN items, with phrase queries, ranking and filters. It can be
+// saved to disk and loaded again.
+package search
+
+import (
+	"bytes"
+	"encoding/gob"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Doc is an item as it is indexed.
+type Doc struct {
+	ID   int
+	Type string
+	// Parent is the item a comment replies to, and Story the story it is under. Story is worked out from the parent
+	// when the parent was indexed first, which it is when threads are fetched top down.
+	Parent int
+	Story  int
+	Title  string
+	// URL isn't searched, Host is.
+	URL string
+	// Text may be HTML, the tags are ignored.
+	Text  string
+	By    string
+	Host  string
+	Score int
+	Time  time.Time
+}
+
+// The fields of a doc that are searched.
+const (
+	fieldTitle = iota
+	fieldText
+	fieldBy
+	fieldHost
+	numFields
+)
+
+// fieldWeights are how much a match in each field counts.
+var fieldWeights = [numFields]float64{3, 1, 2, 2}
+
+func (d *Doc) fields() [numFields][]string {
+	return [numFields][]string{
+		tokenize(d.Title),
+		tokenize(plain(d.Text)),
+		tokenize(d.By),
+		tokenize(d.Host),
+	}
+}
+
+// posting is where a term appears in a single doc.
+type posting struct {
+	positions [numFields][]int32
+}
+
+// Index is an inverted index of docs. It is safe to use from multiple goroutines.
+type Index struct {
+	mutex    sync.RWMutex
+	docs     map[int]*Doc
+	postings map[string]map[int]*posting
+	lengths  map[int]int
+	totalLen int
+
+	// version counts the changes, so Save can tell whether there is anything to save.
+	version int
+	saved   int
+}
+
+// NewIndex returns an empty index.
+func NewIndex() *Index {
+	return &Index{
+		docs:     make(map[int]*Doc),
+		postings: make(map[string]map[int]*posting),
+		lengths:  make(map[int]int),
+	}
+}
+
+// Len returns the number of docs in the index.
+func (x *Index) Len() int {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	return len(x.docs)
+}
+
+// Add indexes the doc, replacing an earlier version of it.
+func (x *Index) Add(d Doc) {
+	x.mutex.Lock()
+	defer x.mutex.Unlock()
+
+	x.add(d)
+	x.version++
+}
+
+func (x *Index) add(d Doc) {
+	if old, ok := x.docs[d.ID]; ok {
+		x.remove(old)
+	}
+
+	if d.Story == 0 {
+		if parent, ok := x.docs[d.Parent]; ok && parent.Story != 0 {
+			d.Story = parent.Story
+		} else if d.Parent != 0 {
+			d.Story = d.Parent
+		} else {
+			d.Story = d.ID
+		}
+	}
+
+	x.docs[d.ID] = &d
+
+	length := 0
+
+	for field, terms := range d.fields() {
+		for pos, term := range terms {
+			docs, ok := x.postings[term]
+			if !ok {
+				docs = make(map[int]*posting)
+				x.postings[term] = docs
+			}
+
+			p, ok := docs[d.ID]
+			if !ok {
+				p = &posting{}
+				docs[d.ID] = p
+			}
+
+			p.positions[field] = append(p.positions[field], int32(pos))
+		}
+
+		length += len(terms)
+	}
+
+	x.lengths[d.ID] = length
+	x.totalLen += length
+}
+
+func (x *Index) remove(d *Doc) {
+	for _, terms := range d.fields() {
+		for _, term := range terms {
+			docs := x.postings[term]
+			delete(docs, d.ID)
+
+			if len(docs) == 0 {
+				delete(x.postings, term)
+			}
+		}
+	}
+
+	x.totalLen -= x.lengths[d.ID]
+	delete(x.lengths, d.ID)
+	delete(x.docs, d.ID)
+}
+
+// Doc returns the indexed doc with the id.
+func (x *Index) Doc(id int) (Doc, bool) {
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	d, ok := x.docs[id]
+	if !ok {
+		return Doc{}, false
+	}
+
+	return *d, true
+}
+
+// Save writes the docs to path, if they changed since they were last saved or loaded. The terms aren't saved, they
+// are indexed again by Load.
+func (x *Index) Save(path string) error {
+	x.mutex.RLock()
+
+	if x.version == x.saved {
+		x.mutex.RUnlock()
+		return nil
+	}
+
+	version := x.version
+	docs := make([]Doc, 0, len(x.docs))
+	for _, d := range x.docs {
+		docs = append(docs, *d)
+	}
+
+	x.mutex.RUnlock()
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(docs); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+
+	_, err = tmp.Write(buf.Bytes())
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+
+	x.mutex.Lock()
+	if version > x.saved {
+		x.saved = version
+	}
+	x.mutex.Unlock()
+
+	return nil
+}
+
+// Load reads an index saved to path. A missing file is an empty index.
+func Load(path string) (*Index, error) {
+	x := NewIndex()
+
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return x, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var docs []Doc
+	if err := gob.NewDecoder(f).Decode(&docs); err != nil {
+		return nil, err
+	}
+
+	for _, d := range docs {
+		x.add(d)
+	}
+
+	return x, nil
+}
@@ -0,0 +1,338 @@
+package search
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BM25 parameters, the usual defaults.
+const (
+	k1 = 1.2
+	b  = 0.75
+)
+
+// Query is a search. Text is made of words and "quoted phrases", which must all match, and the operators by:user
+// and site:host. A word that splits into several terms, like node.js, is matched as a phrase.
+type Query struct {
+	Text string
+	// After and Before keep the items posted in that period, if they are set.
+	After  time.Time
+	Before time.Time
+	// MinScore keeps the stories with at least that many points. Comments are kept if their story is.
+	MinScore int
+	// Type keeps only the items of that type, such as story or comment.
+	Type string
+	// Limit is the most hits returned, from Offset. Defaults to 20.
+	Limit  int
+	Offset int
+}
+
+// Hit is a doc matching a query.
+type Hit struct {
+	Doc   Doc
+	Score float64
+	// Snippet is the part of the doc's text around the first match.
+	Snippet string
+}
+
+// Results are the hits for a query, best first.
+type Results struct {
+	// Total is the number of docs that matched, Hits only has the ones asked for.
+	Total int
+	Hits  []Hit
+}
+
+// parsed is a query's text, split up.
+type parsed struct {
+	// phrases are the terms that must match, a single word is a phrase of one term.
+	phrases [][]string
+	by      string
+	site    string
+}
+
+func parse(text string) parsed {
+	var p parsed
+
+	for text = strings.TrimSpace(text); text != ""; text = strings.TrimSpace(text) {
+		if text[0] == '"' {
+			end := strings.IndexByte(text[1:], '"')
+			if end < 0 {
+				end = len(text) - 1
+			}
+
+			if terms := tokenize(text[1 : end+1]); len(terms) > 0 {
+				p.phrases = append(p.phrases, terms)
+			}
+
+			text = text[min(end+2, len(text)):]
+			continue
+		}
+
+		word := text
+		if i := strings.IndexAny(text, " \t\n\""); i >= 0 {
+			word = text[:i]
+		}
+		text = text[len(word):]
+
+		lower := strings.ToLower(word)
+
+		switch {
+		case strings.HasPrefix(lower, "by:") && len(lower) > 3:
+			p.by = lower[3:]
+		case strings.HasPrefix(lower, "site:") && len(lower) > 5:
+			p.site = strings.TrimPrefix(lower[5:], "www.")
+		default:
+			if terms := tokenize(word); len(terms) > 0 {
+				p.phrases = append(p.phrases, terms)
+			}
+		}
+	}
+
+	return p
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+
+	return b
+}
+
+// Search returns the docs matching the query, ranked with BM25 over their fields. Field matches are weighted, a
+// match in a title counts for more than one in a comment.
+func (x *Index) Search(q Query) Results {
+	if q.Limit <= 0 {
+		q.Limit = 20
+	}
+	if q.Offset < 0 {
+		q.Offset = 0
+	}
+
+	p := parse(q.Text)
+
+	x.mutex.RLock()
+	defer x.mutex.RUnlock()
+
+	if len(p.phrases) == 0 && p.by == "" && p.site == "" {
+		return Results{}
+	}
+
+	var candidates map[int]bool
+
+	if len(p.phrases) > 0 {
+		candidates = x.matchPhrase(p.phrases[0])
+		for _, phrase := range p.phrases[1:] {
+			if len(candidates) == 0 {
+				break
+			}
+
+			matches := x.matchPhrase(phrase)
+			for id := range candidates {
+				if !matches[id] {
+					delete(candidates, id)
+				}
+			}
+		}
+	} else {
+		candidates = make(map[int]bool, len(x.docs))
+		for id := range x.docs {
+			candidates[id] = true
+		}
+	}
+
+	var hits []Hit
+
+	for id := range candidates {
+		d := x.docs[id]
+		if !x.keep(d, q, p) {
+			continue
+		}
+
+		hits = append(hits, Hit{Doc: *d, Score: x.score(id, p.phrases)})
+	}
+
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].Score != hits[j].Score {
+			return hits[i].Score > hits[j].Score
+		}
+
+		return hits[i].Doc.Time.After(hits[j].Doc.Time)
+	})
+
+	results := Results{Total: len(hits)}
+
+	if q.Offset < len(hits) {
+		hits = hits[q.Offset:]
+		if len(hits) > q.Limit {
+			hits = hits[:q.Limit]
+		}
+
+		for i := range hits {
+			hits[i].Snippet = snippet(plain(hits[i].Doc.Text), p.phrases)
+		}
+
+		results.Hits = hits
+	}
+
+	return results
+}
+
+// keep reports whether the doc passes the query's filters.
+func (x *Index) keep(d *Doc, q Query, p parsed) bool {
+	if q.Type != "" && d.Type != q.Type {
+		return false
+	}
+	if !q.After.IsZero() && d.Time.Before(q.After) {
+		return false
+	}
+	if !q.Before.IsZero() && !d.Time.Before(q.Before) {
+		return false
+	}
+	if p.by != "" && strings.ToLower(d.By) != p.by {
+		return false
+	}
+
+	story := d
+	if s, ok := x.docs[d.Story]; ok {
+		story = s
+	}
+
+	if p.site != "" && story.Host != p.site && !strings.HasSuffix(story.Host, "."+p.site) {
+		return false
+	}
+	if q.MinScore > 0 && story.Score < q.MinScore {
+		return false
+	}
+
+	return true
+}
+
+// matchPhrase returns the docs with the terms next to each other, in the same field.
+func (x *Index) matchPhrase(terms []string) map[int]bool {
+	matches := make(map[int]bool)
+
+	first := x.postings[terms[0]]
+
+	for id, p := range first {
+		if len(terms) == 1 {
+			matches[id] = true
+			continue
+		}
+
+		for field := 0; field < numFields && !matches[id]; field++ {
+			for _, pos := range p.positions[field] {
+				if x.phraseAt(id, field, pos, terms[1:]) {
+					matches[id] = true
+					break
+				}
+			}
+		}
+	}
+
+	return matches
+}
+
+// phraseAt reports whether terms follow position pos in the doc's field.
+func (x *Index) phraseAt(id, field int, pos int32, terms []string) bool {
+	for i, term := range terms {
+		p, ok := x.postings[term][id]
+		if !ok || !containsPos(p.positions[field], pos+int32(i)+1) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func containsPos(positions []int32, pos int32) bool {
+	i := sort.Search(len(positions), func(i int) bool {
+		return positions[i] >= pos
+	})
+
+	return i < len(positions) && positions[i] == pos
+}
+
+// score is the doc's BM25 score for the terms in the phrases, counting each field match by its weight.
+func (x *Index) score(id int, phrases [][]string) float64 {
+	n := float64(len(x.docs))
+	avgLen := float64(x.totalLen) / math.Max(n, 1)
+	length := float64(x.lengths[id])
+
+	score := 0.0
+
+	for _, phrase := range phrases {
+		for _, term := range phrase {
+			docs := x.postings[term]
+			p, ok := docs[id]
+			if !ok {
+				continue
+			}
+
+			tf := 0.0
+			for field, positions := range p.positions {
+				tf += fieldWeights[field] * float64(len(positions))
+			}
+
+			df := float64(len(docs))
+			idf := math.Log(1 + (n-df+0.5)/(df+0.5))
+
+			score += idf * tf * (k1 + 1) / (tf + k1*(1-b+b*length/math.Max(avgLen, 1)))
+		}
+	}
+
+	return score
+}
+
+const snippetLength = 200
+
+// snippet returns up to snippetLength bytes of text around the first word of the phrases it contains, on word
+// boundaries.
+func snippet(text string, phrases [][]string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if len(text) <= snippetLength {
+		return text
+	}
+
+	lower := strings.ToLower(text)
+	at := -1
+
+	// Lower casing can change the length of some characters, then the offsets wouldn't line up.
+	for _, phrase := range phrases {
+		if len(lower) != len(text) {
+			break
+		}
+
+		if i := strings.Index(lower, phrase[0]); i >= 0 && (at < 0 || i < at) {
+			at = i
+		}
+	}
+
+	start := 0
+	if at > snippetLength/4 {
+		start = at - snippetLength/4
+		// Start on a word.
+		if i := strings.IndexByte(text[start:], ' '); i >= 0 && i < at-start {
+			start += i + 1
+		}
+	}
+
+	end := start + snippetLength
+	if end >= len(text) {
+		end = len(text)
+	} else if i := strings.LastIndexByte(text[start:end], ' '); i > 0 {
+		end = start + i
+	}
+
+	s := text[start:end]
+	if start > 0 {
+		s = "…" + s
+	}
+	if end < len(text) {
+		s += "…"
+	}
+
+	return s
+}
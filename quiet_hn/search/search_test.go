@@ -0,0 +1,166 @@
+package search
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var day = time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+// setup returns an index of a few stories and comments.
+func setup() *Index {
+	x := NewIndex()
+
+	docs := []Doc{
+		{ID: 1, Type: "story", Title: "Go 1.15 is released", By: "rsc", Host: "blog.golang.org", Score: 500, Time: day},
+		{ID: 2, Type: "comment", Parent: 1, By: "pg", Text: "The linker is <i>much</i> faster now, great work.", Time: day.Add(time.Hour)},
+		{ID: 3, Type: "comment", Parent: 2, By: "tptacek", Text: "Faster linking matters for big binaries&#x2F;monorepos.", Time: day.Add(2 * time.Hour)},
+		{ID: 4, Type: "story", Title: "Why Rust is faster than Go", By: "steveklabnik", Host: "example.com", Score: 40, Time: day.Add(24 * time.Hour)},
+		{ID: 5, Type: "story", Title: "Ask HN: Is the Go linker slow?", By: "pg", Text: "<p>Builds take a minute.<p>Is the linker the problem?", Score: 12, Time: day.Add(48 * time.Hour)},
+		{ID: 6, Type: "story", Title: "Node.js 14 released", By: "nodejs", Host: "nodejs.org", Score: 300, Time: day.Add(72 * time.Hour)},
+	}
+
+	for _, d := range docs {
+		x.Add(d)
+	}
+
+	return x
+}
+
+func ids(r Results) []int {
+	var ret []int
+	for _, h := range r.Hits {
+		ret = append(ret, h.Doc.ID)
+	}
+
+	return ret
+}
+
+func TestIndex_Search(t *testing.T) {
+	x := setup()
+
+	tests := []struct {
+		name  string
+		query Query
+		want  []int
+	}{
+		{name: "shorter docs rank first", query: Query{Text: "released"}, want: []int{6, 1}},
+		{name: "every word must match", query: Query{Text: "go linker"}, want: []int{5}},
+		{name: "titles rank first", query: Query{Text: "linker"}, want: []int{5, 2}},
+		{name: "case and tags are ignored", query: Query{Text: "MUCH"}, want: []int{2}},
+		{name: "entities are decoded", query: Query{Text: "monorepos"}, want: []int{3}},
+		{name: "phrase", query: Query{Text: `"faster than go"`}, want: []int{4}},
+		{name: "phrase out of order", query: Query{Text: `"go faster"`}},
+		{name: "dotted word is a phrase", query: Query{Text: "node.js"}, want: []int{6}},
+		{name: "by", query: Query{Text: "by:PG"}, want: []int{5, 2}},
+		{name: "site matches subdomains", query: Query{Text: "site:golang.org"}, want: []int{3, 2, 1}},
+		{name: "site of the story", query: Query{Text: "faster site:golang.org"}, want: []int{3, 2}},
+		{name: "type", query: Query{Text: "faster", Type: "story"}, want: []int{4}},
+		{name: "after", query: Query{Text: "released", After: day.Add(time.Hour)}, want: []int{6}},
+		{name: "before", query: Query{Text: "released", Before: day.Add(time.Hour)}, want: []int{1}},
+		{name: "min score keeps comments on good stories", query: Query{Text: "faster", MinScore: 100}, want: []int{3, 2}},
+		{name: "no terms", query: Query{Text: "  "}},
+		{name: "limit", query: Query{Text: "released", Limit: 1}, want: []int{6}},
+		{name: "offset", query: Query{Text: "released", Offset: 1}, want: []int{1}},
+		{name: "offset past the end", query: Query{Text: "released", Offset: 2}},
+		{name: "negative offset", query: Query{Text: "released", Offset: -9000000000000000000}, want: []int{6, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ids(x.Search(tt.query))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("x.Search(%+v): want %v, got %v", tt.query, tt.want, got)
+			}
+		})
+	}
+}
+
+func TestIndex_Add(t *testing.T) {
+	x := setup()
+
+	if d, _ := x.Doc(3); d.Story != 1 {
+		t.Errorf("d.Story: want %d, got %d", 1, d.Story)
+	}
+
+	// An edited item replaces the old one.
+	x.Add(Doc{ID: 4, Type: "story", Title: "Why Zig is faster than C", Time: day})
+
+	if got := ids(x.Search(Query{Text: "rust"})); got != nil {
+		t.Errorf("expected the old title to be forgotten, got %v", got)
+	}
+
+	if got := ids(x.Search(Query{Text: "zig"})); !reflect.DeepEqual(got, []int{4}) {
+		t.Errorf("x.Search(zig): want %v, got %v", []int{4}, got)
+	}
+
+	if x.Len() != 6 {
+		t.Errorf("x.Len(): want %d, got %d", 6, x.Len())
+	}
+}
+
+func TestIndex_Save(t *testing.T) {
+	dir, err := ioutil.TempDir("", "search")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "index.gob")
+
+	x := setup()
+	if err := x.Save(path); err != nil {
+		t.Fatalf("x.Save() received an error: %s", err.Error())
+	}
+
+	loaded, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() received an error: %s", err.Error())
+	}
+
+	for _, q := range []string{"released", `"faster than go"`, "site:golang.org", "by:pg"} {
+		want, got := x.Search(Query{Text: q}), loaded.Search(Query{Text: q})
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("loaded.Search(%q):\nwant %+v\n got %+v", q, want, got)
+		}
+	}
+
+	// Nothing changed, so nothing is written.
+	os.Remove(path)
+
+	if err := loaded.Save(path); err != nil {
+		t.Fatalf("loaded.Save() received an error: %s", err.Error())
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected an unchanged index not to be saved")
+	}
+
+	if x, err := Load(filepath.Join(dir, "missing.gob")); err != nil || x.Len() != 0 {
+		t.Errorf("expected a missing file to load as an empty index, got %v", err)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	text := strings.Repeat("lorem ipsum ", 40) + "the needle is here " + strings.Repeat("dolor sit ", 40)
+
+	got := snippet(text, [][]string{{"needle"}})
+
+	if !strings.Contains(got, "the needle is here") {
+		t.Errorf("expected the snippet to contain the match, got %q", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("expected the snippet to be marked as cut at both ends, got %q", got)
+	}
+	if len(got) > snippetLength+len("……") {
+		t.Errorf("expected at most %d bytes, got %d", snippetLength, len(got))
+	}
+
+	if got := snippet("short  <b>text</b>", nil); got != "short <b>text</b>" {
+		t.Errorf("snippet(): want %q, got %q", "short <b>text</b>", got)
+	}
+}
@@ -0,0 +1,45 @@
+package search
+
+import (
+	"html"
+	"strings"
+	"unicode"
+)
+
+// plain strips the tags from HN's HTML text and unescapes its entities.
+func plain(text string) string {
+	if !strings.ContainsAny(text, "<&") {
+		return text
+	}
+
+	var b strings.Builder
+
+	for len(text) > 0 {
+		start := strings.IndexByte(text, '<')
+		if start < 0 {
+			b.WriteString(html.UnescapeString(text))
+			break
+		}
+
+		b.WriteString(html.UnescapeString(text[:start]))
+
+		end := strings.IndexByte(text[start:], '>')
+		if end < 0 {
+			b.WriteString(html.UnescapeString(text[start:]))
+			break
+		}
+
+		// Tags separate words, <p> especially.
+		b.WriteByte(' ')
+		text = text[start+end+1:]
+	}
+
+	return b.String()
+}
+
+// tokenize splits text into lower case words, keeping letters and digits only.
+func tokenize(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
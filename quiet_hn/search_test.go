@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestParseSearch(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantPage   int
+		wantOffset int
+		wantErr    bool
+	}{
+		{name: "first page", query: "q=go", wantPage: 1, wantOffset: 0},
+		{name: "second page", query: "q=go&page=2", wantPage: 2, wantOffset: searchPageSize},
+		{name: "last page", query: "q=go&page=107374182", wantPage: maxSearchPage, wantOffset: (maxSearchPage - 1) * searchPageSize},
+		{name: "page past the last", query: "q=go&page=107374183", wantErr: true},
+		{name: "page that would overflow", query: "q=go&page=500000000000000000", wantErr: true},
+		{name: "page zero", query: "q=go&page=0", wantErr: true},
+		{name: "bad date", query: "q=go&after=yesterday", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			query, page, err := parseSearch(values)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("parseSearch(%q): want an error, got page %d", tt.query, page)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseSearch(%q): %v", tt.query, err)
+			}
+			if page != tt.wantPage {
+				t.Errorf("page: want %d, got %d", tt.wantPage, page)
+			}
+			if query.Offset != tt.wantOffset {
+				t.Errorf("offset: want %d, got %d", tt.wantOffset, query.Offset)
+			}
+		})
+	}
+}
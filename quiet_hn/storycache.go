@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"log"
+	"sync"
+	"time"
+)
+
+// cacheMetrics are published on /debug/vars, with a map of counters for each feed.
+var cacheMetrics = expvar.NewMap("story_cache")
+
+type storyCacheOptions struct {
+	client     *hn.Client
+	batch      hn.BatchOptions
+	rules      *ruleSet
+	numStories int
+	// page is which page of numStories stories to cache, starting from 1.
+	page int
+	// ttl is how long stories are served without being refreshed. Stale stories are still served while they are
+	// refreshed in the background.
+	ttl time.Duration
+	// refresh is how often the stories are refreshed in the background, whether anyone asked for them or not. Zero
+	// only refreshes them when they are asked for.
+	refresh time.Duration
+}
+
+// refreshCall is a refresh in progress. Everyone asking for the stories while it runs waits for the same call.
+type refreshCall struct {
+	done chan struct{}
+	err  error
+}
+
+// storyCache holds a feed's stories and keeps them fresh. Requests are always served from the cache once it has been
+// filled, a failed refresh keeps the stories from the last one that worked.
+type storyCache struct {
+	feed feed
+	opts storyCacheOptions
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	done   chan struct{}
+
+	mutex    sync.Mutex
+	cache    []item
+	hidden   []filtered
+	updated  time.Time
+	expires  time.Time
+	inFlight *refreshCall
+	// again is set when the stories expire during a refresh, which may have started with outdated rules.
+	again bool
+
+	metrics *expvar.Map
+}
+
+// newStoryCache returns the cache of a page of a feed's stories and starts refreshing it in the background. Close
+// stops it.
+func newStoryCache(f feed, opts storyCacheOptions) *storyCache {
+	if opts.page < 1 {
+		opts.page = 1
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	sc := &storyCache{
+		feed:    f,
+		opts:    opts,
+		ctx:     ctx,
+		cancel:  cancel,
+		done:    make(chan struct{}),
+		metrics: new(expvar.Map).Init(),
+	}
+
+	cacheMetrics.Set(sc.name(), sc.metrics)
+
+	if opts.refresh > 0 {
+		go sc.run()
+	} else {
+		close(sc.done)
+	}
+
+	return sc
+}
+
+// name identifies the page the cache holds in its metrics.
+func (sc *storyCache) name() string {
+	return fmt.Sprintf("%s?page=%d&n=%d", sc.feed.Name, sc.opts.page, sc.opts.numStories)
+}
+
+// run refreshes the stories every refresh interval until the cache is closed.
+func (sc *storyCache) run() {
+	defer close(sc.done)
+
+	ticker := time.NewTicker(sc.opts.refresh)
+	defer ticker.Stop()
+
+	for {
+		sc.wait(sc.refresh())
+
+		select {
+		case <-sc.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Close stops the background refreshes, cancelling one that is running, and waits for them to finish.
+func (sc *storyCache) Close() {
+	sc.cancel()
+	<-sc.done
+
+	cacheMetrics.Delete(sc.name())
+}
+
+// evict removes the cache's metrics, for a page cache that makes room for another. Unlike Close it doesn't cancel a
+// refresh that is running, requests that are waiting for it or still hold the cache keep being served. Page caches
+// aren't refreshed in the background, so there is nothing else to stop.
+func (sc *storyCache) evict() {
+	cacheMetrics.Delete(sc.name())
+}
+
+// stories returns the cached stories along with when they were fetched. Stale stories are returned straight away
+// while they are refreshed, only an empty cache makes the caller wait.
+func (sc *storyCache) stories() ([]item, time.Time, error) {
+	sc.mutex.Lock()
+
+	if sc.updated.IsZero() {
+		sc.mutex.Unlock()
+		sc.metrics.Add("misses", 1)
+
+		if err := sc.wait(sc.refresh()); err != nil {
+			return nil, time.Time{}, err
+		}
+
+		sc.mutex.Lock()
+	} else if time.Now().Before(sc.expires) {
+		sc.metrics.Add("hits", 1)
+	} else {
+		sc.metrics.Add("stale_hits", 1)
+		sc.mutex.Unlock()
+
+		sc.refresh()
+
+		sc.mutex.Lock()
+	}
+
+	defer sc.mutex.Unlock()
+
+	return sc.cache, sc.updated, nil
+}
+
+// wait waits for a refresh to finish and returns its error.
+func (sc *storyCache) wait(call *refreshCall) error {
+	<-call.done
+	return call.err
+}
+
+// refresh starts fetching the stories again, unless that is already happening, and returns the refresh in progress.
+func (sc *storyCache) refresh() *refreshCall {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	if sc.inFlight != nil {
+		return sc.inFlight
+	}
+
+	call := &refreshCall{done: make(chan struct{})}
+	sc.inFlight = call
+
+	go func() {
+		start := time.Now()
+
+		// Every page is counted from the top, so the stories of the pages before it are needed too. They are
+		// usually in the item cache already.
+		o := sc.opts
+		stories, hidden, err := getTopStories(sc.ctx, o.client, sc.feed, o.rules, o.page*o.numStories, o.batch)
+
+		if skip := (o.page - 1) * o.numStories; skip < len(stories) {
+			stories = stories[skip:]
+		} else {
+			stories = nil
+		}
+
+		latency := time.Since(start)
+
+		sc.metrics.Add("refreshes", 1)
+		sc.metrics.Add("refresh_latency_total_ms", latency.Milliseconds())
+
+		last := new(expvar.Int)
+		last.Set(latency.Milliseconds())
+		sc.metrics.Set("refresh_latency_last_ms", last)
+
+		sc.mutex.Lock()
+		if err == nil {
+			sc.cache = stories
+			sc.hidden = hidden
+			sc.updated = time.Now()
+			sc.expires = sc.updated.Add(o.ttl)
+		}
+		sc.inFlight = nil
+		again := sc.again
+		sc.again = false
+		sc.mutex.Unlock()
+
+		if again {
+			sc.refresh()
+		}
+
+		if err != nil {
+			sc.metrics.Add("refresh_errors", 1)
+
+			if sc.ctx.Err() == nil {
+				log.Printf("failed to refresh the %s stories, keeping the last ones: %v", sc.feed.Name, err)
+			}
+		}
+
+		call.err = err
+		close(call.done)
+	}()
+
+	return call
+}
+
+// filtered returns the stories the rules hid the last time the cache was filled.
+func (sc *storyCache) filtered() []filtered {
+	sc.mutex.Lock()
+	defer sc.mutex.Unlock()
+
+	return sc.hidden
+}
+
+// expire marks the stories as stale and refreshes them, for instance because the rules changed.
+func (sc *storyCache) expire() {
+	sc.mutex.Lock()
+	sc.expires = time.Time{}
+	sc.again = sc.inFlight != nil
+	sc.mutex.Unlock()
+
+	sc.refresh()
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"github.com/jwambugu/gophercises/quiet_hn/hn/hntest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeHN is a fake HN API that counts how often the top stories were listed and can be made to fail.
+type fakeHN struct {
+	*httptest.Server
+	handler *hntest.Handler
+	lists   int32
+	failing int32
+}
+
+func newFakeHN(opts hntest.Options) *fakeHN {
+	f := &fakeHN{handler: hntest.NewHandler(hntest.Generate(1, 60), opts)}
+
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/topstories.json") {
+			atomic.AddInt32(&f.lists, 1)
+		}
+		if atomic.LoadInt32(&f.failing) == 1 {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+
+		f.handler.ServeHTTP(w, r)
+	}))
+
+	return f
+}
+
+func (f *fakeHN) client() *hn.Client {
+	return hn.NewClient(hn.WithBaseURL(f.URL), hn.WithRetries(0, time.Millisecond))
+}
+
+func (f *fakeHN) fail(failing bool) {
+	var v int32
+	if failing {
+		v = 1
+	}
+
+	atomic.StoreInt32(&f.failing, v)
+}
+
+func testCacheOptions(client *hn.Client) storyCacheOptions {
+	return storyCacheOptions{client: client, numStories: 5, ttl: time.Hour}
+}
+
+func TestStoryCache_singleflight(t *testing.T) {
+	api := newFakeHN(hntest.Options{Latency: 50 * time.Millisecond})
+	defer api.Close()
+
+	sc := newStoryCache(feeds[0], testCacheOptions(api.client()))
+	defer sc.Close()
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			if stories, _, err := sc.stories(); err != nil || len(stories) == 0 {
+				t.Errorf("sc.stories(): want stories, got %d and %v", len(stories), err)
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&api.lists); n != 1 {
+		t.Errorf("expected the requests to share %d refresh, got %d", 1, n)
+	}
+}
+
+func TestStoryCache_keepsStoriesOnError(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	opts := testCacheOptions(api.client())
+	opts.ttl = time.Nanosecond
+
+	sc := newStoryCache(feeds[0], opts)
+	defer sc.Close()
+
+	want, updated, err := sc.stories()
+	if err != nil {
+		t.Fatalf("sc.stories() received an error: %s", err)
+	}
+
+	api.fail(true)
+
+	// The stories are stale, so this starts a refresh that fails.
+	if _, _, err := sc.stories(); err != nil {
+		t.Fatalf("expected stale stories to be served, got %v", err)
+	}
+	if err := sc.wait(sc.refresh()); err == nil {
+		t.Fatalf("expected the refresh to fail")
+	}
+
+	got, gotUpdated, err := sc.stories()
+	if err != nil {
+		t.Fatalf("expected the last stories to be kept, got %v", err)
+	}
+	if len(got) != len(want) || got[0].ID != want[0].ID || !gotUpdated.Equal(updated) {
+		t.Errorf("expected the stories from %v to be kept, got %d stories from %v", updated, len(got), gotUpdated)
+	}
+
+	// An empty cache has nothing to fall back on.
+	empty := newStoryCache(feeds[0], opts)
+	defer empty.Close()
+
+	if _, _, err := empty.stories(); err == nil {
+		t.Errorf("expected an error from an empty cache that can't be filled")
+	}
+}
+
+func TestStoryCache_Close(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	opts := testCacheOptions(api.client())
+	opts.refresh = 10 * time.Millisecond
+
+	sc := newStoryCache(feeds[0], opts)
+
+	deadline := time.Now().Add(2 * time.Second)
+	for atomic.LoadInt32(&api.lists) < 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the stories to be refreshed in the background")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	sc.Close()
+
+	if cacheMetrics.Get(sc.name()) != nil {
+		t.Errorf("expected the metrics of a closed cache to be removed")
+	}
+
+	n := atomic.LoadInt32(&api.lists)
+	time.Sleep(50 * time.Millisecond)
+
+	if got := atomic.LoadInt32(&api.lists); got != n {
+		t.Errorf("expected no refreshes after Close, got %d more", got-n)
+	}
+}
+
+func TestStoryCache_CloseCancelsRefresh(t *testing.T) {
+	api := newFakeHN(hntest.Options{Latency: time.Minute})
+	defer api.Close()
+
+	sc := newStoryCache(feeds[0], testCacheOptions(api.client()))
+
+	call := sc.refresh()
+
+	closed := make(chan struct{})
+	go func() {
+		sc.Close()
+		close(closed)
+	}()
+
+	select {
+	case <-call.done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Close to cancel the refresh")
+	}
+
+	<-closed
+}
+
+func TestPages_get(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	p := newPages(feeds[0], testCacheOptions(api.client()))
+	defer p.Close()
+
+	first := p.get(pageKey{page: 2, n: 5})
+	second := p.get(pageKey{page: 3, n: 5})
+
+	for i := 0; i < maxPageCaches-2; i++ {
+		p.get(pageKey{page: 4 + i, n: 1})
+	}
+
+	// Using the first page makes the second the least recently used.
+	if got := p.get(pageKey{page: 2, n: 5}); got != first {
+		t.Fatalf("expected the cached page to be returned")
+	}
+
+	p.get(pageKey{page: 100, n: 1})
+
+	if got := p.get(pageKey{page: 2, n: 5}); got != first {
+		t.Errorf("expected the recently used page to be kept")
+	}
+	if got := p.get(pageKey{page: 3, n: 5}); got == second {
+		t.Errorf("expected the least recently used page to be evicted")
+	}
+	if got := len(p.caches); got != maxPageCaches+1 {
+		t.Errorf("expected %d page caches and the front page, got %d", maxPageCaches, got)
+	}
+}
+
+func TestPages_evictKeepsWaiters(t *testing.T) {
+	api := newFakeHN(hntest.Options{Latency: 100 * time.Millisecond})
+	defer api.Close()
+
+	p := newPages(feeds[0], testCacheOptions(api.client()))
+	defer p.Close()
+
+	sc := p.get(pageKey{page: 2, n: 5})
+	call := sc.refresh()
+
+	// Fill the page caches until the page being refreshed is evicted.
+	for i := 0; i < maxPageCaches; i++ {
+		p.get(pageKey{page: 3 + i, n: 1})
+	}
+
+	if _, ok := p.caches[pageKey{page: 2, n: 5}]; ok {
+		t.Fatalf("expected the page to be evicted")
+	}
+
+	if err := sc.wait(call); err != nil {
+		t.Errorf("expected the refresh of an evicted page to finish, got %v", err)
+	}
+	if stories, _, err := sc.stories(); err != nil || len(stories) == 0 {
+		t.Errorf("expected an evicted page to still serve its stories, got %d and %v", len(stories), err)
+	}
+}
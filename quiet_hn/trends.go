@@ -0,0 +1,267 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/jwambugu/gophercises/quiet_hn/history"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// record snapshots the ranks, scores and comment counts of the top n stories on HN every interval, until ctx is
+// cancelled.
+func record(ctx context.Context, client *hn.Client, store *history.Store, n int, interval time.Duration, batch hn.BatchOptions) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		if err := snapshot(ctx, client, store, n, batch); err != nil && ctx.Err() == nil {
+			log.Printf("failed to snapshot the top stories: %v", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func snapshot(ctx context.Context, client *hn.Client, store *history.Store, n int, batch hn.BatchOptions) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Minute)
+	defer cancel()
+
+	now := time.Now()
+
+	ids, err := client.TopItemsContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if len(ids) > n {
+		ids = ids[:n]
+	}
+
+	var samples []history.Sample
+
+	for i, r := range client.GetItems(ctx, ids, batch) {
+		if r.Err != nil {
+			continue
+		}
+
+		samples = append(samples, history.Sample{
+			ID:       r.Item.ID,
+			Title:    r.Item.Title,
+			Rank:     i + 1,
+			Score:    r.Item.Score,
+			Comments: r.Item.Descendants,
+		})
+	}
+
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return store.Add(now, samples)
+}
+
+// trendFuncs are the template functions the trend pages use.
+var trendFuncs = template.FuncMap{
+	"duration": func(d time.Duration) string {
+		d = d.Round(time.Minute)
+		if d < time.Minute {
+			return "under a minute"
+		}
+		if d < time.Hour {
+			return plural(int(d/time.Minute), "minute")
+		}
+
+		return fmt.Sprintf("%dh %02dm", d/time.Hour, d%time.Hour/time.Minute)
+	},
+}
+
+type trendsData struct {
+	Enabled bool
+	Hours   int
+	Risers  []history.Rise
+	Day     time.Time
+	Prev    string
+	Next    string
+	Peaks   []history.Peak
+	Time    time.Duration
+}
+
+// trendsHandler renders /trends with the stories that rose fastest in the last ?hours= hours and the peak positions
+// of the stories ranked on ?day=.
+func trendsHandler(store *history.Store, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		data := trendsData{Enabled: store != nil, Hours: 1}
+
+		q := r.URL.Query()
+
+		if s := q.Get("hours"); s != "" {
+			hours, err := strconv.Atoi(s)
+			if err != nil || hours < 1 || hours > 24*7 {
+				http.Error(w, "hours must be a number from 1 to 168", http.StatusBadRequest)
+				return
+			}
+
+			data.Hours = hours
+		}
+
+		today := midnight(start)
+		data.Day = today
+
+		if s := q.Get("day"); s != "" {
+			day, err := time.ParseInLocation("2006-01-02", s, time.Local)
+			if err != nil {
+				http.Error(w, "day must be a date like 2006-01-02", http.StatusBadRequest)
+				return
+			}
+
+			data.Day = day
+		}
+
+		if store != nil {
+			data.Risers = store.Risers(start.Add(-time.Duration(data.Hours)*time.Hour), history.FrontPage)
+
+			next := data.Day.AddDate(0, 0, 1)
+			data.Peaks = store.Peaks(data.Day, next)
+
+			if len(data.Peaks) > history.FrontPage {
+				data.Peaks = data.Peaks[:history.FrontPage]
+			}
+
+			data.Prev = fmt.Sprintf("/trends?hours=%d&day=%s", data.Hours, data.Day.AddDate(0, 0, -1).Format("2006-01-02"))
+			if next.Before(today) || next.Equal(today) {
+				data.Next = fmt.Sprintf("/trends?hours=%d&day=%s", data.Hours, next.Format("2006-01-02"))
+			}
+		}
+
+		data.Time = time.Now().Sub(start)
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// midnight returns the start of the local day t is in.
+func midnight(t time.Time) time.Time {
+	y, m, d := t.Date()
+
+	return time.Date(y, m, d, 0, 0, 0, 0, t.Location())
+}
+
+const (
+	chartWidth  = 600
+	chartHeight = 120
+)
+
+type historyData struct {
+	Enabled bool
+	ID      int
+	Title   string
+	Points  []history.Point
+	// RankLine and ScoreLine are the points of the charts' polylines.
+	RankLine  string
+	ScoreLine string
+	Best      int
+	MaxScore  int
+	First     time.Time
+	Last      time.Time
+	Time      time.Duration
+}
+
+// historyHandler renders /history/{id} with how the story's rank and score changed over time.
+func historyHandler(store *history.Store, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, "/history/"))
+		if err != nil || id <= 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		data := historyData{Enabled: store != nil, ID: id}
+
+		if store != nil {
+			data.Title = store.Title(id)
+			data.Points = store.History(id)
+		}
+
+		if len(data.Points) > 0 {
+			data.First = data.Points[0].Time
+			data.Last = data.Points[len(data.Points)-1].Time
+		}
+
+		worst := 1
+
+		for _, p := range data.Points {
+			worst = maxInt(worst, p.Rank)
+
+			if data.Best == 0 || p.Rank < data.Best {
+				data.Best = p.Rank
+			}
+			if p.Score > data.MaxScore {
+				data.MaxScore = p.Score
+			}
+		}
+
+		// Rank 1 is drawn at the top, the score grows upwards.
+		data.RankLine = polyline(data.Points, func(p history.Point) float64 {
+			return float64(p.Rank-1) / float64(maxInt(worst-1, 1))
+		})
+		data.ScoreLine = polyline(data.Points, func(p history.Point) float64 {
+			return 1 - float64(p.Score)/float64(maxInt(data.MaxScore, 1))
+		})
+
+		data.Time = time.Now().Sub(start)
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// polyline lays the points out evenly in time across the chart. y returns how far down the chart a point is, from 0
+// to 1.
+func polyline(points []history.Point, y func(history.Point) float64) string {
+	if len(points) == 0 {
+		return ""
+	}
+
+	first, last := points[0].Time, points[len(points)-1].Time
+	span := last.Sub(first)
+
+	coords := make([]string, len(points))
+
+	for i, p := range points {
+		x := 0.0
+		if span > 0 {
+			x = float64(p.Time.Sub(first)) / float64(span) * chartWidth
+		}
+
+		coords[i] = fmt.Sprintf("%.1f,%.1f", x, y(p)*chartHeight)
+	}
+
+	return strings.Join(coords, " ")
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
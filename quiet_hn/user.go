@@ -0,0 +1,347 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"github.com/jwambugu/gophercises/quiet_hn/prefs"
+	"html/template"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const userCookie = "quiet_hn_user"
+
+// feedChoices are the feeds a reader can pick for their front page.
+var feedChoices = []string{"top", "best", "new"}
+
+// users identifies readers by a signed cookie holding a random id, and keeps their preferences on the server. A nil
+// *users leaves every reader anonymous.
+type users struct {
+	store  *prefs.Store
+	signer *prefs.Signer
+}
+
+// id returns the id in the reader's cookie, if it has a valid one.
+func (u *users) id(r *http.Request) (string, bool) {
+	if u == nil {
+		return "", false
+	}
+
+	c, err := r.Cookie(userCookie)
+	if err != nil {
+		return "", false
+	}
+
+	return u.signer.Verify(c.Value)
+}
+
+// get returns the reader's preferences. Anonymous readers, and readers whose preferences can't be read, get the
+// defaults.
+func (u *users) get(r *http.Request) prefs.Prefs {
+	id, ok := u.id(r)
+	if !ok {
+		return prefs.Prefs{}
+	}
+
+	p, err := u.store.Get(id)
+	if err != nil {
+		log.Printf("failed to load preferences: %v", err)
+	}
+
+	return p
+}
+
+// update changes the reader's preferences with fn and returns them. Anonymous readers are given an id first.
+func (u *users) update(w http.ResponseWriter, r *http.Request, fn func(*prefs.Prefs)) (prefs.Prefs, error) {
+	id, ok := u.id(r)
+	if !ok {
+		var err error
+		if id, err = prefs.NewID(); err != nil {
+			return prefs.Prefs{}, err
+		}
+	}
+
+	p, err := u.store.Update(id, fn)
+	if err != nil {
+		return prefs.Prefs{}, err
+	}
+
+	// The cookie is set again on every change, so it only expires for readers who stop coming back.
+	http.SetCookie(w, &http.Cookie{
+		Name:     userCookie,
+		Value:    u.signer.Sign(id),
+		Path:     "/",
+		MaxAge:   365 * 24 * 60 * 60,
+		Secure:   r.TLS != nil,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+
+	return p, nil
+}
+
+// forget deletes the reader's preferences and cookie.
+func (u *users) forget(w http.ResponseWriter, r *http.Request) error {
+	http.SetCookie(w, &http.Cookie{Name: userCookie, Path: "/", MaxAge: -1, Secure: r.TLS != nil, HttpOnly: true})
+
+	id, ok := u.id(r)
+	if !ok {
+		return nil
+	}
+
+	return u.store.Delete(id)
+}
+
+// filterRules turns a reader's filters into hide rules, one for each kind of filter so a story matching any of them
+// is hidden.
+func filterRules(f prefs.Filters) *ruleSet {
+	rs := &ruleSet{}
+
+	if len(f.Domains) > 0 {
+		rs.rules = append(rs.rules, &rule{Name: "your domain filter", Action: actionHide, Domains: f.Domains})
+	}
+
+	if len(f.Keywords) > 0 {
+		rs.rules = append(rs.rules, &rule{Name: "your keyword filter", Action: actionHide, Keywords: f.Keywords})
+	}
+
+	if f.MinScore > 0 {
+		maxScore := f.MinScore - 1
+		rs.rules = append(rs.rules, &rule{Name: "your score filter", Action: actionHide, MaxScore: &maxScore})
+	}
+
+	return rs
+}
+
+// personalize returns a copy of stories with the reader's filters applied and their read and saved stories marked.
+// It also returns how many stories were hidden.
+func personalize(stories []item, p prefs.Prefs) ([]item, int) {
+	rules := filterRules(p.Filters)
+	now := time.Now()
+
+	ret := make([]item, 0, len(stories))
+
+	for _, story := range stories {
+		if _, rule := rules.apply(story, now); rule != "" {
+			continue
+		}
+
+		story.Read = p.IsRead(story.ID)
+		story.Saved = p.IsSaved(story.ID)
+		ret = append(ret, story)
+	}
+
+	return ret, len(stories) - len(ret)
+}
+
+// idFrom returns the story id at the end of the request's path.
+func idFrom(r *http.Request, prefix string) (int, bool) {
+	id, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, prefix))
+	return id, err == nil && id > 0
+}
+
+// localRedirect redirects to back if it is a path on this site, and to fallback otherwise.
+func localRedirect(w http.ResponseWriter, r *http.Request, back, fallback string) {
+	if !strings.HasPrefix(back, "/") || strings.HasPrefix(back, "//") || strings.HasPrefix(back, "/\\") {
+		back = fallback
+	}
+
+	http.Redirect(w, r, back, http.StatusSeeOther)
+}
+
+// readHandler marks /read/{id} as read and redirects to the story, so stories fade once they've been visited. Reads
+// are only recorded for readers who already have preferences, following a link doesn't give anyone a cookie.
+func readHandler(client *hn.Client, u *users) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, ok := idFrom(r, "/read/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// The item is almost always in the cache, it was just on the front page.
+		hnItem, err := client.GetItemContext(ctx, id)
+		if err != nil {
+			var ne *hn.NullItemError
+			if errors.As(err, &ne) {
+				http.NotFound(w, r)
+				return
+			}
+
+			http.Error(w, "Failed to load the item", http.StatusBadGateway)
+			return
+		}
+
+		if _, known := u.id(r); known {
+			_, err := u.update(w, r, func(p *prefs.Prefs) {
+				p.MarkRead(id, time.Now())
+			})
+			if err != nil {
+				log.Printf("failed to mark %d as read: %v", id, err)
+			}
+		}
+
+		http.Redirect(w, r, parseHNItem(hnItem).Link(), http.StatusFound)
+	}
+}
+
+// saveHandler saves or unsaves the story in a POST to /save/{id}, then sends the reader back where they were.
+func saveHandler(u *users) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "Stories are saved with a POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		id, ok := idFrom(r, "/save/")
+		if !ok {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, err := u.update(w, r, func(p *prefs.Prefs) {
+			p.Save(id, time.Now())
+		})
+		if err != nil {
+			http.Error(w, "Failed to save the story", http.StatusInternalServerError)
+			return
+		}
+
+		localRedirect(w, r, r.FormValue("back"), "/saved")
+	}
+}
+
+type savedData struct {
+	Stories []item
+	Time    time.Duration
+}
+
+// savedHandler renders /saved with the reader's saved stories, fetched through the shared item cache.
+func savedHandler(client *hn.Client, u *users, batch hn.BatchOptions, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+
+		p := u.get(r)
+
+		ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+		defer cancel()
+
+		var data savedData
+
+		for _, res := range client.GetItems(ctx, p.SavedIDs(), batch) {
+			if res.Err != nil {
+				continue
+			}
+
+			story := parseHNItem(res.Item)
+			story.Read = p.IsRead(story.ID)
+			story.Saved = true
+			data.Stories = append(data.Stories, story)
+		}
+
+		data.Time = time.Now().Sub(start)
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+type prefsData struct {
+	Prefs    prefs.Prefs
+	Feeds    []string
+	Domains  string
+	Keywords string
+	Saved    bool
+	Err      string
+}
+
+// prefsHandler shows the reader's preferences on /prefs and changes them when the form is posted.
+func prefsHandler(u *users, tpl *template.Template) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		data := prefsData{Feeds: feedChoices, Prefs: u.get(r)}
+
+		if r.Method == http.MethodPost {
+			if r.FormValue("forget") != "" {
+				if err := u.forget(w, r); err != nil {
+					http.Error(w, "Failed to forget your preferences", http.StatusInternalServerError)
+					return
+				}
+
+				http.Redirect(w, r, "/", http.StatusSeeOther)
+				return
+			}
+
+			filters, feedName, err := parsePrefsForm(r)
+			if err == nil {
+				data.Prefs, err = u.update(w, r, func(p *prefs.Prefs) {
+					p.Feed = feedName
+					p.Filters = filters
+				})
+			}
+
+			if err != nil {
+				data.Err = err.Error()
+			} else {
+				data.Saved = true
+			}
+		}
+
+		data.Domains = strings.Join(data.Prefs.Filters.Domains, ", ")
+		data.Keywords = strings.Join(data.Prefs.Filters.Keywords, ", ")
+
+		if err := tpl.Execute(w, data); err != nil {
+			http.Error(w, "Failed to process the template", http.StatusInternalServerError)
+			return
+		}
+	}
+}
+
+// parsePrefsForm reads the preferences form.
+func parsePrefsForm(r *http.Request) (prefs.Filters, string, error) {
+	var filters prefs.Filters
+
+	feedName := r.FormValue("feed")
+	if feedName == "top" {
+		feedName = ""
+	}
+	if feedName != "" && !contains(feedChoices, feedName) {
+		return filters, "", errors.New("that isn't a feed you can pick")
+	}
+
+	filters.Domains = splitList(r.FormValue("domains"))
+	filters.Keywords = splitList(r.FormValue("keywords"))
+
+	if s := strings.TrimSpace(r.FormValue("min_score")); s != "" {
+		minScore, err := strconv.Atoi(s)
+		if err != nil || minScore < 0 {
+			return filters, "", errors.New("the minimum score must be a positive number")
+		}
+
+		filters.MinScore = minScore
+	}
+
+	return filters, feedName, nil
+}
+
+// splitList splits a comma or newline separated list, dropping empty entries.
+func splitList(s string) []string {
+	var list []string
+
+	for _, v := range strings.FieldsFunc(s, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
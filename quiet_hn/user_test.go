@@ -0,0 +1,236 @@
+package main
+
+import (
+	"github.com/jwambugu/gophercises/quiet_hn/hn"
+	"github.com/jwambugu/gophercises/quiet_hn/hn/hntest"
+	"github.com/jwambugu/gophercises/quiet_hn/prefs"
+	"html/template"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// testUsers returns users whose preferences are kept in a temporary directory.
+func testUsers(t *testing.T) *users {
+	t.Helper()
+
+	store, err := prefs.NewStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("prefs.NewStore() received an error: %s", err)
+	}
+
+	return &users{store: store, signer: prefs.NewSigner([]byte("test key"))}
+}
+
+// newReader returns the cookie of a reader who already has preferences, and their id.
+func newReader(t *testing.T, u *users) (*http.Cookie, string) {
+	t.Helper()
+
+	id, err := prefs.NewID()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &http.Cookie{Name: userCookie, Value: u.signer.Sign(id)}, id
+}
+
+// userCookieIn returns the reader's cookie set by a response, if any.
+func userCookieIn(w *httptest.ResponseRecorder) *http.Cookie {
+	for _, c := range w.Result().Cookies() {
+		if c.Name == userCookie {
+			return c
+		}
+	}
+
+	return nil
+}
+
+// serveUser serves a request made by the reader with cookie, or by an anonymous one if cookie is nil.
+func serveUser(h http.HandlerFunc, method, target string, form url.Values, cookie *http.Cookie) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(form.Encode()))
+	if form != nil {
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+
+	w := httptest.NewRecorder()
+	h(w, r)
+
+	return w
+}
+
+// topStory returns the id and link of the top story on the fake API.
+func topStory(t *testing.T, client *hn.Client) (int, string) {
+	t.Helper()
+
+	ids, err := client.TopItems()
+	if err != nil {
+		t.Fatalf("client.TopItems() received an error: %s", err)
+	}
+
+	story, err := client.GetItem(ids[0])
+	if err != nil {
+		t.Fatalf("client.GetItem() received an error: %s", err)
+	}
+
+	return ids[0], parseHNItem(story).Link()
+}
+
+func TestReadHandler(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	client := api.client()
+	u := testUsers(t)
+	h := readHandler(client, u)
+
+	storyID, link := topStory(t, client)
+	target := "/read/" + strconv.Itoa(storyID)
+
+	t.Run("anonymous", func(t *testing.T) {
+		w := serveUser(h, http.MethodGet, target, nil, nil)
+		if w.Code != http.StatusFound || w.Header().Get("Location") != link {
+			t.Errorf("expected a redirect to %s, got %d to %q", link, w.Code, w.Header().Get("Location"))
+		}
+		if c := userCookieIn(w); c != nil {
+			t.Errorf("expected anonymous readers not to be given an id, got %v", c)
+		}
+	})
+
+	t.Run("known reader", func(t *testing.T) {
+		cookie, id := newReader(t, u)
+
+		w := serveUser(h, http.MethodGet, "https://example.com"+target, nil, cookie)
+		if w.Code != http.StatusFound {
+			t.Fatalf("status: want %d, got %d", http.StatusFound, w.Code)
+		}
+
+		p, err := u.store.Get(id)
+		if err != nil || !p.IsRead(storyID) {
+			t.Errorf("expected the story to be marked as read, got %v and %v", p.Read, err)
+		}
+		if c := userCookieIn(w); c == nil || !c.Secure {
+			t.Errorf("expected the cookie to be renewed as a secure cookie over TLS, got %v", c)
+		}
+	})
+
+	t.Run("forged cookie", func(t *testing.T) {
+		cookie := &http.Cookie{Name: userCookie, Value: "0123456789abcdef0123456789abcdef.forged"}
+
+		if c := userCookieIn(serveUser(h, http.MethodGet, target, nil, cookie)); c != nil {
+			t.Errorf("expected a forged cookie not to be replaced, got %v", c)
+		}
+	})
+
+	for _, path := range []string{"/read/", "/read/abc", "/read/-1"} {
+		if w := serveUser(h, http.MethodGet, path, nil, nil); w.Code != http.StatusNotFound {
+			t.Errorf("%s: want %d, got %d", path, http.StatusNotFound, w.Code)
+		}
+	}
+}
+
+func TestSaveHandler(t *testing.T) {
+	api := newFakeHN(hntest.Options{})
+	defer api.Close()
+
+	client := api.client()
+	u := testUsers(t)
+	save := saveHandler(u)
+	saved := savedHandler(client, u, hn.BatchOptions{}, template.Must(template.ParseFiles("saved.html")))
+
+	storyID, _ := topStory(t, client)
+	target := "/save/" + strconv.Itoa(storyID)
+
+	if w := serveUser(save, http.MethodGet, target, nil, nil); w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("GET: want %d, got %d", http.StatusMethodNotAllowed, w.Code)
+	}
+
+	// Saving a story is how an anonymous reader gets an id.
+	w := serveUser(save, http.MethodPost, target, url.Values{"back": {"//example.com/"}}, nil)
+	if w.Code != http.StatusSeeOther || w.Header().Get("Location") != "/saved" {
+		t.Errorf("expected a redirect to /saved, got %d to %q", w.Code, w.Header().Get("Location"))
+	}
+
+	cookie := userCookieIn(w)
+	if cookie == nil || cookie.Secure || !cookie.HttpOnly {
+		t.Fatalf("expected an http only cookie, which isn't secure without TLS, got %v", cookie)
+	}
+
+	story, err := client.GetItem(storyID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body := serveUser(saved, http.MethodGet, "/saved", nil, cookie).Body.String()
+	if !strings.Contains(body, template.HTMLEscapeString(story.Title)) {
+		t.Errorf("expected the saved story on /saved, got %s", body)
+	}
+
+	// Saving it again unsaves it, and sends the reader back where they were.
+	w = serveUser(save, http.MethodPost, target, url.Values{"back": {"/new?page=2"}}, cookie)
+	if w.Header().Get("Location") != "/new?page=2" {
+		t.Errorf("expected a redirect back to /new?page=2, got %q", w.Header().Get("Location"))
+	}
+
+	body = serveUser(saved, http.MethodGet, "/saved", nil, cookie).Body.String()
+	if strings.Contains(body, "/read/"+strconv.Itoa(storyID)) {
+		t.Errorf("expected the unsaved story to be gone from /saved, got %s", body)
+	}
+}
+
+func TestPrefsHandler(t *testing.T) {
+	u := testUsers(t)
+	h := prefsHandler(u, template.Must(template.ParseFiles("prefs.html")))
+
+	w := serveUser(h, http.MethodPost, "/prefs", url.Values{
+		"feed":      {"best"},
+		"domains":   {"example.com,\n medium.com"},
+		"keywords":  {"crypto"},
+		"min_score": {"10"},
+	}, nil)
+
+	if !strings.Contains(w.Body.String(), "Your preferences were saved.") {
+		t.Fatalf("expected the preferences to be saved, got %s", w.Body.String())
+	}
+
+	cookie := userCookieIn(w)
+	if cookie == nil {
+		t.Fatalf("expected the reader to be given an id")
+	}
+
+	id, _ := u.signer.Verify(cookie.Value)
+
+	p, err := u.store.Get(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Feed != "best" || len(p.Filters.Domains) != 2 || p.Filters.MinScore != 10 {
+		t.Errorf("expected the preferences to be stored, got %+v", p)
+	}
+
+	body := serveUser(h, http.MethodGet, "/prefs", nil, cookie).Body.String()
+	if !strings.Contains(body, "example.com, medium.com") {
+		t.Errorf("expected the stored filters on /prefs, got %s", body)
+	}
+
+	for _, form := range []url.Values{{"min_score": {"-1"}}, {"feed": {"jobs"}}} {
+		w := serveUser(h, http.MethodPost, "/prefs", form, cookie)
+		if !strings.Contains(w.Body.String(), `class="error"`) {
+			t.Errorf("%v: expected an error, got %s", form, w.Body.String())
+		}
+	}
+
+	w = serveUser(h, http.MethodPost, "/prefs", url.Values{"forget": {"1"}}, cookie)
+	if c := userCookieIn(w); w.Code != http.StatusSeeOther || c == nil || c.MaxAge >= 0 {
+		t.Errorf("expected the cookie to be cleared, got %d and %v", w.Code, c)
+	}
+
+	if p, err := u.store.Get(id); err != nil || p.Feed != "" {
+		t.Errorf("expected the preferences to be forgotten, got %+v and %v", p, err)
+	}
+}